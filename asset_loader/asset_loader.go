@@ -12,7 +12,6 @@ import (
 
 	"github.com/3elDU/bamboo/config"
 	"github.com/3elDU/bamboo/types"
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func init() {
@@ -20,10 +19,12 @@ func init() {
 }
 
 type AssetList struct {
-	Textures          map[string]*ebiten.Image
-	ConnectedTextures map[connectedTexture]*ebiten.Image
+	Textures          map[string]*textureImage
+	ConnectedTextures map[connectedTexture]*textureImage
+	BlockDefinitions  map[string]BlockDefinition
+	RecipeDefinitions map[string]RecipeDefinition
 
-	Font *ebiten.Image
+	Font *textureImage
 }
 
 var (
@@ -36,10 +37,6 @@ func cleanPath(path string) string {
 	return strings.Replace(filepath.Base(path), filepath.Ext(path), "", 1)
 }
 
-func DefaultFont() *ebiten.Image {
-	return GlobalAssets.Font
-}
-
 // Texture panicks when a specified texture doesn't exist
 func Texture(name string) types.Texture {
 	_, exists := GlobalAssets.Textures[name]
@@ -0,0 +1,171 @@
+package asset_loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/3elDU/bamboo/types"
+	"golang.org/x/exp/slices"
+)
+
+// Block definitions are stored in this subdirectory of the asset directory
+const blockDefinitionsDirectory = "blocks"
+
+// BlockDefinition describes a block, composed from the generic building blocks
+// ( textured or connected texture, with optional collision ), so it doesn't need its own Go code.
+//
+// Example ( assets/blocks/stone.json ):
+//
+//	{
+//		"name": "stone",
+//		"texture": "stone",
+//		"connected": true,
+//		"connectsTo": ["stone"],
+//		"collidable": true
+//	}
+type BlockDefinition struct {
+	// Stable name, under which the block is registered
+	Name string `json:"name"`
+	// Name of the texture. For connected blocks, it is a name of the connected texture atlas
+	Texture string `json:"texture"`
+	// Optional texture, that is shown at night instead of Texture ( e.g. closed flowers )
+	// Connected blocks don't support it
+	NightTexture string `json:"nightTexture"`
+
+	// If set, the block uses connected texture, and connects to the blocks listed in ConnectsTo
+	Connected  bool     `json:"connected"`
+	ConnectsTo []string `json:"connectsTo"`
+
+	Collidable bool `json:"collidable"`
+	// How fast the player moves through the block, applicable only if the block is not collidable
+	// Defaults to 1
+	PlayerSpeed *float64 `json:"playerSpeed"`
+
+	// Light level, emitted by the block, up to types.MaxLightLevel
+	Light uint8 `json:"light"`
+
+	// Time to break the block without a tool, in seconds. Defaults to 1
+	Hardness *float64 `json:"hardness"`
+	// Tools of the category break the block faster. One of types.BlockCategory values
+	Category types.BlockCategory `json:"category"`
+	// Names of the items, dropped when the block is broken. Defaults to the block itself
+	Drops []string `json:"drops"`
+	// Name of the block, that is left after breaking. Defaults to the ground from the world generator
+	BreaksInto string `json:"breaksInto"`
+}
+
+var blockCategories = []types.BlockCategory{
+	types.NoCategory, types.StoneCategory, types.WoodCategory, types.GroundCategory, types.PlantCategory,
+}
+
+func parseBlockDefinition(assetList *AssetList, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	definition := BlockDefinition{}
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return fmt.Errorf("block definition %v - %w", path, err)
+	}
+
+	if _, exists := assetList.BlockDefinitions[definition.Name]; exists {
+		return fmt.Errorf("block definition %v - block %v is already defined", path, definition.Name)
+	}
+	if err := definition.setDefaults(); err != nil {
+		return fmt.Errorf("block definition %v - %w", path, err)
+	}
+
+	assetList.BlockDefinitions[definition.Name] = definition
+	return nil
+}
+
+// fills in the optional fields, and checks the ones that don't depend on other assets
+func (definition *BlockDefinition) setDefaults() error {
+	if definition.Name == "" {
+		return fmt.Errorf("name is not set")
+	}
+	if definition.PlayerSpeed == nil {
+		defaultSpeed := 1.0
+		definition.PlayerSpeed = &defaultSpeed
+	}
+	if definition.Hardness == nil {
+		defaultHardness := 1.0
+		definition.Hardness = &defaultHardness
+	}
+	// an empty list means that the block drops nothing
+	if definition.Drops == nil {
+		definition.Drops = []string{definition.Name}
+	}
+	if !slices.Contains(blockCategories, definition.Category) {
+		return fmt.Errorf("unknown category %q", definition.Category)
+	}
+	return nil
+}
+
+// checks that the textures, referenced by block definitions, exist
+// must be called after all the textures are loaded
+func validateBlockDefinitions(assetList *AssetList) error {
+	for _, definition := range assetList.BlockDefinitions {
+		if err := validateBlockDefinition(assetList, definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateBlockDefinition(assetList *AssetList, definition BlockDefinition) error {
+	if definition.Light > types.MaxLightLevel {
+		return fmt.Errorf("block %v - light level %v is higher than %v", definition.Name, definition.Light, types.MaxLightLevel)
+	}
+
+	if definition.Connected {
+		tex := connectedTexture{baseName: definition.Texture}
+		if _, exists := assetList.ConnectedTextures[tex]; !exists {
+			return fmt.Errorf("block %v - connected texture %v doesn't exist", definition.Name, definition.Texture)
+		}
+		if definition.NightTexture != "" {
+			return fmt.Errorf("block %v - connected blocks can't have a night texture", definition.Name)
+		}
+		return nil
+	}
+
+	if _, exists := assetList.Textures[definition.Texture]; !exists {
+		return fmt.Errorf("block %v - texture %v doesn't exist", definition.Name, definition.Texture)
+	}
+	if _, exists := assetList.Textures[definition.NightTexture]; definition.NightTexture != "" && !exists {
+		return fmt.Errorf("block %v - night texture %v doesn't exist", definition.Name, definition.NightTexture)
+	}
+	return nil
+}
+
+// PrepareBlockDefinition fills in the defaults, and validates a definition, that doesn't come from the asset directory,
+// e.g. a block registered by a mod. The textures must be already loaded
+func PrepareBlockDefinition(definition BlockDefinition) (BlockDefinition, error) {
+	if err := definition.setDefaults(); err != nil {
+		return BlockDefinition{}, fmt.Errorf("block %v - %w", definition.Name, err)
+	}
+	if err := validateBlockDefinition(GlobalAssets, definition); err != nil {
+		return BlockDefinition{}, err
+	}
+	return definition, nil
+}
+
+func isBlockDefinition(path string) bool {
+	return filepath.Ext(path) == ".json" && filepath.Base(filepath.Dir(path)) == blockDefinitionsDirectory
+}
+
+// BlockDefinitions returns all loaded block definitions, sorted by name
+func BlockDefinitions() []BlockDefinition {
+	definitions := make([]BlockDefinition, 0, len(GlobalAssets.BlockDefinitions))
+	for _, definition := range GlobalAssets.BlockDefinitions {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions
+}
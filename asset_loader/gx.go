@@ -0,0 +1,33 @@
+//go:build !headless
+
+package asset_loader
+
+import (
+	"image"
+
+	"github.com/3elDU/bamboo/config"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type textureImage = ebiten.Image
+
+func newTextureImage(img image.Image) *textureImage {
+	return ebiten.NewImageFromImage(img)
+}
+
+func DefaultFont() *ebiten.Image {
+	return GlobalAssets.Font
+}
+
+func (t *texture) Texture() *ebiten.Image {
+	return GlobalAssets.Textures[t.name]
+}
+
+func (t *texture) ScaledSize() (float64, float64) {
+	w, h := t.Texture().Size()
+	return float64(w) * config.UIScaling, float64(h) * config.UIScaling
+}
+
+func (t *connectedTexture) Texture() *ebiten.Image {
+	return GlobalAssets.ConnectedTextures[*t]
+}
@@ -0,0 +1,13 @@
+//go:build headless
+
+package asset_loader
+
+import "image"
+
+// Without the graphics, only the names of the textures are needed,
+// so that blocks can check, whether their textures exist
+type textureImage struct{}
+
+func newTextureImage(image.Image) *textureImage {
+	return &textureImage{}
+}
@@ -2,15 +2,19 @@ package asset_loader
 
 import (
 	"bytes"
+	"fmt"
 	"image"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
-
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
+// implemented by all the images, returned from png decoder
+type subImager interface {
+	SubImage(r image.Rectangle) image.Image
+}
+
 func parseTexture(assetList *AssetList, path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -21,7 +25,7 @@ func parseTexture(assetList *AssetList, path string) error {
 	if err != nil {
 		return err
 	}
-	assetList.Textures[cleanPath(path)] = ebiten.NewImageFromImage(img)
+	assetList.Textures[cleanPath(path)] = newTextureImage(img)
 
 	return nil
 }
@@ -38,7 +42,10 @@ func parseConnectedTexture(assetList *AssetList, path string) error {
 		return err
 	}
 
-	tex := ebiten.NewImageFromImage(img)
+	tex, ok := img.(subImager)
+	if !ok {
+		return fmt.Errorf("%v - unsupported image type %T", path, img)
+	}
 
 	// texture map describes, which sub-texture is on which coordinate
 	// first and second indices represent coordinates ( multiples of 16 ) on an atlas
@@ -56,14 +63,14 @@ func parseConnectedTexture(assetList *AssetList, path string) error {
 			assetList.ConnectedTextures[connectedTexture{
 				baseName:       cleanPath(path),
 				connectedSides: col,
-			}] = ebiten.NewImageFromImage(
+			}] = newTextureImage(
 				tex.SubImage(image.Rect(x*16, y*16, x*16+16, y*16+16)),
 			)
 		}
 	}
 
 	// also save a texture with no connected sides, as a regular texture
-	assetList.Textures[cleanPath(path)] = ebiten.NewImageFromImage(tex.SubImage(
+	assetList.Textures[cleanPath(path)] = newTextureImage(tex.SubImage(
 		image.Rect(0, 0, 16, 16),
 	))
 
@@ -73,8 +80,10 @@ func parseConnectedTexture(assetList *AssetList, path string) error {
 // LoadAssets loads assets from directory dir to global variable GlobalAssets
 func LoadAssets(dir string) {
 	assetList := &AssetList{
-		Textures:          make(map[string]*ebiten.Image),
-		ConnectedTextures: make(map[connectedTexture]*ebiten.Image),
+		Textures:          make(map[string]*textureImage),
+		ConnectedTextures: make(map[connectedTexture]*textureImage),
+		BlockDefinitions:  make(map[string]BlockDefinition),
+		RecipeDefinitions: make(map[string]RecipeDefinition),
 	}
 
 	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
@@ -90,6 +99,13 @@ func LoadAssets(dir string) {
 		switch filepath.Ext(path) {
 		case ".png":
 			return parseTexture(assetList, path)
+		case ".json":
+			if isBlockDefinition(path) {
+				return parseBlockDefinition(assetList, path)
+			}
+			if isRecipeDefinition(path) {
+				return parseRecipeDefinition(assetList, path)
+			}
 		}
 
 		return nil
@@ -98,6 +114,10 @@ func LoadAssets(dir string) {
 		log.Panicln(err)
 	}
 
+	if err := validateBlockDefinitions(assetList); err != nil {
+		log.Panicln(err)
+	}
+
 	font, exists := assetList.Textures["font"]
 	if !exists {
 		log.Panicln("cannot find the font texture")
@@ -106,3 +126,41 @@ func LoadAssets(dir string) {
 
 	GlobalAssets = assetList
 }
+
+// LoadModTextures adds textures and connected texture atlases from the directory to GlobalAssets.
+// Unlike LoadAssets, it returns an error instead of panicking, and doesn't allow to replace the existing textures
+func LoadModTextures(dir string) error {
+	modAssets := &AssetList{
+		Textures:          make(map[string]*textureImage),
+		ConnectedTextures: make(map[connectedTexture]*textureImage),
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return parseConnectedTexture(modAssets, path)
+		}
+		if filepath.Ext(path) == ".png" {
+			return parseTexture(modAssets, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for name := range modAssets.Textures {
+		if _, exists := GlobalAssets.Textures[name]; exists {
+			return fmt.Errorf("texture %v already exists", name)
+		}
+	}
+	for name, tex := range modAssets.Textures {
+		GlobalAssets.Textures[name] = tex
+	}
+	for tex, img := range modAssets.ConnectedTextures {
+		GlobalAssets.ConnectedTextures[tex] = img
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package asset_loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Crafting recipes are stored in this subdirectory of the asset directory
+const recipeDefinitionsDirectory = "recipes"
+
+// RecipeDefinition describes a crafting recipe. The name of the recipe is the name of the file.
+// Item names are checked by the crafting package, since the items aren't known there.
+//
+// Shaped recipe ( assets/recipes/pickaxe.json ), each character of the pattern is a key, space is an empty cell:
+//
+//	{
+//		"pattern": ["SSS", " T ", " T "],
+//		"key": {"S": "stone", "T": "stick"},
+//		"result": {"item": "pickaxe"}
+//	}
+//
+// Shapeless recipe ( assets/recipes/planks.json ):
+//
+//	{
+//		"ingredients": [{"item": "pine_tree"}],
+//		"result": {"item": "planks", "count": 4}
+//	}
+type RecipeDefinition struct {
+	Name string `json:"-"`
+
+	// Only one of Pattern and Ingredients is set
+	Pattern     []string          `json:"pattern"`
+	Key         map[string]string `json:"key"`
+	Ingredients []RecipeItem      `json:"ingredients"`
+
+	Result RecipeItem `json:"result"`
+}
+
+type RecipeItem struct {
+	Item string `json:"item"`
+	// Defaults to 1
+	Count int `json:"count"`
+}
+
+// IngredientCounts returns how many items of each name the recipe takes
+func (definition RecipeDefinition) IngredientCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, row := range definition.Pattern {
+		for _, key := range row {
+			if key != ' ' {
+				counts[definition.Key[string(key)]]++
+			}
+		}
+	}
+	for _, ingredient := range definition.Ingredients {
+		counts[ingredient.Item] += ingredient.Count
+	}
+	return counts
+}
+
+func parseRecipeDefinition(assetList *AssetList, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	definition := RecipeDefinition{Name: cleanPath(path)}
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return fmt.Errorf("recipe %v - %w", path, err)
+	}
+
+	if (len(definition.Pattern) == 0) == (len(definition.Ingredients) == 0) {
+		return fmt.Errorf("recipe %v - exactly one of pattern and ingredients must be set", path)
+	}
+	for _, row := range definition.Pattern {
+		for _, key := range row {
+			if _, exists := definition.Key[string(key)]; key != ' ' && !exists {
+				return fmt.Errorf("recipe %v - key %q is not defined", path, key)
+			}
+		}
+	}
+	for i := range definition.Ingredients {
+		if definition.Ingredients[i].Count == 0 {
+			definition.Ingredients[i].Count = 1
+		}
+	}
+	if definition.Result.Item == "" {
+		return fmt.Errorf("recipe %v - result is not set", path)
+	}
+	if definition.Result.Count == 0 {
+		definition.Result.Count = 1
+	}
+
+	assetList.RecipeDefinitions[definition.Name] = definition
+	return nil
+}
+
+func isRecipeDefinition(path string) bool {
+	return filepath.Ext(path) == ".json" && filepath.Base(filepath.Dir(path)) == recipeDefinitionsDirectory
+}
+
+// RecipeDefinitions returns all loaded recipes, sorted by name
+func RecipeDefinitions() []RecipeDefinition {
+	definitions := make([]RecipeDefinition, 0, len(GlobalAssets.RecipeDefinitions))
+	for _, definition := range GlobalAssets.RecipeDefinitions {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions
+}
@@ -7,6 +7,7 @@ package blocks
 
 import (
 	"encoding/gob"
+	"fmt"
 
 	"github.com/3elDU/bamboo/types"
 )
@@ -28,7 +29,7 @@ type baseBlock struct {
 	// Block coordinates in world space
 	x, y uint
 
-	// Block types are defined in (blocks.go):13
+	// Block types are defined in blocks.go, and registered in the registry ( registry.go )
 	// Each block must specify its type, so that we can actually know what the block it is
 	// ( Remember, all blocks are the same interface )
 	blockType types.BlockType
@@ -68,7 +69,16 @@ func (b *baseBlock) State() interface{} {
 	}
 }
 
-func (b *baseBlock) LoadState(s interface{}) {
-	state := s.(BaseBlockState)
-	b.blockType = state.BlockType
+func (b *baseBlock) LoadState(s interface{}) error {
+	if _, ok := s.(BaseBlockState); !ok {
+		return invalidStateError(b, BaseBlockState{}, s)
+	}
+	// block type is set by the constructor.
+	// the saved one is kept only for compatibility, it may come from a different numbering
+	return nil
+}
+
+// invalidStateError is returned by LoadState, when it receives a state of the wrong type
+func invalidStateError(block interface{}, expected interface{}, got interface{}) error {
+	return fmt.Errorf("%T - invalid state type; expected %T, got %T", block, expected, got)
 }
@@ -2,9 +2,10 @@ package blocks
 
 import (
 	"github.com/3elDU/bamboo/types"
-	"github.com/google/uuid"
 )
 
+// Block types are only valid at runtime, and can be reordered freely.
+// Each block also registers a stable name in the registry, which is used in saves
 const (
 	Empty types.BlockType = iota
 	Stone
@@ -21,42 +22,21 @@ const (
 	CaveEntrance
 	CaveWall
 	CaveFloor
+	GlowingMushroom
+
+	// blocks from assets/blocks, which aren't referenced from the code, get types starting from here
+	firstDefinedType
 )
 
-// GetBlockByID returns an empty block
-func GetBlockByID(id types.BlockType) types.Block {
-	switch id {
-	case Empty:
-		return NewEmptyBlock()
-	case Stone:
-		return NewStoneBlock()
-	case Water:
-		return NewWaterBlock()
-	case Sand:
-		return NewSandBlock(false)
-	case Grass:
-		return NewGrassBlock()
-	case Snow:
-		return NewSnowBlock()
-	case ShortGrass:
-		return NewShortGrassBlock()
-	case TallGrass:
-		return NewTallGrassBlock()
-	case Flowers:
-		return NewFlowersBlock()
-	case PineTree:
-		return NewPineTreeBlock()
-	case RedMushroom:
-		return NewRedMushroomBlock()
-	case WhiteMushroom:
-		return NewWhiteMushroomBlock()
-	case CaveEntrance:
-		return NewCaveEntranceBlock(uuid.New())
-	case CaveWall:
-		return NewCaveWallBlock()
-	case CaveFloor:
-		return NewCaveFloorBlock()
-	}
+// Blocks, that are defined in assets/blocks, but are referenced from the code
+var definedTypes = map[string]types.BlockType{
+	"stone":       Stone,
+	"snow":        Snow,
+	"short_grass": ShortGrass,
+	"tall_grass":  TallGrass,
+	"flowers":     Flowers,
+	"pine_tree":   PineTree,
+	"cave_wall":   CaveWall,
 
-	return NewEmptyBlock()
+	"glowing_mushroom": GlowingMushroom,
 }
@@ -0,0 +1,41 @@
+package blocks
+
+import (
+	"github.com/3elDU/bamboo/types"
+)
+
+// Base structure for blocks, that can be broken by the player.
+// Everything is set by the constructor, so there is no state to save
+type breakableBlock struct {
+	// time to break the block by hand, in seconds
+	hardness float64
+	category types.BlockCategory
+	// names of the dropped items
+	drops []string
+	// name of the block, that is left after breaking. Empty means the ground from the world generator,
+	// so the ground blocks can be broken only where they were placed by the player, on top of other ground
+	breaksInto string
+}
+
+func (b *breakableBlock) Hardness() float64 {
+	return b.hardness
+}
+
+func (b *breakableBlock) Category() types.BlockCategory {
+	return b.category
+}
+
+func (b *breakableBlock) Drops() []string {
+	return b.drops
+}
+
+func (b *breakableBlock) BreaksInto() types.Block {
+	if b.breaksInto == "" {
+		return nil
+	}
+	block, exists := GetBlockByName(b.breaksInto)
+	if !exists {
+		return nil
+	}
+	return block
+}
@@ -1,7 +1,6 @@
 package blocks
 
 import (
-	"encoding/gob"
 	"github.com/3elDU/bamboo/asset_loader"
 	"github.com/3elDU/bamboo/event"
 	"github.com/3elDU/bamboo/types"
@@ -9,7 +8,7 @@ import (
 )
 
 func init() {
-	gob.Register(CaveEntranceState{})
+	Register("cave_entrance", CaveEntrance, func() types.Block { return NewCaveEntranceBlock(uuid.New()) }, CaveEntranceState{})
 }
 
 type CaveEntranceState struct {
@@ -45,18 +44,27 @@ func (cave *CaveEntranceBlock) State() interface{} {
 	}
 }
 
-func (cave *CaveEntranceBlock) LoadState(s interface{}) {
-	state := s.(CaveEntranceState)
-	cave.baseBlock.LoadState(state.BaseBlockState)
-	cave.texturedBlock.LoadState(state.TexturedBlockState)
+func (cave *CaveEntranceBlock) LoadState(s interface{}) error {
+	state, ok := s.(CaveEntranceState)
+	if !ok {
+		return invalidStateError(cave, CaveEntranceState{}, s)
+	}
+	if err := cave.baseBlock.LoadState(state.BaseBlockState); err != nil {
+		return err
+	}
+	if err := cave.texturedBlock.LoadState(state.TexturedBlockState); err != nil {
+		return err
+	}
 	cave.id = state.ID
+	return nil
 }
 
-func (cave *CaveEntranceBlock) Interact(_ types.World, _ types.Vec2f) {
-	event.FireEvent(event.NewEvent(
-		event.CaveEntered,
-		event.CaveEnteredArgs{
-			ID: cave.id,
-		},
-	))
+// LightLevel implements types.LightEmitter
+// In caves, daylight falls through the exit
+func (cave *CaveEntranceBlock) LightLevel() uint8 {
+	return 12
+}
+
+func (cave *CaveEntranceBlock) Interact(world types.World, _ types.Vec2f) {
+	event.Fire(world.Events(), &types.CaveEnteredEvent{ID: cave.id})
 }
@@ -1,13 +1,12 @@
 package blocks
 
 import (
-	"encoding/gob"
-
 	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/types"
 )
 
 func init() {
-	gob.Register(CaveFloorState{})
+	Register("cave_floor", CaveFloor, func() types.Block { return NewCaveFloorBlock() }, CaveFloorState{})
 }
 
 type CaveFloorState struct {
@@ -18,6 +17,7 @@ type CaveFloorState struct {
 type CaveFloorBlock struct {
 	baseBlock
 	texturedBlock
+	breakableBlock
 }
 
 func NewCaveFloorBlock() *CaveFloorBlock {
@@ -29,6 +29,11 @@ func NewCaveFloorBlock() *CaveFloorBlock {
 			tex:      asset_loader.Texture("cave_floor"),
 			rotation: 0,
 		},
+		breakableBlock: breakableBlock{
+			hardness: 0.6,
+			category: types.GroundCategory,
+			drops:    []string{"cave_floor"},
+		},
 	}
 }
 
@@ -39,8 +44,13 @@ func (b *CaveFloorBlock) State() interface{} {
 	}
 }
 
-func (b *CaveFloorBlock) LoadState(s interface{}) {
-	state := s.(CaveFloorState)
-	b.baseBlock.LoadState(state.BaseBlockState)
-	b.texturedBlock.LoadState(state.TexturedBlockState)
+func (b *CaveFloorBlock) LoadState(s interface{}) error {
+	state, ok := s.(CaveFloorState)
+	if !ok {
+		return invalidStateError(b, CaveFloorState{}, s)
+	}
+	if err := b.baseBlock.LoadState(state.BaseBlockState); err != nil {
+		return err
+	}
+	return b.texturedBlock.LoadState(state.TexturedBlockState)
 }
@@ -66,9 +66,13 @@ func (b *collidableBlock) State() interface{} {
 	}
 }
 
-func (b *collidableBlock) LoadState(s interface{}) {
-	state := s.(CollidableBlockState)
+func (b *collidableBlock) LoadState(s interface{}) error {
+	state, ok := s.(CollidableBlockState)
+	if !ok {
+		return invalidStateError(b, CollidableBlockState{}, s)
+	}
 	b.collidable = state.Collidable
 	b.collisionPoints = state.CollisionPoints
 	b.playerSpeed = state.PlayerSpeed
+	return nil
 }
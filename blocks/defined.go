@@ -0,0 +1,199 @@
+/*
+	Blocks, defined in data files ( assets/blocks/*.json ).
+	They are composed from connectedBlock, texturedBlock, collidableBlock and breakableBlock,
+	so adding such a block doesn't require any Go code.
+*/
+
+package blocks
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/types"
+)
+
+func init() {
+	gob.Register(DefinedBlockState{})
+
+	for _, definition := range asset_loader.BlockDefinitions() {
+		blockType, exists := definedTypes[definition.Name]
+		if !exists {
+			blockType = nextDefinedType
+			nextDefinedType++
+		}
+
+		d := &blockDefinition{BlockDefinition: definition, blockType: blockType}
+		Register(definition.Name, blockType, func() types.Block { return newDefinedBlock(d) }, nil)
+	}
+
+	for name := range definedTypes {
+		if _, exists := blocksByName[name]; !exists {
+			log.Panicf("block %v is referenced from the code, but it isn't defined in %v", name, config.AssetDirectory)
+		}
+	}
+	for _, definition := range asset_loader.BlockDefinitions() {
+		if _, exists := blocksByName[definition.BreaksInto]; definition.BreaksInto != "" && !exists {
+			log.Panicf("block %v breaks into unknown block %v", definition.Name, definition.BreaksInto)
+		}
+	}
+}
+
+// Type of the next block from assets/blocks, or the next block registered with RegisterDefinition()
+var nextDefinedType = firstDefinedType
+
+// InteractFunc is called, when the player walks into a block, registered with RegisterDefinition()
+type InteractFunc func(world types.World, block types.Vec2u, playerPosition types.Vec2f)
+
+// RegisterDefinition registers a block, that is defined at runtime, e.g. by a mod.
+// The definition is validated the same way as the ones from assets/blocks, but errors are returned instead of panicking.
+// interact can be nil
+func RegisterDefinition(definition asset_loader.BlockDefinition, interact InteractFunc) error {
+	definition, err := asset_loader.PrepareBlockDefinition(definition)
+	if err != nil {
+		return err
+	}
+	if _, exists := blocksByName[definition.Name]; exists {
+		return fmt.Errorf("block %v is already registered", definition.Name)
+	}
+	for _, name := range append(definition.ConnectsTo, definition.BreaksInto) {
+		if _, exists := blocksByName[name]; name != "" && !exists {
+			return fmt.Errorf("block %v refers to unknown block %v", definition.Name, name)
+		}
+	}
+
+	d := &blockDefinition{BlockDefinition: definition, blockType: nextDefinedType, interact: interact}
+	nextDefinedType++
+	Register(definition.Name, d.blockType, func() types.Block {
+		if d.interact != nil {
+			return &interactiveDefinedBlock{definedBlock: newDefinedBlock(d)}
+		}
+		return newDefinedBlock(d)
+	}, nil)
+	return nil
+}
+
+type DefinedBlockState struct {
+	BaseBlockState
+	Rotation float64
+}
+
+type blockDefinition struct {
+	asset_loader.BlockDefinition
+	blockType types.BlockType
+
+	// connectsTo may refer to blocks that aren't registered yet,
+	// so the names are resolved on the first use
+	resolveOnce sync.Once
+	connectsTo  []types.BlockType
+
+	// nil for the blocks from assets/blocks
+	interact InteractFunc
+}
+
+func (d *blockDefinition) resolveConnectsTo() []types.BlockType {
+	d.resolveOnce.Do(func() {
+		for _, name := range d.ConnectsTo {
+			blockType, exists := TypeByName(name)
+			if !exists {
+				log.Panicf("block %v connects to unknown block %v", d.Name, name)
+			}
+			d.connectsTo = append(d.connectsTo, blockType)
+		}
+	})
+	return d.connectsTo
+}
+
+type definedBlock struct {
+	// only one of the textures is used, depending on definition.Connected
+	connectedBlock
+	texturedBlock
+	collidableBlock
+	breakableBlock
+	// nil, if the block looks the same at night
+	nightTex types.Texture
+
+	definition *blockDefinition
+}
+
+func newDefinedBlock(definition *blockDefinition) *definedBlock {
+	b := &definedBlock{
+		connectedBlock: connectedBlock{
+			baseBlock: baseBlock{
+				blockType: definition.blockType,
+			},
+		},
+		collidableBlock: collidableBlock{
+			collidable:  definition.Collidable,
+			playerSpeed: *definition.PlayerSpeed,
+		},
+		breakableBlock: breakableBlock{
+			hardness:   *definition.Hardness,
+			category:   definition.Category,
+			drops:      definition.Drops,
+			breaksInto: definition.BreaksInto,
+		},
+		definition: definition,
+	}
+
+	if definition.Connected {
+		b.connectedBlock.tex = asset_loader.ConnectedTexture(definition.Texture, false, false, false, false)
+		b.connectedBlock.connectsTo = definition.resolveConnectsTo()
+	} else {
+		b.texturedBlock.tex = asset_loader.Texture(definition.Texture)
+	}
+	if definition.NightTexture != "" {
+		b.nightTex = asset_loader.Texture(definition.NightTexture)
+	}
+	if definition.Collidable {
+		b.collidableBlock.collisionPoints = defaultCollisionPoints()
+	}
+
+	return b
+}
+
+// LightLevel implements types.LightEmitter
+func (b *definedBlock) LightLevel() uint8 {
+	return b.definition.Light
+}
+
+func (b *definedBlock) TextureName() string {
+	if b.definition.Connected {
+		return b.connectedBlock.TextureName()
+	}
+	return b.texturedBlock.TextureName()
+}
+
+// everything else is taken from the definition, so only the rotation is saved
+func (b *definedBlock) State() interface{} {
+	return DefinedBlockState{
+		BaseBlockState: b.baseBlock.State().(BaseBlockState),
+		Rotation:       b.texturedBlock.rotation,
+	}
+}
+
+func (b *definedBlock) LoadState(s interface{}) error {
+	state, ok := s.(DefinedBlockState)
+	if !ok {
+		return invalidStateError(b, DefinedBlockState{}, s)
+	}
+	if err := b.baseBlock.LoadState(state.BaseBlockState); err != nil {
+		return err
+	}
+	b.texturedBlock.rotation = state.Rotation
+	return nil
+}
+
+// interactiveDefinedBlock is a defined block with an InteractFunc.
+// It is a separate type, so that only such blocks implement types.InteractiveBlock
+type interactiveDefinedBlock struct {
+	*definedBlock
+}
+
+func (b *interactiveDefinedBlock) Interact(world types.World, playerPosition types.Vec2f) {
+	b.definition.interact(world, b.Coords(), playerPosition)
+}
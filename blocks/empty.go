@@ -8,6 +8,10 @@ import (
 	"github.com/3elDU/bamboo/types"
 )
 
+func init() {
+	Register("empty", Empty, func() types.Block { return NewEmptyBlock() }, nil)
+}
+
 type EmptyBlock struct {
 	baseBlock
 }
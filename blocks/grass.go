@@ -1,14 +1,12 @@
 package blocks
 
 import (
-	"encoding/gob"
-
 	"github.com/3elDU/bamboo/asset_loader"
 	"github.com/3elDU/bamboo/types"
 )
 
 func init() {
-	gob.Register(GrassBlockState{})
+	Register("grass", Grass, func() types.Block { return NewGrassBlock() }, GrassBlockState{})
 }
 
 type GrassBlockState struct {
@@ -19,6 +17,7 @@ type GrassBlockState struct {
 type GrassBlock struct {
 	connectedBlock
 	collidableBlock
+	breakableBlock
 }
 
 func NewGrassBlock() *GrassBlock {
@@ -40,6 +39,11 @@ func NewGrassBlock() *GrassBlock {
 			collidable:  false,
 			playerSpeed: 1,
 		},
+		breakableBlock: breakableBlock{
+			hardness: 0.6,
+			category: types.GroundCategory,
+			drops:    []string{"grass"},
+		},
 	}
 }
 
@@ -50,8 +54,13 @@ func (b *GrassBlock) State() interface{} {
 	}
 }
 
-func (b *GrassBlock) LoadState(s interface{}) {
-	state := s.(GrassBlockState)
-	b.connectedBlock.LoadState(state.ConnectedBlockState)
-	b.collidableBlock.LoadState(state.CollidableBlockState)
+func (b *GrassBlock) LoadState(s interface{}) error {
+	state, ok := s.(GrassBlockState)
+	if !ok {
+		return invalidStateError(b, GrassBlockState{}, s)
+	}
+	if err := b.connectedBlock.LoadState(state.ConnectedBlockState); err != nil {
+		return err
+	}
+	return b.collidableBlock.LoadState(state.CollidableBlockState)
 }
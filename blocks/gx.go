@@ -0,0 +1,65 @@
+//go:build !headless
+
+// Rendering of the blocks. It is left out, when building with the "headless" tag
+
+package blocks
+
+import (
+	"math"
+
+	"github.com/3elDU/bamboo/types"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func (b *texturedBlock) Render(_ types.World, screen *ebiten.Image, pos types.Vec2f) {
+	opts := &ebiten.DrawImageOptions{}
+
+	if b.rotation != 0 {
+		w, h := b.tex.Texture().Size()
+		// Move image half a texture size, so that rotation origin will be in the center
+		opts.GeoM.Translate(float64(-w/2), float64(-h/2))
+		opts.GeoM.Rotate(b.rotation * (math.Pi / 180))
+		pos.X += float64(w / 2)
+		pos.Y += float64(h / 2)
+	}
+
+	opts.GeoM.Translate(pos.X, pos.Y)
+
+	screen.DrawImage(b.tex.Texture(), opts)
+}
+
+func (b *connectedBlock) Render(world types.World, screen *ebiten.Image, pos types.Vec2f) {
+	var connectedSides [4]bool
+	for i, side := range [4]types.Vec2i{{X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: -1}, {X: 0, Y: 1}} {
+		x, y := int64(b.x)+side.X, int64(b.y)+side.Y
+		neighbor := world.BlockAt(uint64(x), uint64(y))
+		if !b.shouldConnect(neighbor.Type()) {
+			continue
+		}
+
+		connectedSides[i] = true
+		// If neighbor is on another chunk, trigger redraw of that chunk
+		if neighbor.ParentChunk() != b.parentChunk {
+			neighbor.ParentChunk().TriggerRedraw()
+		}
+	}
+	b.tex.SetConnectedSides(connectedSides)
+
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Translate(pos.X, pos.Y)
+	screen.DrawImage(b.tex.Texture(), opts)
+}
+
+func (b *definedBlock) Render(world types.World, screen *ebiten.Image, pos types.Vec2f) {
+	switch {
+	case b.definition.Connected:
+		b.connectedBlock.Render(world, screen, pos)
+	case b.nightTex != nil && world.Time().IsNight():
+		// keep the rotation of the day texture
+		night := b.texturedBlock
+		night.tex = b.nightTex
+		night.Render(world, screen, pos)
+	default:
+		b.texturedBlock.Render(world, screen, pos)
+	}
+}
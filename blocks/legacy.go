@@ -0,0 +1,75 @@
+/*
+	States of the blocks, which were moved to data files ( see defined.go ).
+	They are kept only to decode old saves, and are upgraded to DefinedBlockState by a migration
+*/
+
+package blocks
+
+import "encoding/gob"
+
+func init() {
+	gob.Register(StoneState{})
+	gob.Register(PineTreeState{})
+	gob.Register(CaveWallState{})
+	gob.Register(SnowState{})
+	gob.Register(ShortGrassState{})
+	gob.Register(TallGrassState{})
+	gob.Register(FlowersState{})
+}
+
+type StoneState struct {
+	ConnectedBlockState
+	CollidableBlockState
+}
+
+type PineTreeState struct {
+	ConnectedBlockState
+	CollidableBlockState
+}
+
+type CaveWallState struct {
+	ConnectedBlockState
+	CollidableBlockState
+}
+
+type SnowState struct {
+	BaseBlockState
+	TexturedBlockState
+}
+
+type ShortGrassState struct {
+	BaseBlockState
+	TexturedBlockState
+}
+
+type TallGrassState struct {
+	BaseBlockState
+	TexturedBlockState
+}
+
+type FlowersState struct {
+	BaseBlockState
+	TexturedBlockState
+}
+
+// UpgradeLegacyState converts a state of the block, that was moved to a data file, to DefinedBlockState
+// Other states are returned as is
+func UpgradeLegacyState(state interface{}) interface{} {
+	switch s := state.(type) {
+	case StoneState:
+		return DefinedBlockState{BaseBlockState: s.BaseBlockState}
+	case PineTreeState:
+		return DefinedBlockState{BaseBlockState: s.BaseBlockState}
+	case CaveWallState:
+		return DefinedBlockState{BaseBlockState: s.BaseBlockState}
+	case SnowState:
+		return DefinedBlockState{BaseBlockState: s.BaseBlockState, Rotation: s.Rotation}
+	case ShortGrassState:
+		return DefinedBlockState{BaseBlockState: s.BaseBlockState, Rotation: s.Rotation}
+	case TallGrassState:
+		return DefinedBlockState{BaseBlockState: s.BaseBlockState, Rotation: s.Rotation}
+	case FlowersState:
+		return DefinedBlockState{BaseBlockState: s.BaseBlockState, Rotation: s.Rotation}
+	}
+	return state
+}
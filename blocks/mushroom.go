@@ -1,13 +1,14 @@
 package blocks
 
 import (
-	"encoding/gob"
-
 	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/types"
 )
 
 func init() {
-	gob.Register(MushroomState{})
+	Register("red_mushroom", RedMushroom, func() types.Block { return NewRedMushroomBlock() }, MushroomState{})
+	// both mushrooms share the same state type, so it is registered only once
+	Register("white_mushroom", WhiteMushroom, func() types.Block { return NewWhiteMushroomBlock() }, nil)
 }
 
 type MushroomState struct {
@@ -18,6 +19,7 @@ type MushroomState struct {
 type MushroomBlock struct {
 	baseBlock
 	texturedBlock
+	breakableBlock
 }
 
 func NewRedMushroomBlock() *MushroomBlock {
@@ -28,6 +30,11 @@ func NewRedMushroomBlock() *MushroomBlock {
 		texturedBlock: texturedBlock{
 			tex: asset_loader.Texture("red-mushroom"),
 		},
+		breakableBlock: breakableBlock{
+			hardness: 0.2,
+			category: types.PlantCategory,
+			drops:    []string{"red_mushroom"},
+		},
 	}
 }
 
@@ -39,6 +46,11 @@ func NewWhiteMushroomBlock() *MushroomBlock {
 		texturedBlock: texturedBlock{
 			tex: asset_loader.Texture("white-mushroom"),
 		},
+		breakableBlock: breakableBlock{
+			hardness: 0.2,
+			category: types.PlantCategory,
+			drops:    []string{"white_mushroom"},
+		},
 	}
 }
 
@@ -49,8 +61,13 @@ func (b *MushroomBlock) State() interface{} {
 	}
 }
 
-func (b *MushroomBlock) LoadState(s interface{}) {
-	state := s.(MushroomState)
-	b.baseBlock.LoadState(state.BaseBlockState)
-	b.texturedBlock.LoadState(state.TexturedBlockState)
+func (b *MushroomBlock) LoadState(s interface{}) error {
+	state, ok := s.(MushroomState)
+	if !ok {
+		return invalidStateError(b, MushroomState{}, s)
+	}
+	if err := b.baseBlock.LoadState(state.BaseBlockState); err != nil {
+		return err
+	}
+	return b.texturedBlock.LoadState(state.TexturedBlockState)
 }
@@ -0,0 +1,89 @@
+/*
+	Block registry.
+	Each block registers a stable string name, along with its constructor and state type.
+	Numeric block types are only valid while the game is running,
+	saves refer to blocks by their names ( see world.palette ).
+*/
+
+package blocks
+
+import (
+	"encoding/gob"
+	"log"
+
+	"github.com/3elDU/bamboo/types"
+)
+
+type registeredBlock struct {
+	name        string
+	blockType   types.BlockType
+	constructor func() types.Block
+}
+
+var (
+	blocksByName = make(map[string]*registeredBlock)
+	blocksByType = make(map[types.BlockType]*registeredBlock)
+)
+
+// Register adds a block to the registry.
+// name must never change after the block is released, since saves reference blocks by it.
+// state is a zero value of the block state type, it is registered with gob. Can be nil,
+// if the block doesn't have its own state type.
+func Register(name string, blockType types.BlockType, constructor func() types.Block, state interface{}) {
+	if _, exists := blocksByName[name]; exists {
+		log.Panicf("blocks.Register() - block with name %v is already registered", name)
+	}
+	if existing, exists := blocksByType[blockType]; exists {
+		log.Panicf("blocks.Register() - block type %v is already registered as %v", blockType, existing.name)
+	}
+
+	if state != nil {
+		gob.Register(state)
+	}
+
+	block := &registeredBlock{
+		name:        name,
+		blockType:   blockType,
+		constructor: constructor,
+	}
+	blocksByName[name] = block
+	blocksByType[blockType] = block
+}
+
+// GetBlockByID returns a new block of the given type
+// If the type is unknown, returns an empty block
+func GetBlockByID(id types.BlockType) types.Block {
+	block, exists := blocksByType[id]
+	if !exists {
+		return NewEmptyBlock()
+	}
+	return block.constructor()
+}
+
+// GetBlockByName returns a new block with the given name
+// The second value is false, if there is no such block
+func GetBlockByName(name string) (types.Block, bool) {
+	block, exists := blocksByName[name]
+	if !exists {
+		return nil, false
+	}
+	return block.constructor(), true
+}
+
+// Name returns the registered name of the block type, or an empty string if the type is unknown
+func Name(id types.BlockType) string {
+	block, exists := blocksByType[id]
+	if !exists {
+		return ""
+	}
+	return block.name
+}
+
+// TypeByName returns the block type, registered with the given name
+func TypeByName(name string) (types.BlockType, bool) {
+	block, exists := blocksByName[name]
+	if !exists {
+		return 0, false
+	}
+	return block.blockType, true
+}
@@ -1,14 +1,13 @@
 package blocks
 
 import (
-	"encoding/gob"
-
 	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/types"
 	"github.com/3elDU/bamboo/util"
 )
 
 func init() {
-	gob.Register(SandState{})
+	Register("sand", Sand, func() types.Block { return NewSandBlock(false) }, SandState{})
 }
 
 type SandState struct {
@@ -21,6 +20,7 @@ type SandBlock struct {
 	baseBlock
 	texturedBlock
 	collidableBlock
+	breakableBlock
 }
 
 func NewSandBlock(stones bool) *SandBlock {
@@ -41,6 +41,11 @@ func NewSandBlock(stones bool) *SandBlock {
 			collidable:  false,
 			playerSpeed: 0.8,
 		},
+		breakableBlock: breakableBlock{
+			hardness: 0.5,
+			category: types.GroundCategory,
+			drops:    []string{"sand"},
+		},
 	}
 }
 
@@ -52,9 +57,16 @@ func (b *SandBlock) State() interface{} {
 	}
 }
 
-func (b *SandBlock) LoadState(s interface{}) {
-	state := s.(SandState)
-	b.baseBlock.LoadState(state.BaseBlockState)
-	b.texturedBlock.LoadState(state.TexturedBlockState)
-	b.collidableBlock.LoadState(state.CollidableBlockState)
+func (b *SandBlock) LoadState(s interface{}) error {
+	state, ok := s.(SandState)
+	if !ok {
+		return invalidStateError(b, SandState{}, s)
+	}
+	if err := b.baseBlock.LoadState(state.BaseBlockState); err != nil {
+		return err
+	}
+	if err := b.texturedBlock.LoadState(state.TexturedBlockState); err != nil {
+		return err
+	}
+	return b.collidableBlock.LoadState(state.CollidableBlockState)
 }
@@ -1,14 +1,12 @@
 package blocks
 
 import (
-	"encoding/gob"
-
 	"github.com/3elDU/bamboo/asset_loader"
 	"github.com/3elDU/bamboo/types"
 )
 
 func init() {
-	gob.Register(WaterState{})
+	Register("water", Water, func() types.Block { return NewWaterBlock() }, WaterState{})
 }
 
 type WaterState struct {
@@ -44,8 +42,13 @@ func (b *WaterBlock) State() interface{} {
 	}
 }
 
-func (b *WaterBlock) LoadState(s interface{}) {
-	state := s.(WaterState)
-	b.connectedBlock.LoadState(state.ConnectedBlockState)
-	b.collidableBlock.LoadState(state.CollidableBlockState)
+func (b *WaterBlock) LoadState(s interface{}) error {
+	state, ok := s.(WaterState)
+	if !ok {
+		return invalidStateError(b, WaterState{}, s)
+	}
+	if err := b.connectedBlock.LoadState(state.ConnectedBlockState); err != nil {
+		return err
+	}
+	return b.collidableBlock.LoadState(state.CollidableBlockState)
 }
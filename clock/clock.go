@@ -0,0 +1,22 @@
+/*
+	Global tick counter, which doesn't depend on the graphics.
+	In the game, it is advanced by the scene manager on each Update() call,
+	and on the dedicated server - by the server itself, on each tick of the world.
+*/
+
+package clock
+
+import "sync/atomic"
+
+var ticks uint64
+
+// Ticks returns the number of ticks, passed since the start of the program
+func Ticks() uint64 {
+	return atomic.LoadUint64(&ticks)
+}
+
+// Advance increments the tick counter.
+// There must be only one thing advancing the clock in a program
+func Advance() {
+	atomic.AddUint64(&ticks, 1)
+}
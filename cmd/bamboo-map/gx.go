@@ -0,0 +1,13 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/3elDU/bamboo/types"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// the map is drawn from the decoded asset files, so the chunk is never rendered by ebiten
+
+func (c *mapChunk) Render(types.World)     {}
+func (c *mapChunk) Texture() *ebiten.Image { return nil }
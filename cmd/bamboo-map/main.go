@@ -0,0 +1,352 @@
+/*
+	bamboo-map renders the whole world to a PNG image, without starting the game.
+
+	Usage ( from the repository root, so the assets can be found ):
+
+		go run -tags headless ./cmd/bamboo-map -seed 42 -type overworld -o map.png
+		go run -tags headless ./cmd/bamboo-map -seed 42 -zoom 4
+		go run -tags headless ./cmd/bamboo-map -save saves/<base uuid>/<world uuid>
+
+	The headless tag leaves out ebiten, which can't start without a display.
+	Textures are decoded from the asset files directly, so the tool doesn't need it anyway.
+
+	By default, each block is drawn as a single pixel of the average color of its texture.
+	With -zoom N, blocks are drawn with their textures, N pixels per block.
+	With -save, chunks saved in the world are drawn on top of the generated ones,
+	and the seed and world type are taken from the save.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+	"github.com/3elDU/bamboo/world_type"
+	"github.com/3elDU/bamboo/worldgen"
+)
+
+var worldTypes = map[string]world_type.WorldType{
+	"overworld": world_type.Overworld,
+	"cave":      world_type.Cave,
+}
+
+// mapChunk is a bare chunk, that only holds the blocks.
+// Regular world chunks also cache light and entities, which isn't needed for the map
+type mapChunk struct {
+	x, y   uint64
+	blocks [16][16]types.Block
+}
+
+func (c *mapChunk) At(x, y uint) types.Block {
+	return c.blocks[x][y]
+}
+
+func (c *mapChunk) BlockCoords() types.Vec2u {
+	return types.Vec2u{X: c.x * 16, Y: c.y * 16}
+}
+
+func (c *mapChunk) Coords() types.Vec2u {
+	return types.Vec2u{X: c.x, Y: c.y}
+}
+
+func (c *mapChunk) SetBlock(x, y uint, block types.Block) {
+	block.SetParentChunk(c)
+	block.SetCoords(types.Vec2u{X: c.x*16 + uint64(x), Y: c.y*16 + uint64(y)})
+	c.blocks[x][y] = block
+}
+
+func (c *mapChunk) Save(types.Save)    {}
+func (c *mapChunk) Update(types.World) {}
+func (c *mapChunk) TriggerRedraw()     {}
+
+func main() {
+	var (
+		seed     = flag.Int64("seed", 0, "world seed")
+		typeName = flag.String("type", "overworld", "world type ( overworld, cave )")
+		output   = flag.String("o", "map.png", "output file")
+		zoom     = flag.Int("zoom", 0, "draw block textures, with this many pixels per block ( 0 - one colored pixel per block )")
+		saveDir  = flag.String("save", "", "world directory, whose saved chunks are drawn on top of the generated map")
+		chunksX  = config.WorldWidth / 16
+		chunksY  = config.WorldHeight / 16
+	)
+	flag.Parse()
+
+	worldType, ok := worldTypes[*typeName]
+	if !ok {
+		log.Fatalf("unknown world type %q", *typeName)
+	}
+
+	var metadata *types.Save
+	if *saveDir != "" {
+		m, err := readSave(*saveDir)
+		if err != nil {
+			log.Fatalf("failed to read the save - %v", err)
+		}
+		metadata = &m
+		*seed, worldType = m.Seed, m.WorldType
+	}
+
+	textures, err := newTextureCache(config.AssetDirectory)
+	if err != nil {
+		log.Fatalf("failed to load textures - %v", err)
+	}
+
+	scale := 1
+	if *zoom > 0 {
+		scale = *zoom
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(config.WorldWidth)*scale, int(config.WorldHeight)*scale))
+	drawChunk := func(chunk types.Chunk) {
+		origin := chunk.BlockCoords()
+		for x := uint(0); x < 16; x++ {
+			for y := uint(0); y < 16; y++ {
+				bx, by := int(origin.X)+int(x), int(origin.Y)+int(y)
+				block := chunk.At(x, y)
+				if *zoom > 0 {
+					textures.drawBlock(img, block, bx*scale, by*scale, scale)
+				} else {
+					img.Set(bx, by, textures.blockColor(block))
+				}
+			}
+		}
+	}
+
+	log.Printf("generating %v world with seed %v", *typeName, *seed)
+	generator := worldgen.NewWorldgenForType(*seed, worldType)
+
+	// generation doesn't depend on other chunks, so rows are generated in parallel
+	rows := make(chan uint64)
+	wg := sync.WaitGroup{}
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cy := range rows {
+				for cx := uint64(0); cx < chunksX; cx++ {
+					chunk := &mapChunk{x: cx, y: cy}
+					generator.GenerateImmediately(chunk)
+					drawChunk(chunk)
+				}
+			}
+		}()
+	}
+	for cy := uint64(0); cy < chunksY; cy++ {
+		rows <- cy
+	}
+	close(rows)
+	wg.Wait()
+
+	if metadata != nil {
+		coords, err := world.SavedChunks(*metadata)
+		if err != nil {
+			log.Fatalf("failed to list saved chunks - %v", err)
+		}
+		for _, c := range coords {
+			chunk, err := world.ReadChunk(*metadata, c.X, c.Y)
+			if err != nil {
+				log.Printf("skipping chunk %v, %v - %v", c.X, c.Y, err)
+				continue
+			}
+			if chunk != nil {
+				drawChunk(chunk)
+			}
+		}
+		log.Printf("drew %v saved chunks", len(coords))
+	}
+
+	if err := writePNG(*output, img); err != nil {
+		log.Fatalf("failed to write %v - %v", *output, err)
+	}
+	log.Printf("map written to %v", *output)
+}
+
+// readSave reads metadata of the world in the given directory
+// Chunks are looked up by the world UUIDs, so the directory must be inside the save directory
+func readSave(dir string) (types.Save, error) {
+	metadata, err := world.ReadMetadata(dir)
+	if err != nil {
+		return types.Save{}, err
+	}
+
+	expected, err := filepath.Abs(filepath.Join(config.WorldSaveDirectory, metadata.BaseUUID.String(), metadata.UUID.String()))
+	if err != nil {
+		return types.Save{}, err
+	}
+	actual, err := filepath.Abs(dir)
+	if err != nil {
+		return types.Save{}, err
+	}
+	if expected != actual {
+		return types.Save{}, fmt.Errorf("world must be in %v", expected)
+	}
+
+	return metadata, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// textureCache decodes textures straight from the asset files.
+// Pixels of ebiten images can't be read before the game is running, so asset_loader can't be used here
+type textureCache struct {
+	mutex  sync.Mutex
+	paths  map[string]string
+	images map[string]image.Image
+	colors map[string]color.RGBA
+}
+
+func newTextureCache(dir string) (*textureCache, error) {
+	cache := &textureCache{
+		paths:  make(map[string]string),
+		images: make(map[string]image.Image),
+		colors: make(map[string]color.RGBA),
+	}
+
+	// same naming rules, as in asset_loader:
+	// regular textures are named after the file, connected textures - after the directory with atlas.png
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			atlas := filepath.Join(path, "atlas.png")
+			if _, err := os.Stat(atlas); err == nil {
+				cache.paths[filepath.Base(path)] = atlas
+			}
+		case filepath.Ext(path) == ".png":
+			cache.paths[filepath.Base(path[:len(path)-len(".png")])] = path
+		}
+		return nil
+	})
+	return cache, err
+}
+
+// returns the texture, or nil if it doesn't exist
+func (cache *textureCache) texture(name string) image.Image {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if img, exists := cache.images[name]; exists {
+		return img
+	}
+
+	var img image.Image
+	if path, exists := cache.paths[name]; exists {
+		f, err := os.Open(path)
+		if err == nil {
+			img, _, err = image.Decode(f)
+			f.Close()
+		}
+		if err != nil {
+			log.Printf("failed to decode %v - %v", path, err)
+			img = nil
+		}
+	}
+	if img != nil {
+		// connected textures are atlases, their top-left tile is the texture without connected sides
+		img = cropTile(img)
+	}
+
+	cache.images[name] = img
+	return img
+}
+
+func cropTile(img image.Image) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= 16 && bounds.Dy() <= 16 {
+		return img
+	}
+	tile := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(tile, tile.Bounds(), img, bounds.Min, draw.Src)
+	return tile
+}
+
+func (cache *textureCache) blockColor(block types.Block) color.RGBA {
+	drawable, ok := block.(types.DrawableBlock)
+	if !ok {
+		return color.RGBA{}
+	}
+	name := drawable.TextureName()
+
+	cache.mutex.Lock()
+	clr, exists := cache.colors[name]
+	cache.mutex.Unlock()
+	if exists {
+		return clr
+	}
+
+	clr = averageColor(cache.texture(name))
+
+	cache.mutex.Lock()
+	cache.colors[name] = clr
+	cache.mutex.Unlock()
+	return clr
+}
+
+// averages all opaque pixels of the image
+func averageColor(img image.Image) color.RGBA {
+	if img == nil {
+		return color.RGBA{}
+	}
+
+	var r, g, b, n uint64
+	bounds := img.Bounds()
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			pr, pg, pb, pa := img.At(x, y).RGBA()
+			if pa == 0 {
+				continue
+			}
+			r, g, b = r+uint64(pr>>8), g+uint64(pg>>8), b+uint64(pb>>8)
+			n++
+		}
+	}
+	if n == 0 {
+		return color.RGBA{}
+	}
+	return color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 0xFF}
+}
+
+// draws the block texture, scaled with nearest neighbor filtering to size x size pixels
+func (cache *textureCache) drawBlock(dst *image.RGBA, block types.Block, x, y, size int) {
+	drawable, ok := block.(types.DrawableBlock)
+	if !ok {
+		return
+	}
+	tex := cache.texture(drawable.TextureName())
+	if tex == nil {
+		return
+	}
+
+	bounds := tex.Bounds()
+	for dx := 0; dx < size; dx++ {
+		for dy := 0; dy < size; dy++ {
+			sx := bounds.Min.X + dx*bounds.Dx()/size
+			sy := bounds.Min.Y + dy*bounds.Dy()/size
+			dst.Set(x+dx, y+dy, tex.At(sx, sy))
+		}
+	}
+}
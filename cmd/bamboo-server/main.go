@@ -0,0 +1,91 @@
+//go:build headless
+
+/*
+	bamboo-server hosts a world for other players, without opening a window.
+	It must be built with the "headless" tag, so that ebiten isn't linked in.
+
+	Usage ( from the repository root, so the assets can be found ):
+
+		go run -tags headless ./cmd/bamboo-server -save saves/<base uuid>/<world uuid>
+		go run -tags headless ./cmd/bamboo-server -name island -seed 42 -addr :7412
+
+	Without -save, a new world is created in the saves folder.
+	The world is saved each config.WorldAutosaveDelay ticks, and on exit ( Ctrl+C ).
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/3elDU/bamboo/config"
+	_ "github.com/3elDU/bamboo/mobs"
+	"github.com/3elDU/bamboo/network"
+	"github.com/3elDU/bamboo/scripting"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+	"github.com/3elDU/bamboo/world_type"
+	"github.com/google/uuid"
+)
+
+func main() {
+	var (
+		saveDir = flag.String("save", "", "world directory to host. If empty, a new world is created")
+		name    = flag.String("name", "Server world", "name of the new world")
+		seed    = flag.Int64("seed", 0, "seed of the new world ( 0 - random )")
+		address = flag.String("addr", fmt.Sprintf(":%v", config.DefaultServerPort), "address to listen on")
+	)
+	flag.Parse()
+
+	// the blocks and items of the mods must be registered, so that the server knows them, when the clients place them
+	scripting.LoadMods(config.ModDirectory)
+
+	var hostedWorld *world.World
+	if *saveDir != "" {
+		metadata, err := world.ReadMetadata(*saveDir)
+		if err != nil {
+			log.Fatalf("failed to read the save - %v", err)
+		}
+		hostedWorld, err = world.Load(metadata.BaseUUID, metadata.UUID)
+		if err != nil {
+			log.Fatalf("failed to load the world - %v", err)
+		}
+	} else {
+		if *seed == 0 {
+			*seed = rand.New(rand.NewSource(time.Now().UnixNano())).Int63()
+		}
+		metadata := types.Save{
+			Name:      *name,
+			BaseUUID:  uuid.New(),
+			UUID:      uuid.New(),
+			Seed:      *seed,
+			WorldType: world_type.Overworld,
+		}
+		log.Printf("creating world %v in %v", metadata.BaseUUID, config.WorldSaveDirectory)
+		hostedWorld = world.NewWorld(metadata)
+	}
+
+	// chunk events of the hosted world are passed to the scripts
+	scripting.SetContext(scripting.Context{World: hostedWorld})
+
+	server := network.NewDedicatedServer(hostedWorld)
+	if err := server.Listen(*address); err != nil {
+		log.Fatalf("failed to start the server - %v", err)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-interrupt:
+	case <-server.Done():
+	}
+
+	server.Close()
+}
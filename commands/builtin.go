@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/types"
+)
+
+// Names of the times of day for /time set, as in types.WorldTime.TimeOfDay()
+var timesOfDay = map[string]float64{
+	"day":      0,
+	"noon":     0.25,
+	"night":    0.5,
+	"midnight": 0.75,
+}
+
+func init() {
+	Register(Command{
+		Name:        "help",
+		Description: "lists the commands",
+		Handler:     help,
+	})
+	Register(Command{
+		Name:        "tp",
+		Usage:       "x y",
+		Description: "teleports the player",
+		Handler:     teleport,
+	})
+	Register(Command{
+		Name:        "give",
+		Usage:       "item (count)",
+		Description: "puts items in the inventory",
+		Handler:     give,
+	})
+	Register(Command{
+		Name:        "setblock",
+		Usage:       "x y block",
+		Description: "places a block",
+		Handler:     setBlock,
+	})
+	Register(Command{
+		Name:        "seed",
+		Description: "shows the world seed",
+		Handler:     seed,
+	})
+	Register(Command{
+		Name:        "time",
+		Usage:       "(set day, noon, night, midnight or ticks)",
+		Description: "shows or changes the world time",
+		Handler:     worldTime,
+	})
+	Register(Command{
+		Name:        "save",
+		Description: "saves the game",
+		Handler:     save,
+	})
+}
+
+func help(_ *Context, _ []string) (string, error) {
+	lines := make([]string, 0, len(registry))
+	for _, command := range All() {
+		lines = append(lines, fmt.Sprintf("%v - %v", command.usageLine(), command.Description))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// parses block coordinates, checking that they are inside of the world
+func parseCoords(x, y string) (uint64, uint64, error) {
+	bx, errX := strconv.ParseUint(x, 10, 64)
+	by, errY := strconv.ParseUint(y, 10, 64)
+	if errX != nil || errY != nil {
+		return 0, 0, ErrUsage
+	}
+	if bx >= config.WorldWidth || by >= config.WorldHeight {
+		return 0, 0, fmt.Errorf("%v, %v is outside of the world", bx, by)
+	}
+	return bx, by, nil
+}
+
+func teleport(ctx *Context, args []string) (string, error) {
+	if len(args) != 2 {
+		return "", ErrUsage
+	}
+	// the server would only send the player back, as it doesn't allow moving that far at once
+	if ctx.Multiplayer {
+		return "", ErrMultiplayer
+	}
+	x, y, err := parseCoords(args[0], args[1])
+	if err != nil {
+		return "", err
+	}
+
+	// to the center of the block
+	ctx.Player.SetPosition(types.Vec2f{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+	ctx.Player.SetVelocity(types.Vec2f{})
+	return fmt.Sprintf("Teleported to %v, %v", x, y), nil
+}
+
+func give(ctx *Context, args []string) (string, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return "", ErrUsage
+	}
+	count := 1
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return "", ErrUsage
+		}
+		count = n
+	}
+	// the items would come from nowhere, which is cheating on someone else's server
+	if ctx.Multiplayer {
+		return "", ErrMultiplayer
+	}
+	if _, exists := items.GetItemByName(args[0]); !exists {
+		return "", fmt.Errorf("unknown item %v", args[0])
+	}
+
+	given := 0
+	for ; given < count; given++ {
+		item, _ := items.GetItemByName(args[0])
+		if !ctx.Inventory.AddItem(item) {
+			break
+		}
+	}
+	if given < count {
+		return "", fmt.Errorf("gave %v %v, the inventory is full", given, args[0])
+	}
+	return fmt.Sprintf("Gave %v %v", given, args[0]), nil
+}
+
+func setBlock(ctx *Context, args []string) (string, error) {
+	if len(args) != 3 {
+		return "", ErrUsage
+	}
+	// only the server can change the blocks, the players can only place and break them
+	if ctx.Multiplayer {
+		return "", ErrMultiplayer
+	}
+	x, y, err := parseCoords(args[0], args[1])
+	if err != nil {
+		return "", err
+	}
+	block, exists := blocks.GetBlockByName(args[2])
+	if !exists {
+		return "", fmt.Errorf("unknown block %v", args[2])
+	}
+
+	ctx.World.SetBlock(x, y, block)
+	return fmt.Sprintf("Placed %v at %v, %v", args[2], x, y), nil
+}
+
+func seed(ctx *Context, _ []string) (string, error) {
+	return fmt.Sprintf("Seed: %v", ctx.World.Seed()), nil
+}
+
+func worldTime(ctx *Context, args []string) (string, error) {
+	now := ctx.World.Time()
+	if len(args) == 0 {
+		return fmt.Sprintf("Day %v, %.2f", now.Day(), now.TimeOfDay()), nil
+	}
+	if len(args) != 2 || args[0] != "set" {
+		return "", ErrUsage
+	}
+	// the server keeps sending its own time
+	if ctx.Multiplayer {
+		return "", ErrMultiplayer
+	}
+
+	var time types.WorldTime
+	if timeOfDay, exists := timesOfDay[args[1]]; exists {
+		time = types.WorldTime(now.Day()*config.DayLength + uint64(timeOfDay*float64(config.DayLength)))
+	} else {
+		ticks, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return "", ErrUsage
+		}
+		time = types.WorldTime(ticks)
+	}
+
+	ctx.World.SetTime(time)
+	return fmt.Sprintf("Set the time to day %v, %.2f", time.Day(), time.TimeOfDay()), nil
+}
+
+func save(ctx *Context, _ []string) (string, error) {
+	ctx.Save()
+	return "Saved the game", nil
+}
@@ -0,0 +1,116 @@
+/*
+	Console commands, typed in the game console, e.g. "/tp 100 200".
+	Commands are registered with Register(), and run with Execute().
+	The built-in commands are in builtin.go
+*/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/3elDU/bamboo/game/inventory"
+	"github.com/3elDU/bamboo/game/player"
+	"github.com/3elDU/bamboo/types"
+)
+
+// Prefix, that tells commands apart from chat messages
+const Prefix = "/"
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	// returned by the handlers, when the arguments are wrong. Execute() adds the usage of the command to it
+	ErrUsage = errors.New("invalid arguments")
+	// the command changes something, that only the server can change
+	ErrMultiplayer = errors.New("not available in multiplayer")
+)
+
+// Context is what the commands operate on
+type Context struct {
+	World     types.World
+	Player    *player.Player
+	Inventory *inventory.Inventory
+
+	// set, when the world is replicated from the server
+	Multiplayer bool
+	// saves the game, the same way as the pause menu does
+	Save func()
+}
+
+// Handler returns the text, that is shown in the console.
+// args don't include the name of the command
+type Handler func(ctx *Context, args []string) (string, error)
+
+type Command struct {
+	Name string
+	// Arguments of the command, e.g. "x y". Optional ones are in parentheses,
+	// since the font doesn't have other brackets
+	Usage       string
+	Description string
+	Handler     Handler
+}
+
+var registry = make(map[string]*Command)
+
+// Register adds a command to the registry
+func Register(command Command) {
+	if _, exists := registry[command.Name]; exists {
+		log.Panicf("commands.Register() - command %v is already registered", command.Name)
+	}
+	registry[command.Name] = &command
+}
+
+// Lookup returns the command with the given name, without the prefix.
+// The second value is false, if there is no such command
+func Lookup(name string) (Command, bool) {
+	command, exists := registry[name]
+	if !exists {
+		return Command{}, false
+	}
+	return *command, true
+}
+
+// All returns all registered commands, sorted by name
+func All() []Command {
+	all := make([]Command, 0, len(registry))
+	for _, command := range registry {
+		all = append(all, *command)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// IsCommand returns true, if the line should be executed, rather than sent to the chat
+func IsCommand(line string) bool {
+	return strings.HasPrefix(line, Prefix)
+}
+
+// Execute runs the command line, e.g. "/give stick 5"
+func Execute(ctx *Context, line string) (string, error) {
+	fields := strings.Fields(strings.TrimPrefix(line, Prefix))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%w - type %vhelp for the list of commands", ErrUnknownCommand, Prefix)
+	}
+
+	command, exists := registry[fields[0]]
+	if !exists {
+		return "", fmt.Errorf("%w %v%v - type %vhelp for the list of commands", ErrUnknownCommand, Prefix, fields[0], Prefix)
+	}
+
+	output, err := command.Handler(ctx, fields[1:])
+	if errors.Is(err, ErrUsage) {
+		return "", fmt.Errorf("%w - usage: %v", err, command.usageLine())
+	}
+	return output, err
+}
+
+// e.g. "/tp <x> <y>"
+func (command *Command) usageLine() string {
+	return strings.TrimSpace(Prefix + command.Name + " " + command.Usage)
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"log"
+
+	"github.com/3elDU/bamboo/scripting"
+)
+
+// RegisterModCommands adds the commands, registered by the mods. Must be called after scripting.LoadMods().
+// Commands with the names, that are already taken, are skipped, instead of panicking
+func RegisterModCommands() {
+	for _, command := range scripting.Commands() {
+		if _, exists := registry[command.Name]; exists {
+			log.Printf("commands.RegisterModCommands() - command %v is already registered", command.Name)
+			continue
+		}
+
+		run := command.Run
+		Register(Command{
+			Name:        command.Name,
+			Usage:       command.Usage,
+			Description: command.Description,
+			Handler: func(_ *Context, args []string) (string, error) {
+				return run(args)
+			},
+		})
+	}
+}
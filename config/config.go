@@ -1,11 +1,13 @@
 package config
 
+import "os"
+
 // All values with type uint64 are measured in ticks, unless noted otherwise
 // 1 second == 60 ticks
 const (
-	AssetDirectory                 = "./assets/"
 	PerlinNoiseScaleFactor float64 = 128
 	PlayerSpeed            float64 = 0.02
+	PlayerSprintMultiplier float64 = 1.6
 
 	WorldWidth  uint64 = 1024
 	WorldHeight uint64 = 1024
@@ -13,11 +15,43 @@ const (
 	PlayerStartX   = WorldWidth / 2 // initial player position, when the world is created
 	PlayerStartY   = WorldHeight / 2
 	PlayerInfoFile = "player.gob"
+	InventoryFile  = "inventory.gob"
 
-	WorldSaveDirectory        = "./saves/"
 	WorldInfoFile             = "world.gob"
 	WorldAutosaveDelay uint64 = 3600
 	ChunkUnloadDelay   uint64 = 600
+	// Width and height of a region file, in chunks
+	RegionSize uint64 = 32
+	// Length of the day and night cycle, 20 minutes
+	DayLength uint64 = 72000
 
 	UIScaling float64 = 2
+
+	// Each subdirectory is a mod, with its scripts and textures ( see scripting package )
+	ModDirectory = "./mods/"
+
+	// Port, the server listens on, when the world is hosted from the game
+	DefaultServerPort = 7412
 )
+
+var (
+	// Textures and data files. The assets are loaded, while the packages are initialized,
+	// so the directory can only be changed with the BAMBOO_ASSETS environment variable
+	AssetDirectory = assetDirectory()
+	// Can be changed before any world is opened, e.g. by the tests
+	WorldSaveDirectory = "./saves/"
+)
+
+// assetDirectory returns BAMBOO_ASSETS, if it is set, or the assets directory in the working directory.
+// go test runs in the directory of the package, so the parent directories are checked too
+func assetDirectory() string {
+	if dir := os.Getenv("BAMBOO_ASSETS"); dir != "" {
+		return dir
+	}
+	for _, dir := range []string{"./assets/", "../assets/", "../../assets/"} {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return "./assets/"
+}
@@ -0,0 +1,252 @@
+/*
+	Crafting - turning items in the inventory into other items.
+	Recipes are defined in data files ( assets/recipes/*.json ), see asset_loader.RecipeDefinition.
+*/
+
+package crafting
+
+import (
+	"errors"
+	"log"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/game/inventory"
+	"github.com/3elDU/bamboo/items"
+)
+
+// Recipes are laid out in a grid of GridSize by GridSize cells
+const GridSize = 3
+
+var (
+	ErrMissingIngredients = errors.New("not enough ingredients")
+	ErrNoSpace            = errors.New("no space in the inventory")
+)
+
+// Ingredient is an item with its quantity
+type Ingredient struct {
+	Item  string
+	Count int
+}
+
+type Recipe struct {
+	Name string
+	// Rows of item names, empty string is an empty cell. nil for shapeless recipes
+	Pattern [][]string
+	// For shaped recipes, ingredients are counted from the pattern
+	Ingredients []Ingredient
+	Result      Ingredient
+}
+
+// all loaded recipes, sorted by name
+var recipes []*Recipe
+
+func init() {
+	for _, definition := range asset_loader.RecipeDefinitions() {
+		recipe := newRecipe(definition)
+
+		names := []string{recipe.Result.Item}
+		for _, ingredient := range recipe.Ingredients {
+			names = append(names, ingredient.Item)
+		}
+		for _, name := range names {
+			if _, exists := items.GetItemByName(name); !exists {
+				log.Panicf("recipe %v refers to unknown item %v", recipe.Name, name)
+			}
+		}
+
+		fits := len(recipe.Pattern) <= GridSize
+		for _, row := range recipe.Pattern {
+			fits = fits && len(row) <= GridSize
+		}
+		cells := 0
+		for _, ingredient := range recipe.Ingredients {
+			cells += ingredient.Count
+		}
+		fits = fits && cells <= GridSize*GridSize
+		if !fits {
+			log.Panicf("recipe %v doesn't fit in the %vx%v grid", recipe.Name, GridSize, GridSize)
+		}
+
+		recipes = append(recipes, recipe)
+	}
+}
+
+func newRecipe(definition asset_loader.RecipeDefinition) *Recipe {
+	recipe := &Recipe{
+		Name:   definition.Name,
+		Result: Ingredient{Item: definition.Result.Item, Count: definition.Result.Count},
+	}
+
+	if len(definition.Pattern) == 0 {
+		for _, ingredient := range definition.Ingredients {
+			recipe.Ingredients = append(recipe.Ingredients, Ingredient{Item: ingredient.Item, Count: ingredient.Count})
+		}
+		return recipe
+	}
+
+	counts := make(map[string]int)
+	for _, row := range definition.Pattern {
+		cells := make([]string, len(row))
+		for i, key := range row {
+			if key == ' ' {
+				continue
+			}
+			cells[i] = definition.Key[string(key)]
+			if counts[cells[i]] == 0 {
+				// keep the order of the first appearance
+				recipe.Ingredients = append(recipe.Ingredients, Ingredient{Item: cells[i]})
+			}
+			counts[cells[i]]++
+		}
+		recipe.Pattern = append(recipe.Pattern, cells)
+	}
+	for i := range recipe.Ingredients {
+		recipe.Ingredients[i].Count = counts[recipe.Ingredients[i].Item]
+	}
+
+	return recipe
+}
+
+func (recipe *Recipe) Shaped() bool {
+	return recipe.Pattern != nil
+}
+
+// Recipes returns all recipes, sorted by name
+func Recipes() []*Recipe {
+	return recipes
+}
+
+// CanCraft returns true, if the inventory has all the ingredients of the recipe
+func (recipe *Recipe) CanCraft(inv *inventory.Inventory) bool {
+	for _, ingredient := range recipe.Ingredients {
+		if inv.Count(ingredient.Item) < ingredient.Count {
+			return false
+		}
+	}
+	return true
+}
+
+// Available returns recipes, that can be crafted from the items in the inventory
+func Available(inv *inventory.Inventory) (available []*Recipe) {
+	for _, recipe := range recipes {
+		if recipe.CanCraft(inv) {
+			available = append(available, recipe)
+		}
+	}
+	return
+}
+
+// Craft takes the ingredients from the inventory, and puts the result there.
+// The inventory is left untouched, if the crafting fails
+func Craft(inv *inventory.Inventory, recipe *Recipe) error {
+	// try on a copy first, so the ingredients aren't lost, if the result doesn't fit
+	if err := craft(inv.Copy(), recipe); err != nil {
+		return err
+	}
+	return craft(inv, recipe)
+}
+
+func craft(inv *inventory.Inventory, recipe *Recipe) error {
+	if !recipe.CanCraft(inv) {
+		return ErrMissingIngredients
+	}
+	for _, ingredient := range recipe.Ingredients {
+		inv.Remove(ingredient.Item, ingredient.Count)
+	}
+
+	for i := 0; i < recipe.Result.Count; i++ {
+		item, _ := items.GetItemByName(recipe.Result.Item)
+		if !inv.AddItem(item) {
+			return ErrNoSpace
+		}
+	}
+	return nil
+}
+
+// Match finds a recipe, which is laid out in the grid of item names.
+// Shaped recipes can be placed anywhere in the grid, but their shape must be kept.
+// For shapeless recipes, only the items matter
+func Match(grid [][]string) (*Recipe, bool) {
+	trimmed := trimGrid(grid)
+	for _, recipe := range recipes {
+		if recipe.Shaped() && samePattern(recipe.Pattern, trimmed) ||
+			!recipe.Shaped() && sameIngredients(recipe.Ingredients, trimmed) {
+			return recipe, true
+		}
+	}
+	return nil, false
+}
+
+// removes empty rows and columns around the items
+func trimGrid(grid [][]string) [][]string {
+	minX, minY, maxX, maxY := -1, -1, -1, -1
+	for y, row := range grid {
+		for x, cell := range row {
+			if cell == "" {
+				continue
+			}
+			if minX == -1 || x < minX {
+				minX = x
+			}
+			if minY == -1 {
+				minY = y
+			}
+			if x > maxX {
+				maxX = x
+			}
+			maxY = y
+		}
+	}
+	if minX == -1 {
+		return nil
+	}
+
+	trimmed := make([][]string, 0, maxY-minY+1)
+	for _, row := range grid[minY : maxY+1] {
+		cells := make([]string, maxX-minX+1)
+		for x := minX; x <= maxX && x < len(row); x++ {
+			cells[x-minX] = row[x]
+		}
+		trimmed = append(trimmed, cells)
+	}
+	return trimmed
+}
+
+func samePattern(pattern, grid [][]string) bool {
+	pattern = trimGrid(pattern)
+	if len(pattern) != len(grid) {
+		return false
+	}
+	for y := range pattern {
+		if len(pattern[y]) != len(grid[y]) {
+			return false
+		}
+		for x := range pattern[y] {
+			if pattern[y][x] != grid[y][x] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sameIngredients(ingredients []Ingredient, grid [][]string) bool {
+	counts := make(map[string]int)
+	for _, row := range grid {
+		for _, cell := range row {
+			if cell != "" {
+				counts[cell]++
+			}
+		}
+	}
+
+	if len(counts) != len(ingredients) {
+		return false
+	}
+	for _, ingredient := range ingredients {
+		if counts[ingredient.Item] != ingredient.Count {
+			return false
+		}
+	}
+	return true
+}
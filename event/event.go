@@ -1,39 +1,121 @@
+/*
+	Typed publish/subscribe event bus.
+	Each world owns a Bus ( types.World.Events() ), so the subscribers receive only the events of that world.
+	Events are plain structs ( see types/events.go ), and the handlers receive a pointer to them,
+	so they can change the event, or cancel it, if the struct embeds Cancellable.
+
+		event.Subscribe(world.Events(), event.Normal, func(e *types.BlockPlacedEvent) { ... })
+		if event.Fire(world.Events(), &types.BlockPlacedEvent{...}) { ... }
+*/
+
 package event
 
-type Type int
-type Event interface {
-	Type() Type
-	Args() interface{}
+import (
+	"reflect"
+	"sort"
+)
+
+// Handlers with higher priority run first
+type Priority int
+
+const (
+	Lowest Priority = iota - 2
+	Low
+	Normal
+	High
+	Highest
+)
+
+// Cancellable is embedded in the events, that can be cancelled.
+// Once an event is cancelled, the remaining handlers aren't called,
+// and the code, that fired the event, doesn't do what the event announced
+type Cancellable struct {
+	cancelled bool
 }
 
-type CustomEvent struct {
-	t    Type
-	args interface{}
+func (c *Cancellable) Cancel() {
+	c.cancelled = true
 }
 
-func (e CustomEvent) Type() Type {
-	return e.t
+func (c *Cancellable) Cancelled() bool {
+	return c.cancelled
 }
-func (e CustomEvent) Args() interface{} {
-	return e.args
+
+// implemented by the events, that embed Cancellable
+type cancellable interface {
+	Cancelled() bool
+}
+
+// Bus isn't safe for concurrent use. Like the world, that owns it, it is used only from the goroutine, that updates the world
+type Bus struct {
+	// event type -> subscriptions, sorted by priority
+	subscriptions map[reflect.Type][]*Subscription
 }
 
-func NewEvent(t Type, args interface{}) CustomEvent {
-	return CustomEvent{
-		t:    t,
-		args: args,
+func NewBus() *Bus {
+	return &Bus{
+		subscriptions: make(map[reflect.Type][]*Subscription),
 	}
 }
 
-var eventList []Event
+type Subscription struct {
+	bus       *Bus
+	eventType reflect.Type
+	priority  Priority
+	// func(*E), called through call()
+	call func(ev interface{})
+	// set by Unsubscribe(), so that a subscription, removed while the event is being fired, isn't called
+	removed bool
+}
 
-func FireEvent(event Event) {
-	eventList = append(eventList, event)
+// Subscribe calls the handler each time an event of type E is fired on the bus.
+// Handlers with the same priority are called in the order of subscription
+func Subscribe[E any](bus *Bus, priority Priority, handler func(ev *E)) *Subscription {
+	subscription := &Subscription{
+		bus:       bus,
+		eventType: reflect.TypeOf((*E)(nil)),
+		priority:  priority,
+		call:      func(ev interface{}) { handler(ev.(*E)) },
+	}
+
+	// a new slice, since the old one may be iterated by Fire() right now
+	subscriptions := bus.subscriptions[subscription.eventType]
+	subscriptions = append(subscriptions[:len(subscriptions):len(subscriptions)], subscription)
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		return subscriptions[i].priority > subscriptions[j].priority
+	})
+	bus.subscriptions[subscription.eventType] = subscriptions
+	return subscription
+}
+
+// Unsubscribe stops the calls to the handler. It can be called from the handler itself
+func (subscription *Subscription) Unsubscribe() {
+	bus := subscription.bus
+	subscription.removed = true
+	subscriptions := bus.subscriptions[subscription.eventType]
+	for i, other := range subscriptions {
+		if other == subscription {
+			// same as in Subscribe(), the old slice is left untouched
+			bus.subscriptions[subscription.eventType] = append(subscriptions[:i:i], subscriptions[i+1:]...)
+			break
+		}
+	}
 }
 
-func GetEvents() []Event {
-	events := make([]Event, len(eventList))
-	copy(events, eventList)
-	eventList = []Event{}
-	return events
+// Fire calls the handlers of the event, from the highest priority to the lowest.
+// Returns false, if the event was cancelled
+func Fire[E any](bus *Bus, ev *E) bool {
+	for _, subscription := range bus.subscriptions[reflect.TypeOf(ev)] {
+		if cancellable, ok := interface{}(ev).(cancellable); ok && cancellable.Cancelled() {
+			return false
+		}
+		if !subscription.removed {
+			subscription.call(ev)
+		}
+	}
+
+	if cancellable, ok := interface{}(ev).(cancellable); ok {
+		return !cancellable.Cancelled()
+	}
+	return true
 }
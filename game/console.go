@@ -0,0 +1,192 @@
+// Chat and command console
+
+package game
+
+import (
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/colors"
+	"github.com/3elDU/bamboo/commands"
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/font"
+	"github.com/3elDU/bamboo/scene_manager"
+	"github.com/3elDU/bamboo/ui"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// lines, that are kept in the history
+	consoleHistorySize = 100
+	// lines, that are shown at once
+	consoleVisibleLines = 10
+	// while the console is closed, new lines are shown for that many ticks ( 10 seconds )
+	consoleLineDuration uint64 = 600
+)
+
+type consoleLine struct {
+	text  string
+	color color.Color
+	// scene_manager.Ticks(), when the line was added
+	added uint64
+}
+
+// Console shows the chat, and runs the commands.
+// Like the pause menu, it is displayed on top of the game scene
+type console struct {
+	open  bool
+	input *ui.InputComponent
+	// text, typed in the input, is received through this channel, when enter is pressed
+	submitted chan string
+
+	history []consoleLine
+
+	// a black texture, which is drawn behind the lines
+	tex  *ebiten.Image
+	opts *ebiten.DrawImageOptions
+}
+
+func newConsole() *console {
+	tex := ebiten.NewImage(1, 1)
+	tex.Fill(color.RGBA{A: 128})
+
+	c := &console{
+		submitted: make(chan string, 1),
+		tex:       tex,
+		opts:      &ebiten.DrawImageOptions{},
+	}
+	c.input = ui.Input(func(text string) { c.submitted <- text }, ebiten.KeyEnter, true)
+	return c
+}
+
+// Open opens the console with the given text already typed, e.g. "/" for a command
+func (c *console) Open(text string) {
+	c.open = true
+	c.input.SetInput(text)
+	c.input.SetFocused(true)
+}
+
+func (c *console) Close() {
+	c.open = false
+	c.input.SetInput("")
+}
+
+// Print adds the text to the history. Multi-line text is split into separate lines
+func (c *console) Print(text string, clr color.Color) {
+	for _, line := range strings.Split(text, "\n") {
+		c.history = append(c.history, consoleLine{text: line, color: clr, added: scene_manager.Ticks()})
+	}
+	if len(c.history) > consoleHistorySize {
+		c.history = c.history[len(c.history)-consoleHistorySize:]
+	}
+}
+
+// Update returns the submitted text, if enter was pressed. The console is closed then
+func (c *console) Update() (string, bool) {
+	// clicks outside of the input would take the focus away
+	c.input.SetFocused(true)
+	if err := c.input.Update(); err != nil {
+		log.Panicf("console.Update() - %v", err)
+	}
+
+	select {
+	case text := <-c.submitted:
+		c.Close()
+		return strings.TrimSpace(text), true
+	default:
+		return "", false
+	}
+}
+
+// lines, that are visible right now, from the oldest to the newest
+func (c *console) visibleLines() []consoleLine {
+	lines := c.history
+	if len(lines) > consoleVisibleLines {
+		lines = lines[len(lines)-consoleVisibleLines:]
+	}
+	if c.open {
+		return lines
+	}
+
+	for i, line := range lines {
+		if scene_manager.Ticks()-line.added < consoleLineDuration {
+			return lines[i:]
+		}
+	}
+	return nil
+}
+
+func (c *console) Draw(screen *ebiten.Image) error {
+	lines := c.visibleLines()
+	if !c.open && len(lines) == 0 {
+		return nil
+	}
+
+	var (
+		_, sh           = screen.Size()
+		margin          = 2 * config.UIScaling
+		lineHeight      = font.GetStringHeight("", 1)
+		_, hotbarHeight = asset_loader.Texture("inventory").ScaledSize()
+		_, inputHeight  = c.input.ComputedSize()
+		// the console is in the bottom left corner, above the hotbar and the status bars
+		bottom = float64(sh) - hotbarHeight - margin
+	)
+
+	if c.open {
+		bottom -= inputHeight
+		if err := c.input.Draw(screen, margin, bottom); err != nil {
+			return err
+		}
+		bottom -= margin
+	}
+
+	width := 0.0
+	for _, line := range lines {
+		if w := font.GetStringWidth(line.text, 1); w > width {
+			width = w
+		}
+	}
+	top := bottom - lineHeight*float64(len(lines))
+
+	c.opts.GeoM.Reset()
+	c.opts.GeoM.Scale(width+margin*2, bottom-top)
+	c.opts.GeoM.Translate(0, top)
+	screen.DrawImage(c.tex, c.opts)
+
+	for i, line := range lines {
+		font.RenderFont(screen, line.text, margin, top+lineHeight*float64(i), line.color)
+	}
+	return nil
+}
+
+func (game *Game) commandContext() *commands.Context {
+	return &commands.Context{
+		World:       game.world,
+		Player:      game.player,
+		Inventory:   game.inventory,
+		Multiplayer: game.client != nil,
+		Save:        game.Save,
+	}
+}
+
+// submitConsole runs the command, or sends the chat message
+func (game *Game) submitConsole(text string) {
+	switch {
+	case text == "":
+	case commands.IsCommand(text):
+		game.console.Print(text, colors.Gray)
+		output, err := commands.Execute(game.commandContext(), text)
+		if err != nil {
+			game.console.Print(err.Error(), colors.Red)
+		} else if output != "" {
+			game.console.Print(output, colors.Yellow)
+		}
+	case game.client != nil:
+		// the message comes back from the server, along with the messages of the others
+		game.client.SendChat(text)
+	default:
+		game.console.Print(text, colors.White)
+	}
+}
@@ -0,0 +1,258 @@
+// Crafting menu
+
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/3elDU/bamboo/colors"
+	"github.com/3elDU/bamboo/crafting"
+	"github.com/3elDU/bamboo/game/inventory"
+	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/ui"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// how many items of the inventory are shown in one row of the menu
+const craftingMenuItemsPerRow = 10
+
+// Like the pause menu, crafting menu is displayed on top of the game scene.
+// The player picks an item from the inventory, and lays it out in the grid,
+// or picks one of the recipes, that can be crafted, to fill the grid with it.
+// Items stay in the inventory until the result is crafted.
+// UI components can't be changed, so the view is built again on every change
+type craftingMenu struct {
+	view ui.View
+
+	tex  *ebiten.Image
+	opts *ebiten.DrawImageOptions
+
+	inv *inventory.Inventory
+	// item names, "" is an empty cell
+	grid [crafting.GridSize][crafting.GridSize]string
+	// the item, which is put into the cells of the grid
+	selected string
+
+	// index of the pressed cell, y*GridSize + x
+	cellBtn   chan int
+	itemBtn   chan string
+	recipeBtn chan *crafting.Recipe
+	craftBtn  chan bool
+	clearBtn  chan bool
+	closeBtn  chan bool
+}
+
+// "pine_tree" -> "pine tree"
+func itemLabel(name string, count int) string {
+	return fmt.Sprintf("%v %v", count, strings.ReplaceAll(name, "_", " "))
+}
+
+func itemTexture(name string) *ebiten.Image {
+	item, exists := items.GetItemByName(name)
+	if !exists {
+		return nil
+	}
+	return item.Texture()
+}
+
+func newCraftingMenu(inv *inventory.Inventory) *craftingMenu {
+	tex := ebiten.NewImage(1, 1)
+	tex.Fill(color.RGBA{A: 128})
+
+	m := &craftingMenu{
+		tex:  tex,
+		opts: &ebiten.DrawImageOptions{},
+
+		inv: inv,
+
+		cellBtn:   make(chan int, 1),
+		itemBtn:   make(chan string, 1),
+		recipeBtn: make(chan *crafting.Recipe, 1),
+		craftBtn:  make(chan bool, 1),
+		clearBtn:  make(chan bool, 1),
+		closeBtn:  make(chan bool, 1),
+	}
+	m.build()
+	return m
+}
+
+func (m *craftingMenu) build() {
+	labelOpts := ui.LabelOptions{Color: colors.White, Scaling: 1}
+
+	grid := ui.Stack(ui.StackOptions{Direction: ui.VerticalStack})
+	for y := range m.grid {
+		row := ui.Stack(ui.StackOptions{Direction: ui.HorizontalStack})
+		for x := range m.grid[y] {
+			i := y*crafting.GridSize + x
+			row.AddChild(ui.ImageButton(func() { m.cellBtn <- i }, itemTexture(m.grid[y][x])))
+		}
+		grid.AddChild(row)
+	}
+
+	recipe, matches := m.recipe()
+	var result *ebiten.Image
+	resultText := "Nothing matches"
+	if matches {
+		result = itemTexture(recipe.Result.Item)
+		resultText = "Click the result to craft " + itemLabel(recipe.Result.Item, recipe.Result.Count)
+	}
+
+	available := ui.Stack(ui.StackOptions{Direction: ui.VerticalStack, Spacing: 1})
+	recipes := crafting.Available(m.inv)
+	if len(recipes) == 0 {
+		available.AddChild(ui.Label(labelOpts, "Nothing to craft"))
+	}
+	for _, recipe := range recipes {
+		recipe := recipe
+		available.AddChild(ui.Button(func() { m.recipeBtn <- recipe },
+			ui.Label(ui.DefaultLabelOptions(), itemLabel(recipe.Result.Item, recipe.Result.Count))))
+	}
+
+	inventoryItems := ui.Stack(ui.StackOptions{Direction: ui.VerticalStack})
+	var row *ui.StackComponent
+	for i, name := range m.inv.Names() {
+		name := name
+		if i%craftingMenuItemsPerRow == 0 {
+			row = ui.Stack(ui.StackOptions{Direction: ui.HorizontalStack})
+			inventoryItems.AddChild(row)
+		}
+		row.AddChild(ui.ImageButton(func() { m.itemBtn <- name }, itemTexture(name)))
+	}
+
+	selectedText := "Pick an item, then click the cells of the grid"
+	if m.selected != "" {
+		selectedText = "Picked " + itemLabel(m.selected, m.remaining(m.selected))
+	}
+
+	m.view = ui.Screen(ui.Padding(1, ui.Stack(
+		ui.StackOptions{
+			Direction:   ui.VerticalStack,
+			Proportions: []float64{0.2},
+		},
+
+		ui.Center(ui.Label(ui.LabelOptions{Color: colors.White, Scaling: 3.0}, "Crafting")),
+		ui.Center(ui.Stack(ui.StackOptions{Direction: ui.VerticalStack, Spacing: 1},
+			ui.Stack(ui.StackOptions{Direction: ui.HorizontalStack, Spacing: 2},
+				grid,
+				ui.ImageButton(func() { m.craftBtn <- true }, result),
+				available,
+			),
+			ui.Label(labelOpts, resultText),
+			inventoryItems,
+			ui.Label(labelOpts, selectedText),
+			ui.Stack(ui.StackOptions{Direction: ui.HorizontalStack, Spacing: 1},
+				ui.Button(func() { m.clearBtn <- true }, ui.Label(ui.DefaultLabelOptions(), "Clear")),
+				ui.Button(func() { m.closeBtn <- true }, ui.Label(ui.DefaultLabelOptions(), "Close")),
+			),
+		)),
+	)))
+}
+
+// recipe returns the recipe, laid out in the grid
+func (m *craftingMenu) recipe() (*crafting.Recipe, bool) {
+	grid := make([][]string, len(m.grid))
+	for y := range m.grid {
+		grid[y] = m.grid[y][:]
+	}
+	return crafting.Match(grid)
+}
+
+// remaining returns how many items with the given name aren't put into the grid yet
+func (m *craftingMenu) remaining(name string) int {
+	count := m.inv.Count(name)
+	for y := range m.grid {
+		for x := range m.grid[y] {
+			if m.grid[y][x] == name {
+				count--
+			}
+		}
+	}
+	return count
+}
+
+// click puts the picked item into the cell, or empties it
+func (m *craftingMenu) click(x, y int) {
+	switch {
+	case m.selected == "" || m.grid[y][x] == m.selected:
+		m.grid[y][x] = ""
+	case m.remaining(m.selected) > 0:
+		m.grid[y][x] = m.selected
+	}
+}
+
+// fill lays out the recipe in the grid. Shapeless recipes fill the cells row by row
+func (m *craftingMenu) fill(recipe *crafting.Recipe) {
+	m.grid = [crafting.GridSize][crafting.GridSize]string{}
+	if recipe.Shaped() {
+		for y, row := range recipe.Pattern {
+			copy(m.grid[y][:], row)
+		}
+		return
+	}
+
+	i := 0
+	for _, ingredient := range recipe.Ingredients {
+		for n := 0; n < ingredient.Count; n++ {
+			m.grid[i/crafting.GridSize][i%crafting.GridSize] = ingredient.Item
+			i++
+		}
+	}
+}
+
+// Refresh removes the items, that are no longer in the inventory, from the grid.
+// The rest of the grid is kept, so the same recipe can be crafted again
+func (m *craftingMenu) Refresh() {
+	for y := len(m.grid) - 1; y >= 0; y-- {
+		for x := len(m.grid[y]) - 1; x >= 0; x-- {
+			if name := m.grid[y][x]; name != "" && m.remaining(name) < 0 {
+				m.grid[y][x] = ""
+			}
+		}
+	}
+	if m.inv.Count(m.selected) == 0 {
+		m.selected = ""
+	}
+	m.build()
+}
+
+func (m *craftingMenu) Draw(screen *ebiten.Image) error {
+	m.opts.GeoM.Reset()
+	w, h := screen.Size()
+	m.opts.GeoM.Scale(float64(w)+2, float64(h)+2)
+	screen.DrawImage(m.tex, m.opts)
+
+	return m.view.Draw(screen, 0, 0)
+}
+
+// Update returns the recipe to craft, if any, and whether the menu was closed
+func (m *craftingMenu) Update() (*crafting.Recipe, bool) {
+	if err := m.view.Update(); err != nil {
+		log.Panicf("craftingMenu.Update() - %v", err)
+	}
+
+	select {
+	case i := <-m.cellBtn:
+		m.click(i%crafting.GridSize, i/crafting.GridSize)
+		m.build()
+	case recipe := <-m.recipeBtn:
+		m.fill(recipe)
+		m.build()
+	case name := <-m.itemBtn:
+		m.selected = name
+		m.build()
+	case <-m.clearBtn:
+		m.grid = [crafting.GridSize][crafting.GridSize]string{}
+		m.build()
+	case <-m.craftBtn:
+		// the grid never holds more items, than there are in the inventory
+		if recipe, matches := m.recipe(); matches {
+			return recipe, false
+		}
+	case <-m.closeBtn:
+		return nil, true
+	}
+	return nil, false
+}
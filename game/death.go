@@ -0,0 +1,97 @@
+// Death screen
+
+package game
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/3elDU/bamboo/colors"
+	"github.com/3elDU/bamboo/scene_manager"
+	"github.com/3elDU/bamboo/ui"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// deathScene is shown on top of the game, when the player dies.
+// Unlike the pause menu, it is a separate scene, so the world is frozen in the background
+type deathScene struct {
+	game *Game
+	view ui.View
+
+	// translucent red texture, to tint the background
+	tex  *ebiten.Image
+	opts *ebiten.DrawImageOptions
+
+	respawnBtn, exitBtn chan bool
+}
+
+func newDeathScene(game *Game) *deathScene {
+	tex := ebiten.NewImage(1, 1)
+	tex.Fill(color.RGBA{R: 96, A: 128})
+
+	var (
+		respawnBtn = make(chan bool, 1)
+		exitBtn    = make(chan bool, 1)
+	)
+
+	return &deathScene{
+		game: game,
+		tex:  tex,
+		opts: &ebiten.DrawImageOptions{},
+
+		respawnBtn: respawnBtn,
+		exitBtn:    exitBtn,
+
+		view: ui.Screen(ui.Padding(1, ui.Stack(
+			ui.StackOptions{
+				Direction:   ui.VerticalStack,
+				Proportions: []float64{0.3},
+			},
+
+			ui.Center(ui.Label(ui.LabelOptions{Color: colors.White, Scaling: 3.0}, "You died")),
+
+			ui.Center(ui.Stack(ui.StackOptions{Direction: ui.VerticalStack, Spacing: 1},
+				ui.Button(func() { respawnBtn <- true }, ui.Label(ui.DefaultLabelOptions(), "Respawn")),
+				ui.Button(func() { exitBtn <- true }, ui.Label(ui.DefaultLabelOptions(), "Exit to main menu")),
+			)),
+		))),
+	}
+}
+
+func (s *deathScene) Update() {
+	if err := s.view.Update(); err != nil {
+		log.Panicf("deathScene.Update() - %v", err)
+	}
+
+	select {
+	case <-s.respawnBtn:
+		log.Println("deathScene - \"Respawn\" button pressed")
+		s.game.respawn()
+		// back to the game
+		scene_manager.Pop()
+	case <-s.exitBtn:
+		log.Println("deathScene - \"Exit to main menu\" button pressed")
+		// the first Pop() returns to the game, and the second one exits it, saving the world.
+		// The player stays dead, so the death screen will be shown again, when the world is loaded
+		scene_manager.Pop()
+		scene_manager.Pop()
+	default:
+	}
+}
+
+func (s *deathScene) Draw(screen *ebiten.Image) {
+	s.game.Draw(screen)
+
+	s.opts.GeoM.Reset()
+	w, h := screen.Size()
+	s.opts.GeoM.Scale(float64(w)+2, float64(h)+2)
+	screen.DrawImage(s.tex, s.opts)
+
+	if err := s.view.Draw(screen, 0, 0); err != nil {
+		log.Panicf("error while rendering death screen - %v", err)
+	}
+}
+
+func (s *deathScene) Destroy() {
+	log.Println("deathScene.Destroy() called")
+}
@@ -1,22 +1,30 @@
 package game
 
 import (
+	"errors"
 	"fmt"
 	"github.com/3elDU/bamboo/blocks"
 	"github.com/3elDU/bamboo/colors"
+	"github.com/3elDU/bamboo/commands"
 	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/crafting"
 	"github.com/3elDU/bamboo/event"
 	"github.com/3elDU/bamboo/font"
 	"github.com/3elDU/bamboo/game/inventory"
 	"github.com/3elDU/bamboo/game/player"
 	"github.com/3elDU/bamboo/game/widgets"
 	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/network"
+	// registers mob entities and their spawner
+	_ "github.com/3elDU/bamboo/mobs"
 	"github.com/3elDU/bamboo/scene_manager"
+	"github.com/3elDU/bamboo/scripting"
 	"github.com/3elDU/bamboo/types"
 	"github.com/3elDU/bamboo/widget"
 	"github.com/3elDU/bamboo/world"
 	"github.com/3elDU/bamboo/world_type"
 	"github.com/MakeNowJust/heredoc"
+	"github.com/google/uuid"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"log"
@@ -28,33 +36,64 @@ type Game struct {
 
 	paused    bool
 	pauseMenu *pauseMenu
+	// nil, when the crafting menu is closed
+	craftingMenu *craftingMenu
+	console      *console
 
 	world     *world.World
 	player    *player.Player
 	inventory *inventory.Inventory
+	miner     miner
+
+	// subscription to the events of the current world
+	caveSubscription *event.Subscription
+	// set, when the player walks into a cave entrance
+	enteredCave uuid.UUID
+
+	// set in multiplayer. server is set only for the host
+	client *network.Client
+	server *network.Server
+	// other players, by their IDs. Players are added to the world, once their position is known
+	remotePlayers     map[uint32]*network.RemotePlayer
+	remotePlayerNames map[uint32]string
 
 	debugInfoVisible bool
 }
 
-func newGame(gameWorld *world.World, player *player.Player) *Game {
+func newGame(gameWorld *world.World, gamePlayer *player.Player) *Game {
+	// the player is updated and rendered by the world, along with other entities
+	gameWorld.AddEntity(gamePlayer)
+
 	game := &Game{
 		widgets:      widget.NewWidgetContainer(),
 		debugWidgets: widget.NewWidgetContainer(),
 
 		pauseMenu: newPauseMenu(),
+		console:   newConsole(),
 
 		world:     gameWorld,
-		player:    player,
+		player:    gamePlayer,
 		inventory: inventory.NewInventory(),
 
 		debugInfoVisible: false,
 	}
+	game.subscribe()
 
 	game.debugWidgets.AddTextWidget(
 		"debug",
 		&widgets.PerfWidget{Color: colors.Black},
 	)
 
+	// the player is replaced on respawn, so the bars always look it up through the game
+	game.widgets.AddWidget("stats", &widgets.StatusBarsWidget{
+		Anc: widget.BottomLeft,
+		Bars: []widgets.Bar{
+			{Value: func() float64 { return game.player.Stats.Health / player.MaxHealth }, Color: colors.Red},
+			{Value: func() float64 { return game.player.Stats.Hunger / player.MaxHunger }, Color: colors.Orange},
+			{Value: func() float64 { return game.player.Stats.Stamina / player.MaxStamina }, Color: colors.Yellow},
+		},
+	})
+
 	return game
 }
 
@@ -74,20 +113,63 @@ func NewGameScene(metadata types.Save) *Game {
 }
 
 // Creates a game scene from existing world
-func LoadGameScene(metadata types.Save) *Game {
+func LoadGameScene(metadata types.Save) (*Game, error) {
 	// load the player first, to determine which world to load
-	loadedPlayer := player.LoadPlayer(metadata.BaseUUID)
-	loadedWorld := world.Load(metadata.BaseUUID, loadedPlayer.SelectedWorld.UUID)
-	return newGame(loadedWorld, loadedPlayer)
+	loadedPlayer, err := player.LoadPlayer(metadata.BaseUUID)
+	var loadedWorld *world.World
+	if errors.Is(err, world.ErrNotFound) {
+		// without the player file, there is no way to know the sub-world the player was in,
+		// so spawn the player in the given world
+		log.Printf("LoadGameScene() - %v; spawning the player in %v", err, metadata.UUID)
+		loadedWorld, err = world.Load(metadata.BaseUUID, metadata.UUID)
+		if err != nil {
+			return nil, err
+		}
+		loadedPlayer = player.NewPlayer(loadedWorld)
+	} else if err != nil {
+		return nil, err
+	} else {
+		loadedWorld, err = world.Load(metadata.BaseUUID, loadedPlayer.SelectedWorld.UUID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	game := newGame(loadedWorld, loadedPlayer)
+
+	loadedInventory, err := inventory.LoadInventory(metadata.BaseUUID)
+	switch {
+	case errors.Is(err, world.ErrNotFound):
+		// saves made before the inventory was persisted, start with an empty one
+	case err != nil:
+		return nil, err
+	default:
+		game.inventory = loadedInventory
+	}
+
+	return game, nil
 }
 
 func (game *Game) Save() {
 	game.world.Save()
+	if !game.hasOwnSave() {
+		return
+	}
 	game.player.Save(game.world.Metadata())
+	if err := game.inventory.Save(game.world.Metadata().BaseUUID); err != nil {
+		log.Panicf("failed to save the inventory - %v", err)
+	}
 }
 
 func (game *Game) processInput() {
-	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+	// Escape closes the console, the crafting menu and the backpack first
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) && game.console.open {
+		game.console.Close()
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyEscape) && game.craftingMenu != nil {
+		game.craftingMenu = nil
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyEscape) && game.inventory.BackpackOpen() {
+		game.inventory.CloseBackpack()
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
 		game.paused = !game.paused
 		log.Printf("Escape pressed. Toggled pause menu. (%v)", game.paused)
 
@@ -98,6 +180,10 @@ func (game *Game) processInput() {
 	}
 
 	if game.paused {
+		// in multiplayer, the world keeps going while the game is paused
+		game.player.SetMovement(player.MovementVector{})
+		game.miner = miner{}
+
 		switch game.pauseMenu.ButtonPressed() {
 		case continueButtonPressed:
 			game.paused = false
@@ -108,12 +194,90 @@ func (game *Game) processInput() {
 		return
 	}
 
-	game.player.Update(player.MovementVector{
+	if game.console.open {
+		// keys go to the console, instead of moving the player
+		game.player.SetMovement(player.MovementVector{})
+		game.miner = miner{}
+
+		if text, submitted := game.console.Update(); submitted {
+			game.submitConsole(text)
+		}
+		return
+	}
+
+	// Tab toggles the crafting menu, and I toggles the backpack.
+	// Only one of them is open at a time
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyTab):
+		game.inventory.CloseBackpack()
+		if game.craftingMenu == nil {
+			game.craftingMenu = newCraftingMenu(game.inventory)
+		} else {
+			game.craftingMenu = nil
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyI):
+		game.craftingMenu = nil
+		if game.inventory.BackpackOpen() {
+			game.inventory.CloseBackpack()
+		} else {
+			game.inventory.OpenBackpack()
+		}
+	}
+
+	if game.craftingMenu != nil {
+		// the player stands still, while crafting
+		game.player.SetMovement(player.MovementVector{})
+		game.miner = miner{}
+
+		recipe, closed := game.craftingMenu.Update()
+		switch {
+		case closed:
+			game.craftingMenu = nil
+		case recipe != nil:
+			if err := crafting.Craft(game.inventory, recipe); err != nil {
+				log.Printf("failed to craft %v - %v", recipe.Name, err)
+			} else if game.client != nil {
+				game.client.Craft(recipe.Name)
+			}
+			// the ingredients are gone from the inventory
+			game.craftingMenu.Refresh()
+		}
+		return
+	}
+
+	if game.inventory.BackpackOpen() {
+		game.player.SetMovement(player.MovementVector{})
+		game.miner = miner{}
+
+		game.inventory.Update()
+		return
+	}
+
+	// T opens the chat, and slash opens it with a command started
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyT):
+		game.console.Open("")
+		return
+	case inpututil.IsKeyJustPressed(ebiten.KeySlash):
+		game.console.Open(commands.Prefix)
+		return
+	}
+
+	game.player.SetMovement(player.MovementVector{
 		Left:  ebiten.IsKeyPressed(ebiten.KeyA),
 		Right: ebiten.IsKeyPressed(ebiten.KeyD),
 		Up:    ebiten.IsKeyPressed(ebiten.KeyW),
 		Down:  ebiten.IsKeyPressed(ebiten.KeyS),
-	}, game.world)
+
+		Sprint: ebiten.IsKeyPressed(ebiten.KeyShift),
+	})
+
+	// Hold C to break the block in front of the player
+	if ebiten.IsKeyPressed(ebiten.KeyC) {
+		game.mine()
+	} else {
+		game.miner = miner{}
+	}
 
 	// Check for key presses
 	switch {
@@ -122,17 +286,6 @@ func (game *Game) processInput() {
 		game.debugInfoVisible = !game.debugInfoVisible
 		log.Printf("Toggled visibility of debug info. (%v)", game.debugInfoVisible)
 
-	// Interact with the nearby block
-	case ebiten.IsKeyPressed(ebiten.KeyC):
-		block := game.world.BlockAt(uint64(game.player.X), uint64(game.player.Y))
-		drawable, ok := block.(types.DrawableBlock)
-		if !ok {
-			break
-		}
-
-		item := items.NewItemFromBlock(drawable)
-		game.inventory.AddItem(item)
-
 	// Use the item in hand
 	case ebiten.IsKeyPressed(ebiten.KeyF):
 		itemInHand := game.inventory.Slots[game.inventory.SelectedSlot].Item
@@ -144,6 +297,19 @@ func (game *Game) processInput() {
 			Y: uint64(game.player.Y),
 		})
 
+	// Eat the item in hand
+	case inpututil.IsKeyJustPressed(ebiten.KeyE):
+		slot := game.inventory.Slots[game.inventory.SelectedSlot]
+		if slot.Empty {
+			break
+		}
+		food, edible := items.FoodOf(slot.Item)
+		if !edible {
+			break
+		}
+		game.player.Eat(food)
+		slot.RemoveItem(1)
+
 	// Inventory slots selection
 	case ebiten.IsKeyPressed(ebiten.KeyDigit1):
 		game.inventory.SelectSlot(0)
@@ -166,7 +332,7 @@ func (game *Game) processInput() {
 }
 
 func (game *Game) updateLogic() {
-	if game.paused {
+	if game.paused && game.client == nil {
 		return
 	}
 
@@ -182,61 +348,141 @@ func (game *Game) updateLogic() {
 	}
 }
 
-func (game *Game) handleEvents() {
-	for _, ev := range event.GetEvents() {
-		switch ev.Type() {
-		case event.CaveEntered:
-			// save the previous world before switching to a new one
-			game.Save()
+// subscribe subscribes the game to the events of the current world. Called each time the world changes
+func (game *Game) subscribe() {
+	if game.caveSubscription != nil {
+		game.caveSubscription.Unsubscribe()
+	}
+	game.caveSubscription = event.Subscribe(game.world.Events(), event.Normal, func(ev *types.CaveEnteredEvent) {
+		// only one world is replicated from the server
+		if game.client != nil {
+			log.Println("Game - caves aren't supported in multiplayer yet")
+			ev.Cancel()
+			return
+		}
+		// the player is in the middle of its physics update, so the world is switched at the end of the tick
+		game.enteredCave = ev.ID
+	})
+}
 
-			caveID := ev.Args().(event.CaveEnteredArgs).ID
+// enterCave moves the player to the cave with the given ID, or back to the overworld
+func (game *Game) enterCave(caveID uuid.UUID) {
+	// save the previous world before switching to a new one
+	game.Save()
 
-			caveExit := blocks.NewCaveEntranceBlock(game.world.Metadata().UUID)
+	caveExit := blocks.NewCaveEntranceBlock(game.world.Metadata().UUID)
 
-			metadata := types.Save{
-				Name:      game.world.Metadata().Name,
-				BaseUUID:  game.world.Metadata().BaseUUID,
-				UUID:      caveID,
-				Seed:      int64(caveID.ID()),
-				WorldType: world_type.Cave,
-			}
+	metadata := types.Save{
+		Name:      game.world.Metadata().Name,
+		BaseUUID:  game.world.Metadata().BaseUUID,
+		UUID:      caveID,
+		Seed:      int64(caveID.ID()),
+		WorldType: world_type.Cave,
+	}
 
-			var newWorld *world.World
-			// Check if the world already exists on disk
-			if world.ExistsOnDisk(metadata) {
-				newWorld = world.Load(metadata.BaseUUID, metadata.UUID)
-			} else {
-				newWorld = world.NewWorld(metadata)
-			}
+	var newWorld *world.World
+	// Check if the world already exists on disk
+	if world.ExistsOnDisk(metadata) {
+		var err error
+		newWorld, err = world.Load(metadata.BaseUUID, metadata.UUID)
+		if err != nil {
+			// stay in the current world, instead of crashing the game
+			log.Printf("Game.enterCave() - failed to enter the cave - %v", err)
+			return
+		}
+	} else {
+		newWorld = world.NewWorld(metadata)
+	}
+	// the clock is shared by all worlds of the save
+	newWorld.SetTime(game.world.Time())
+
+	game.world.RemoveEntity(game.player)
+	stats := game.player.Stats
+	game.player = player.NewPlayer(newWorld)
+	game.player.Stats = stats
+	newWorld.AddEntity(game.player)
+
+	// if we're switching from cave to overworld, don't place the cave exit.
+	// also don't place cave exit if that chunk already exists on disk, so we don't overwrite it
+	exitOnDisk, err := world.ChunkExistsOnDisk(
+		newWorld.Metadata(),
+		uint64(game.player.X+2)/16, uint64(game.player.Y)/16,
+	)
+	if err != nil {
+		log.Printf("Game.enterCave() - %v", err)
+	}
+	if newWorld.Metadata().WorldType == world_type.Cave && err == nil && !exitOnDisk {
+		// place a portal to overworld next to the player
+		newWorld.SetBlock(uint64(game.player.X)+2, uint64(game.player.Y), caveExit)
+	}
 
-			game.player = player.NewPlayer(newWorld)
+	previousWorld := game.world
+	game.world = newWorld
+	game.miner = miner{}
+	game.subscribe()
 
-			// if we're switching from cave to overworld, don't place the cave exit.
-			// also don't place cave exit if that chunk already exists on disk, so we don't overwrite it
-			if newWorld.Metadata().WorldType == world_type.Cave && !world.ChunkExistsOnDisk(
-				newWorld.Metadata(),
-				uint64(game.player.X+2)/16, uint64(game.player.Y)/16,
-			) {
-				// place a portal to overworld next to the player
-				newWorld.SetBlock(uint64(game.player.X)+2, uint64(game.player.Y), caveExit)
-			}
+	changed := &types.PlayerChangedWorldEvent{Player: game.player, From: previousWorld, To: newWorld}
+	event.Fire(previousWorld.Events(), changed)
+	event.Fire(newWorld.Events(), changed)
+	// the player is gone from the previous world, so it is saved one last time
+	previousWorld.Close()
 
-			game.world = newWorld
+	game.Save()
+}
 
-			game.Save()
-		}
+// respawn places the player at a new spawn point in the current world.
+// The inventory is kept
+func (game *Game) respawn() {
+	game.world.RemoveEntity(game.player)
+	game.player = player.NewPlayer(game.world)
+	game.world.AddEntity(game.player)
+	if game.client != nil {
+		game.client.Respawn()
 	}
+
+	game.Save()
 }
 
 func (game *Game) Update() {
+	// this also catches players, that were saved dead
+	if game.player.Dead() {
+		log.Println("The player died")
+		game.Save()
+		scene_manager.PushAndSwitch(newDeathScene(game))
+		return
+	}
+
+	// before the input, since exiting through the pause menu closes the connection
+	if game.client != nil && !game.updateMultiplayer() {
+		return
+	}
+
+	// e.g. a chunk, saved by a newer version of the game. The world is saved and closed, before the chunk is overwritten
+	if err := game.world.Err(); err != nil {
+		log.Printf("Game - can't continue in this world - %v", err)
+		scene_manager.Pop()
+		return
+	}
+
+	scripting.SetContext(scripting.Context{
+		World:       game.world,
+		Player:      game.player,
+		Inventory:   game.inventory,
+		Multiplayer: game.client != nil,
+	})
+
 	game.processInput()
 	game.updateLogic()
-	game.handleEvents()
+
+	if game.enteredCave != uuid.Nil {
+		game.enterCave(game.enteredCave)
+		game.enteredCave = uuid.Nil
+	}
 }
 
 func (game *Game) Draw(screen *ebiten.Image) {
 	game.world.Render(screen, game.player.X, game.player.Y, config.UIScaling)
-	game.player.Render(screen, config.UIScaling, game.paused)
+	game.renderCracks(screen, config.UIScaling)
 	game.inventory.Render(screen)
 
 	game.widgets.Render(screen)
@@ -244,20 +490,32 @@ func (game *Game) Draw(screen *ebiten.Image) {
 		game.debugWidgets.Render(screen)
 	}
 
+	if err := game.console.Draw(screen); err != nil {
+		log.Panicf("error while rendering console - %v", err)
+	}
+
 	if game.debugInfoVisible {
 		font.RenderFont(screen,
 			fmt.Sprintf(
 				heredoc.Doc(`
 					player pos:		%.2f, %.2f
 					world seed:		%v
+					world time:		day %v, %.2f
 					UI scaling:		%v
 				`),
-				game.player.X, game.player.Y, game.world.Seed(), config.UIScaling,
+				game.player.X, game.player.Y, game.world.Seed(),
+				game.world.Time().Day(), game.world.Time().TimeOfDay(), config.UIScaling,
 			),
 			0, 0, colors.Black,
 		)
 	}
 
+	if game.craftingMenu != nil {
+		if err := game.craftingMenu.Draw(screen); err != nil {
+			log.Panicf("error while rendering crafting menu - %v", err)
+		}
+	}
+
 	// draw pause menu
 	if game.paused {
 		err := game.pauseMenu.Draw(screen)
@@ -269,5 +527,7 @@ func (game *Game) Draw(screen *ebiten.Image) {
 
 func (game *Game) Destroy() {
 	game.Save()
+	game.world.Close()
+	game.closeMultiplayer()
 	log.Println("GameScene.Destroy() called")
 }
@@ -0,0 +1,183 @@
+/*
+	Backpack - the inventory grid, which is opened with a key.
+	Items are moved between the slots with the mouse:
+	- left click picks up the whole stack, or puts the dragged items into the slot
+	- holding the left button, the stack can be dragged to another slot
+	- right click picks up half of the stack, or puts one of the dragged items into the slot
+	- shift + left click moves the stack between the hotbar and the backpack
+*/
+
+package inventory
+
+import (
+	"log"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/colors"
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/font"
+	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/types"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+func (inv *Inventory) BackpackOpen() bool {
+	return inv.backpackOpen
+}
+
+func (inv *Inventory) OpenBackpack() {
+	inv.backpackOpen = true
+}
+
+// CloseBackpack puts the dragged items back into the inventory
+func (inv *Inventory) CloseBackpack() {
+	inv.backpackOpen = false
+	if inv.cursor.Empty {
+		return
+	}
+
+	// try the slot, where the items were taken from, first
+	move(&inv.cursor, inv.Slots[inv.cursorSource], inv.cursor.Quantity)
+	moveToSlots(&inv.cursor, inv.Slots[:])
+
+	if !inv.cursor.Empty {
+		log.Printf("Inventory.CloseBackpack() - no space for %v %v", inv.cursor.Quantity, items.NameOf(inv.cursor.Item))
+		inv.cursor = types.ItemSlot{Empty: true}
+	}
+}
+
+// move moves up to count items between the slots.
+// When the stack is split, the new stack gets its own copy of the item, so their metadata is independent
+func move(from, to *types.ItemSlot, count uint8) {
+	from.MoveTo(to, count)
+	if !from.Empty && !to.Empty && from.Item == to.Item {
+		to.Item = items.Copy(to.Item)
+	}
+}
+
+// moves the items to the given slots, filling the stacks of the same items first, and then the empty slots
+func moveToSlots(from *types.ItemSlot, slots []*types.ItemSlot) {
+	for _, fillEmpty := range [2]bool{false, true} {
+		for _, slot := range slots {
+			if from.Empty {
+				return
+			}
+			if slot.Empty == fillEmpty {
+				move(from, slot, from.Quantity)
+			}
+		}
+	}
+}
+
+// slotAt returns the index of the slot at the given screen position
+func (inv *Inventory) slotAt(x, y int) (int, bool) {
+	for i := range inv.Slots {
+		// the backpack slots can't be clicked, while it is closed
+		if i >= HotbarSize && !inv.backpackOpen {
+			break
+		}
+
+		sx, sy := slotPosition(i, inv.screenWidth, inv.screenHeight)
+		// slot textures overlap by two pixels, so the clickable area is a bit smaller
+		if float64(x) >= sx && float64(x) < sx+20*config.UIScaling &&
+			float64(y) >= sy && float64(y) < sy+22*config.UIScaling {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// picks up count items from the slot
+func (inv *Inventory) pickUp(i int, count uint8) {
+	move(inv.Slots[i], &inv.cursor, count)
+	inv.cursorSource = i
+}
+
+// puts count dragged items into the slot.
+// If the slot holds different items, and all the dragged items are put, they are swapped
+func (inv *Inventory) put(i int, count uint8) {
+	slot := inv.Slots[i]
+	// so the items aren't put again, when the mouse button is released over the same slot
+	inv.cursorSource = i
+
+	if slot.Stacks(inv.cursor.Item) {
+		move(&inv.cursor, slot, count)
+	} else if count == inv.cursor.Quantity {
+		*slot, inv.cursor = inv.cursor, *slot
+	}
+}
+
+// quickMove moves the stack from the hotbar to the backpack, or vice versa
+func (inv *Inventory) quickMove(i int) {
+	if i < HotbarSize {
+		moveToSlots(inv.Slots[i], inv.Slots[HotbarSize:])
+	} else {
+		moveToSlots(inv.Slots[i], inv.Slots[:HotbarSize])
+	}
+}
+
+// Update handles the mouse input, while the backpack is open
+func (inv *Inventory) Update() {
+	if !inv.backpackOpen {
+		return
+	}
+
+	i, overSlot := inv.slotAt(ebiten.CursorPosition())
+	switch {
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		switch {
+		case !overSlot:
+		case ebiten.IsKeyPressed(ebiten.KeyShift) && inv.cursor.Empty:
+			inv.quickMove(i)
+		case inv.cursor.Empty:
+			inv.pickUp(i, inv.Slots[i].Quantity)
+		default:
+			inv.put(i, inv.cursor.Quantity)
+		}
+
+	// the items were dragged to another slot
+	case inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft):
+		if overSlot && !inv.cursor.Empty && i != inv.cursorSource {
+			inv.put(i, inv.cursor.Quantity)
+		}
+
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight):
+		switch {
+		case !overSlot:
+		case inv.cursor.Empty:
+			// the bigger half is taken
+			inv.pickUp(i, inv.Slots[i].Quantity-inv.Slots[i].Quantity/2)
+		default:
+			inv.put(i, 1)
+		}
+	}
+}
+
+func (inv *Inventory) renderBackpack(screen *ebiten.Image) {
+	rowTexture := asset_loader.Texture("inventory").Texture()
+	sw, sh := screen.Size()
+
+	opts := &ebiten.DrawImageOptions{}
+	for row := 0; row < BackpackRows; row++ {
+		opts.GeoM.Reset()
+		opts.GeoM.Scale(config.UIScaling, config.UIScaling)
+		opts.GeoM.Translate(backpackRowPosition(row, sw, sh))
+		screen.DrawImage(rowTexture, opts)
+	}
+
+	for i, slot := range inv.Slots[HotbarSize:] {
+		x, y := slotPosition(HotbarSize+i, sw, sh)
+		renderSlot(screen, slot, x, y)
+	}
+
+	// dragged items follow the mouse
+	cx, cy := ebiten.CursorPosition()
+	renderSlot(screen, &inv.cursor, float64(cx)-10*config.UIScaling, float64(cy)-10*config.UIScaling)
+
+	// name of the item under the mouse
+	if i, overSlot := inv.slotAt(cx, cy); overSlot && inv.cursor.Empty && !inv.Slots[i].Empty {
+		name := items.DisplayName(inv.Slots[i].Item)
+		font.RenderFont(screen, name, float64(cx)+8*config.UIScaling, float64(cy)-font.GetStringHeight(name, 1), colors.White)
+	}
+}
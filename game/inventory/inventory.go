@@ -1,17 +1,40 @@
 package inventory
 
 import (
+	"strconv"
+
 	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/colors"
 	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/font"
+	"github.com/3elDU/bamboo/items"
 	"github.com/3elDU/bamboo/types"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-const Size = 5
+const (
+	// the hotbar is always visible at the bottom of the screen
+	HotbarSize = 5
+	// the backpack is a grid of BackpackRows rows, each as wide as the hotbar
+	BackpackRows = 4
+	BackpackSize = HotbarSize * BackpackRows
+
+	Size = HotbarSize + BackpackSize
+)
 
+// Slots of the hotbar come first, followed by the backpack slots
 type Inventory struct {
 	Slots        [Size]*types.ItemSlot
 	SelectedSlot int
+
+	// state of the backpack UI, see backpack.go
+	backpackOpen bool
+	// items, dragged with the mouse
+	cursor types.ItemSlot
+	// slot, where the dragged items were taken from
+	cursorSource int
+	// size of the screen, where the inventory was last rendered, to find the slot under the mouse
+	screenWidth, screenHeight int
 }
 
 func NewInventory() *Inventory {
@@ -20,6 +43,7 @@ func NewInventory() *Inventory {
 		inv.Slots[i] = new(types.ItemSlot)
 		inv.Slots[i].Empty = true
 	}
+	inv.cursor.Empty = true
 
 	return &inv
 }
@@ -34,11 +58,74 @@ func (inv *Inventory) AddItem(item types.Item) bool {
 	return false
 }
 
+// Count returns the total quantity of the items with the given name
+func (inv *Inventory) Count(name string) (count int) {
+	for _, slot := range inv.Slots {
+		if !slot.Empty && items.NameOf(slot.Item) == name {
+			count += int(slot.Quantity)
+		}
+	}
+	return
+}
+
+// Names returns the names of the items in the inventory, each name once, in the order of the slots
+func (inv *Inventory) Names() (names []string) {
+	seen := make(map[string]bool)
+	for _, slot := range inv.Slots {
+		if slot.Empty {
+			continue
+		}
+		name := items.NameOf(slot.Item)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+// Remove takes count items with the given name from the inventory.
+// Returns false, leaving the inventory untouched, if there are not enough items
+func (inv *Inventory) Remove(name string, count int) bool {
+	if inv.Count(name) < count {
+		return false
+	}
+
+	for _, slot := range inv.Slots {
+		if count == 0 {
+			break
+		}
+		if slot.Empty || items.NameOf(slot.Item) != name {
+			continue
+		}
+
+		taken := count
+		if taken > int(slot.Quantity) {
+			taken = int(slot.Quantity)
+		}
+		slot.RemoveItem(uint8(taken))
+		count -= taken
+	}
+	return true
+}
+
+// Copy returns a copy of the inventory, which can be modified without affecting the original.
+// Items themselves are shared
+func (inv *Inventory) Copy() *Inventory {
+	c := &Inventory{SelectedSlot: inv.SelectedSlot, cursor: types.ItemSlot{Empty: true}}
+	for i, slot := range inv.Slots {
+		slotCopy := *slot
+		c.Slots[i] = &slotCopy
+	}
+	return c
+}
+
+// SelectSlot selects one of the hotbar slots
 func (inv *Inventory) SelectSlot(slot int) {
-	if slot >= Size {
+	if slot >= HotbarSize {
 		slot = 0
 	} else if slot < 0 {
-		slot = Size - 1
+		slot = HotbarSize - 1
 	}
 
 	inv.SelectedSlot = slot
@@ -48,48 +135,109 @@ func (inv *Inventory) ItemInHand() types.Item {
 	return inv.Slots[inv.SelectedSlot].Item
 }
 
-func (inv *Inventory) Render(screen *ebiten.Image) {
-	inventoryTexture := asset_loader.Texture("inventory")
-	w, h := inventoryTexture.ScaledSize()
-	inventoryDrawOpts := &ebiten.DrawImageOptions{}
-	sw, sh := screen.Size()
+// position of the hotbar texture on the screen
+func hotbarPosition(sw, sh int) (x, y float64) {
+	w, h := asset_loader.Texture("inventory").ScaledSize()
+	// horizontally centered, at the bottom of the screen
+	return float64(sw)/2 - w/2, float64(sh) - h
+}
 
-	// position of inventory texture on the screen
-	ix := float64(sw)/2 - float64(w)/2 // horizontally centered
-	iy := float64(sh) - float64(h)     // bottom of the screen
+// position of the row of the backpack on the screen
+func backpackRowPosition(row int, sw, sh int) (x, y float64) {
+	// rows use the same texture as the hotbar, and are stacked in the center of the screen
+	w, h := asset_loader.Texture("inventory").ScaledSize()
+	return float64(sw)/2 - w/2, float64(sh)/2 - h*BackpackRows/2 + h*float64(row)
+}
 
-	inventoryDrawOpts.GeoM.Scale(float64(config.UIScaling), float64(config.UIScaling))
-	inventoryDrawOpts.GeoM.Translate(ix, iy)
+// slotPosition returns the position of the top left corner of the slot on the screen
+func slotPosition(i int, sw, sh int) (x, y float64) {
+	var column int
+	if i < HotbarSize {
+		column = i
+		x, y = hotbarPosition(sw, sh)
+	} else {
+		column = (i - HotbarSize) % HotbarSize
+		x, y = backpackRowPosition((i-HotbarSize)/HotbarSize, sw, sh)
+	}
+	return x + (1+20*float64(column))*config.UIScaling, y
+}
 
-	screen.DrawImage(inventoryTexture.Texture(), inventoryDrawOpts)
+// draws the item of the slot, and its quantity, if there is more than one
+func renderSlot(screen *ebiten.Image, slot *types.ItemSlot, x, y float64) {
+	if slot.Empty {
+		return
+	}
 
-	for i, slot := range inv.Slots {
-		if slot.Empty {
-			continue
-		}
+	itemTexOpts := &ebiten.DrawImageOptions{}
+	itemTexOpts.GeoM.Scale(config.UIScaling, config.UIScaling)
+	itemTexOpts.GeoM.Translate(x+3*config.UIScaling, y+3*config.UIScaling)
+	screen.DrawImage(slot.Item.Texture(), itemTexOpts)
 
-		itemTex := slot.Item.Texture()
-		itemTexOpts := &ebiten.DrawImageOptions{}
+	// worn tools have a bar, showing their remaining durability
+	if tool, ok := slot.Item.(*items.ToolItem); ok && tool.Durability() < items.ToolDurability {
+		renderDurabilityBar(screen, float64(tool.Durability())/items.ToolDurability, x, y)
+	}
 
-		itemTexOpts.GeoM.Scale(float64(config.UIScaling), float64(config.UIScaling))
-		itemTexOpts.GeoM.Translate(
-			ix+4*float64(config.UIScaling)+(20*float64(i)*float64(config.UIScaling)),
-			iy+3*float64(config.UIScaling),
+	if slot.Quantity > 1 {
+		quantity := strconv.Itoa(int(slot.Quantity))
+		// bottom right corner of the slot
+		font.RenderFont(screen, quantity,
+			x+20*config.UIScaling-font.GetStringWidth(quantity, 1),
+			y+20*config.UIScaling-font.GetStringHeight(quantity, 1),
+			colors.White,
 		)
+	}
+}
 
-		screen.DrawImage(itemTex, itemTexOpts)
+// a white pixel, stretched and tinted to draw durability bars
+var barTexture *ebiten.Image
+
+func renderDurabilityBar(screen *ebiten.Image, durability float64, x, y float64) {
+	if barTexture == nil {
+		barTexture = ebiten.NewImage(1, 1)
+		barTexture.Fill(colors.White)
+	}
+
+	clr := colors.Green
+	if durability < 0.25 {
+		clr = colors.Red
+	}
+
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Scale(14*durability*config.UIScaling, config.UIScaling)
+	// under the item texture
+	opts.GeoM.Translate(x+4*config.UIScaling, y+18*config.UIScaling)
+	opts.ColorM.ScaleWithColor(clr)
+	screen.DrawImage(barTexture, opts)
+}
+
+func (inv *Inventory) Render(screen *ebiten.Image) {
+	inventoryTexture := asset_loader.Texture("inventory").Texture()
+	sw, sh := screen.Size()
+	inv.screenWidth, inv.screenHeight = sw, sh
+
+	ix, iy := hotbarPosition(sw, sh)
+	inventoryDrawOpts := &ebiten.DrawImageOptions{}
+	inventoryDrawOpts.GeoM.Scale(config.UIScaling, config.UIScaling)
+	inventoryDrawOpts.GeoM.Translate(ix, iy)
+	screen.DrawImage(inventoryTexture, inventoryDrawOpts)
+
+	for i, slot := range inv.Slots[:HotbarSize] {
+		x, y := slotPosition(i, sw, sh)
+		renderSlot(screen, slot, x, y)
 	}
 
 	selectedSlotTex := asset_loader.Texture("selected_slot").Texture()
 	selectedSlotTexOpts := &ebiten.DrawImageOptions{}
-	selectedSlotTexOpts.GeoM.Scale(float64(config.UIScaling), float64(config.UIScaling))
-	selectedSlotTexOpts.GeoM.Translate(
-		ix+float64(config.UIScaling)+(20*float64(inv.SelectedSlot)*float64(config.UIScaling)),
-		iy,
-	)
+	selectedSlotTexOpts.GeoM.Scale(config.UIScaling, config.UIScaling)
+	selectedSlotTexOpts.GeoM.Translate(slotPosition(inv.SelectedSlot, sw, sh))
 	screen.DrawImage(selectedSlotTex, selectedSlotTexOpts)
 
 	// draw inventory badges on top of everything, so they will be always visible
 	inventoryBadgesTex := asset_loader.Texture("inventory_badges").Texture()
 	screen.DrawImage(inventoryBadgesTex, inventoryDrawOpts)
+
+	if inv.backpackOpen {
+		inv.renderBackpack(screen)
+	}
 }
@@ -0,0 +1,137 @@
+// Saving/loading the inventory
+
+package inventory
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/util"
+	"github.com/3elDU/bamboo/world"
+	"github.com/google/uuid"
+)
+
+// items contain unexported fields, so they are converted to SavedItem before saving,
+// same as blocks are converted to world.SavedBlock
+type SavedItem struct {
+	Type  types.ItemType
+	State interface{}
+}
+
+type SavedSlot struct {
+	Item     SavedItem
+	Quantity uint8
+	Empty    bool
+}
+
+// represents the inventory on the disk
+type SavedInventory struct {
+	Slots [HotbarSize]SavedSlot
+	// saves made before the backpack was added don't have it
+	Backpack     []SavedSlot
+	SelectedSlot int
+}
+
+func saveSlot(slot *types.ItemSlot) SavedSlot {
+	if slot.Empty || slot.Item == nil {
+		return SavedSlot{Empty: true}
+	}
+	return SavedSlot{
+		Item: SavedItem{
+			Type:  slot.Item.Type(),
+			State: slot.Item.State(),
+		},
+		Quantity: slot.Quantity,
+	}
+}
+
+func loadSlot(saved SavedSlot) (*types.ItemSlot, error) {
+	slot := &types.ItemSlot{Empty: true}
+	if saved.Empty {
+		return slot, nil
+	}
+
+	item := items.GetItemByID(saved.Item.Type)
+	if err := item.LoadState(saved.Item.State); err != nil {
+		return nil, err
+	}
+	slot.Item = item
+	slot.Quantity = saved.Quantity
+	slot.Empty = false
+	return slot, nil
+}
+
+func (inv *Inventory) State() SavedInventory {
+	saved := SavedInventory{
+		Backpack:     make([]SavedSlot, BackpackSize),
+		SelectedSlot: inv.SelectedSlot,
+	}
+	for i, slot := range inv.Slots {
+		if i < HotbarSize {
+			saved.Slots[i] = saveSlot(slot)
+		} else {
+			saved.Backpack[i-HotbarSize] = saveSlot(slot)
+		}
+	}
+	return saved
+}
+
+func (inv *Inventory) LoadState(saved SavedInventory) error {
+	savedSlots := append(saved.Slots[:], saved.Backpack...)
+	if len(savedSlots) > Size {
+		return fmt.Errorf("too many slots - %v, expected at most %v", len(savedSlots), Size)
+	}
+
+	for i, savedSlot := range savedSlots {
+		slot, err := loadSlot(savedSlot)
+		if err != nil {
+			return fmt.Errorf("slot %v - %w", i, err)
+		}
+		inv.Slots[i] = slot
+	}
+	inv.SelectSlot(saved.SelectedSlot)
+	return nil
+}
+
+// Save writes the inventory to the base save directory, alongside the player file
+func (inv *Inventory) Save(baseUUID uuid.UUID) error {
+	saveDir := filepath.Join(config.WorldSaveDirectory, baseUUID.String())
+	os.MkdirAll(saveDir, os.ModePerm)
+
+	return util.WriteFileAtomic(filepath.Join(saveDir, config.InventoryFile), func(w io.Writer) error {
+		return gob.NewEncoder(w).Encode(inv.State())
+	})
+}
+
+// LoadInventory returns world.LoadError with world.ErrNotFound kind, if the inventory file doesn't exist
+func LoadInventory(baseUUID uuid.UUID) (*Inventory, error) {
+	path := filepath.Join(config.WorldSaveDirectory, baseUUID.String(), config.InventoryFile)
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, &world.LoadError{Kind: world.ErrNotFound, Path: path, Err: err}
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	saved := SavedInventory{}
+	if err := gob.NewDecoder(f).Decode(&saved); err != nil {
+		return nil, &world.LoadError{Kind: world.ErrCorrupt, Path: path, Err: err}
+	}
+
+	inv := NewInventory()
+	if err := inv.LoadState(saved); err != nil {
+		return nil, &world.LoadError{Kind: world.ErrCorrupt, Path: path, Err: err}
+	}
+	return inv, nil
+}
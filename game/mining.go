@@ -0,0 +1,116 @@
+// Breaking blocks in front of the player
+
+package game
+
+import (
+	"image"
+	"log"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/event"
+	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/types"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// number of frames in the crack overlay texture
+const crackStages = 4
+
+type miner struct {
+	// whether the player is breaking a block at the moment
+	active bool
+	target types.Vec2u
+	// from 0 to 1, the block breaks at 1
+	progress float64
+}
+
+// mine continues breaking the block in front of the player. Called each tick, while the key is held
+func (game *Game) mine() {
+	target := game.player.Facing()
+	block, _, breakable := game.world.BreakableAt(target.X, target.Y)
+	if !breakable {
+		game.miner = miner{}
+		return
+	}
+
+	// the player turned to another block, start from the beginning
+	if !game.miner.active || game.miner.target != target {
+		game.miner = miner{active: true, target: target}
+		game.world.StartBreaking(target.X, target.Y)
+	}
+
+	speed := 1.0
+	if tool, ok := game.inventory.ItemInHand().(types.Tool); ok {
+		speed = tool.MiningSpeed(block.Category())
+	}
+
+	if block.Hardness() <= 0 {
+		game.miner.progress = 1
+	} else {
+		// hardness is in seconds, and there are 60 ticks in a second
+		game.miner.progress += speed / (block.Hardness() * 60)
+	}
+
+	if game.miner.progress >= 1 {
+		game.breakBlock(target, block)
+		game.miner = miner{}
+	}
+}
+
+// replaces the block with the underlying one, and puts the drops into the inventory.
+// In multiplayer, the drops come from the server
+func (game *Game) breakBlock(pos types.Vec2u, block types.BreakableBlock) {
+	if !event.Fire(game.world.Events(), &types.BlockBrokenEvent{Pos: pos, Block: block}) {
+		return
+	}
+
+	game.world.BreakBlock(pos.X, pos.Y)
+
+	if tool, ok := game.inventory.ItemInHand().(types.Tool); ok && tool.Wear() {
+		log.Printf("%v is worn out", items.NameOf(tool))
+		game.inventory.Slots[game.inventory.SelectedSlot].RemoveItem(1)
+	}
+
+	if game.client == nil {
+		game.pickUp(block.Drops())
+	}
+}
+
+// pickUp puts the items, dropped by a block, into the inventory
+func (game *Game) pickUp(drops []string) {
+	for _, name := range drops {
+		item, exists := items.GetItemByName(name)
+		if !exists {
+			log.Printf("Game.pickUp() - unknown item %v", name)
+			continue
+		}
+		if !event.Fire(game.world.Events(), &types.ItemPickedUpEvent{Item: item}) {
+			continue
+		}
+		if !game.inventory.AddItem(item) {
+			log.Printf("Game.pickUp() - no space in the inventory for %v", name)
+		}
+	}
+}
+
+// draws cracks over the block, that is being broken
+func (game *Game) renderCracks(screen *ebiten.Image, scaling float64) {
+	if !game.miner.active {
+		return
+	}
+
+	stage := int(game.miner.progress * crackStages)
+	if stage >= crackStages {
+		stage = crackStages - 1
+	}
+	tex := asset_loader.Texture("cracks").Texture().SubImage(image.Rect(stage*16, 0, stage*16+16, 16)).(*ebiten.Image)
+
+	screenWidth, screenHeight := screen.Size()
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Scale(scaling, scaling)
+	opts.GeoM.Translate(
+		(float64(game.miner.target.X)-game.player.X)*16*scaling+float64(screenWidth)/2,
+		(float64(game.miner.target.Y)-game.player.Y)*16*scaling+float64(screenHeight)/2,
+	)
+	screen.DrawImage(tex, opts)
+}
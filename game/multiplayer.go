@@ -0,0 +1,199 @@
+// Multiplayer - the game, connected to a server
+
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/3elDU/bamboo/colors"
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/game/inventory"
+	"github.com/3elDU/bamboo/game/player"
+	"github.com/3elDU/bamboo/network"
+	"github.com/3elDU/bamboo/scene_manager"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+)
+
+// name of the player, hosting the server
+const hostName = "host"
+
+// newMultiplayerGame creates a game with the world, replicated from the server.
+// If gamePlayer is nil, a new player is created. Either way, the player is placed, where the server has put it
+func newMultiplayerGame(client *network.Client, gamePlayer *player.Player) *Game {
+	remoteWorld := world.NewRemoteWorld(client.Metadata(), client)
+	if gamePlayer == nil {
+		gamePlayer = player.NewPlayerAt(client.Spawn(), client.Metadata())
+	}
+	gamePlayer.SetPosition(client.Spawn())
+
+	game := newGame(remoteWorld, gamePlayer)
+	game.client = client
+	game.remotePlayers = make(map[uint32]*network.RemotePlayer)
+	game.remotePlayerNames = make(map[uint32]string)
+	return game
+}
+
+// WithDefaultPort appends the default server port to the address, if it doesn't have one
+func WithDefaultPort(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(address, strconv.Itoa(config.DefaultServerPort))
+}
+
+// JoinGameScene connects to the server, and creates a game scene with its world
+func JoinGameScene(address, name string) (*Game, error) {
+	client, err := network.Dial(WithDefaultPort(address), name)
+	if err != nil {
+		return nil, err
+	}
+	return newMultiplayerGame(client, nil), nil
+}
+
+// HostGameScene starts a server with the given world, and joins it.
+// The host keeps its player and inventory, same as in singleplayer
+func HostGameScene(metadata types.Save) (*Game, error) {
+	hostedWorld, err := world.Load(metadata.BaseUUID, metadata.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the world on the server is hosted, so the player from another sub-world is spawned again
+	loadedPlayer, err := player.LoadPlayer(metadata.BaseUUID)
+	if err != nil || loadedPlayer.SelectedWorld.UUID != metadata.UUID {
+		log.Printf("HostGameScene() - spawning the host at a new spawn point (%v)", err)
+		loadedPlayer = nil
+	}
+
+	loadedInventory, err := inventory.LoadInventory(metadata.BaseUUID)
+	switch {
+	case errors.Is(err, world.ErrNotFound):
+		loadedInventory = inventory.NewInventory()
+	case err != nil:
+		return nil, err
+	}
+
+	server := network.NewServer(hostedWorld)
+	if loadedPlayer != nil {
+		server.SetPlayerPosition(hostName, loadedPlayer.Position())
+	}
+	// the server checks, that the host has the blocks it places
+	counts := make(map[string]int)
+	for _, name := range loadedInventory.Names() {
+		counts[name] = loadedInventory.Count(name)
+	}
+	server.SetPlayerItems(hostName, counts)
+	if err := server.Listen(fmt.Sprintf(":%v", config.DefaultServerPort)); err != nil {
+		return nil, err
+	}
+
+	client, err := network.Dial(fmt.Sprintf("127.0.0.1:%v", config.DefaultServerPort), hostName)
+	if err != nil {
+		server.Close()
+		return nil, err
+	}
+
+	game := newMultiplayerGame(client, loadedPlayer)
+	game.server = server
+	game.inventory = loadedInventory
+	return game, nil
+}
+
+// hasOwnSave is false for players, that joined someone else's server.
+// Their player and inventory aren't saved
+func (game *Game) hasOwnSave() bool {
+	return game.client == nil || game.server != nil
+}
+
+// updateMultiplayer sends the position of the player, applies what happened to the other players,
+// the damage, dealt by the mobs, and picks up the drops of the blocks, broken by the player.
+// Returns false, and exits the game, if the connection is lost
+func (game *Game) updateMultiplayer() bool {
+	if err := game.client.Err(); err != nil {
+		log.Printf("Game - lost connection to the server - %v", err)
+		scene_manager.Pop()
+		return false
+	}
+
+	for {
+		pos, ok := game.client.ReceivePosition()
+		if !ok {
+			break
+		}
+		game.player.SetPosition(pos)
+		game.player.SetVelocity(types.Vec2f{})
+	}
+	game.client.SendPosition(game.player.Position())
+
+	for {
+		event, ok := game.client.ReceivePlayer()
+		if !ok {
+			break
+		}
+
+		switch event.Type {
+		case network.PlayerJoined:
+			log.Printf("Game - %v joined the game", event.Name)
+			game.console.Print(fmt.Sprintf("%v joined the game", event.Name), colors.Yellow)
+			game.remotePlayerNames[event.ID] = event.Name
+		case network.PlayerMoved:
+			remotePlayer, exists := game.remotePlayers[event.ID]
+			if !exists {
+				remotePlayer = network.NewRemotePlayer(event.ID, game.remotePlayerNames[event.ID], event.Position)
+				game.remotePlayers[event.ID] = remotePlayer
+			}
+			// re-added on each move, since the chunk with the player could have been unloaded
+			game.world.RemoveEntity(remotePlayer)
+			remotePlayer.SetPosition(event.Position)
+			game.world.AddEntity(remotePlayer)
+		case network.PlayerLeft:
+			log.Printf("Game - %v left the game", game.remotePlayerNames[event.ID])
+			game.console.Print(fmt.Sprintf("%v left the game", game.remotePlayerNames[event.ID]), colors.Yellow)
+			if remotePlayer, exists := game.remotePlayers[event.ID]; exists {
+				game.world.RemoveEntity(remotePlayer)
+			}
+			delete(game.remotePlayers, event.ID)
+			delete(game.remotePlayerNames, event.ID)
+		}
+	}
+
+	for {
+		damage, ok := game.client.ReceiveDamage()
+		if !ok {
+			break
+		}
+		game.player.Damage(damage)
+	}
+
+	for {
+		drops, ok := game.client.ReceiveDrops()
+		if !ok {
+			break
+		}
+		game.pickUp(drops)
+	}
+
+	for {
+		chat, ok := game.client.ReceiveChat()
+		if !ok {
+			break
+		}
+		game.console.Print(fmt.Sprintf("%v: %v", chat.Name, chat.Text), colors.White)
+	}
+	return true
+}
+
+// closeMultiplayer disconnects from the server, and stops it, if the player is the host
+func (game *Game) closeMultiplayer() {
+	if game.client != nil {
+		game.client.Close()
+	}
+	if game.server != nil {
+		game.server.Close()
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/types"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
@@ -19,9 +20,9 @@ var textureMap = map[MovementDirection]string{
 	Down:  "player_down",
 }
 
-func (player *Player) Render(screen *ebiten.Image, scaling float64, paused bool) {
+// pos is the position of the player's feet on the screen
+func (player *Player) Render(screen *ebiten.Image, pos types.Vec2f, scaling float64) {
 	opts := &ebiten.DrawImageOptions{}
-	sw, sh := screen.Size()
 	tex := ebiten.NewImageFromImage(
 		asset_loader.Texture(textureMap[player.movementDirection]).Texture().SubImage(
 			image.Rect(int(player.animationFrame)*16, 0, int(player.animationFrame)*16+16, 32),
@@ -31,14 +32,10 @@ func (player *Player) Render(screen *ebiten.Image, scaling float64, paused bool)
 	opts.GeoM.Reset()
 	opts.GeoM.Scale(scaling, scaling)
 	opts.GeoM.Translate(
-		float64(sw)/2-8*scaling,
-		float64(sh)/2-16*scaling,
+		pos.X-8*scaling,
+		pos.Y-16*scaling,
 	)
 	screen.DrawImage(tex, opts)
-
-	if !paused {
-		player.nextAnimationFrame()
-	}
 }
 
 func (player *Player) nextAnimationFrame() {
@@ -4,6 +4,7 @@ import (
 	"math"
 
 	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/physics"
 	"github.com/3elDU/bamboo/types"
 	"github.com/3elDU/bamboo/util"
 )
@@ -41,63 +42,15 @@ func (player *Player) updateMovementDirection() {
 	}
 }
 
-// Collision points for each block are specified in local space ( e.g. relative to the block itself ),
-// so for collision to work we need to convert them to global space first
-func convertToGlobalSpace(block types.Block, points [4]types.Vec2f) [4]types.Vec2f {
-	return [4]types.Vec2f{
-		{X: points[0].X + float64(block.Coords().X), Y: points[0].Y + float64(block.Coords().Y)},
-		{X: points[1].X + float64(block.Coords().X), Y: points[1].Y + float64(block.Coords().Y)},
-		{X: points[2].X + float64(block.Coords().X), Y: points[2].Y + float64(block.Coords().Y)},
-		{X: points[3].X + float64(block.Coords().X), Y: points[3].Y + float64(block.Coords().Y)},
-	}
-}
-
-// check collision between player and the blocks
-// returns collision value for each corner
-func collidePlayer(origin types.Vec2f, world types.World) (collisions [4]bool) {
-	playerCollisionPoints := [4]types.Vec2f{
-		{X: origin.X - .25, Y: origin.Y - .25},
-		{X: origin.X + .25, Y: origin.Y - .25},
-		{X: origin.X - .25, Y: origin.Y + .4},
-		{X: origin.X + .25, Y: origin.Y + .4},
-	}
-
-	for i, point := range playerCollisionPoints {
-		block, isCollidable := world.BlockAt(uint64(point.X), uint64(point.Y)).(types.CollidableBlock)
-		if !isCollidable {
-			continue
-		}
-		if !block.Collidable() {
-			continue
-		}
-
-		blockCollisionPoints := convertToGlobalSpace(block, block.CollisionPoints())
-
-		var blockCollisions [4]bool
-		blockCollisions[0] = point.X < blockCollisionPoints[3].X || point.Y < blockCollisionPoints[3].Y
-		blockCollisions[1] = point.X > blockCollisionPoints[2].X || point.Y < blockCollisionPoints[2].Y
-		blockCollisions[2] = point.X < blockCollisionPoints[1].X || point.Y > blockCollisionPoints[1].Y
-		blockCollisions[3] = point.X > blockCollisionPoints[0].X || point.Y > blockCollisionPoints[0].Y
-		// if current point collides with any corner of the block, set the collision to true
-		collisions[i] = anyOf(blockCollisions)
-	}
-
-	return
-}
+// player's hitbox is a bit lower than the center, since the texture is two blocks high
+var hitbox = physics.Hitbox{Left: .25, Top: .25, Right: .25, Bottom: .4}
 
 // retrieves all interactive blocks the player is colliding with,
 // and calls Interact() on them
 func interactWithBlocks(origin types.Vec2f, world types.World) {
-	playerCollisionPoints := [4]types.Vec2f{
-		{X: origin.X - .25, Y: origin.Y - .25},
-		{X: origin.X + .25, Y: origin.Y - .25},
-		{X: origin.X - .25, Y: origin.Y + .4},
-		{X: origin.X + .25, Y: origin.Y + .4},
-	}
-
 	collisions := make(map[types.Vec2u]types.InteractiveBlock)
 
-	for _, point := range playerCollisionPoints {
+	for _, point := range hitbox.Points(origin) {
 		block, interactive := world.BlockAt(uint64(point.X), uint64(point.Y)).(types.InteractiveBlock)
 		if !interactive {
 			continue
@@ -111,56 +64,29 @@ func interactWithBlocks(origin types.Vec2f, world types.World) {
 	}
 }
 
-// returns true if any of collisions is true
-func anyOf(collisions [4]bool) bool {
-	for _, collision := range collisions {
-		if collision {
-			return true
-		}
-	}
-	return false
-}
-
-func countCollisions(collisions [4]bool) (count uint) {
-	for _, collision := range collisions {
-		if collision {
-			count++
-		}
-	}
-	return
-}
-
 // Update updates the player physics and animation
 // FIXME: consider frame delta time in equations
-func (player *Player) Update(movement MovementVector, world types.World) {
-	dx, dy := movement.ToFloat()
-
-	player.xVelocity += dx * config.PlayerSpeed
-	player.yVelocity += dy * config.PlayerSpeed
+func (player *Player) Update(world types.World) {
+	player.updateStats()
 
-	// if player somehow got stuck in the block, skip collision check
-	if !anyOf(collidePlayer(types.Vec2f{X: player.X, Y: player.Y}, world)) {
-		// check for collisions on X axis
-		if anyOf(collidePlayer(types.Vec2f{X: player.X + player.xVelocity, Y: player.Y}, world)) {
-			player.xVelocity = 0
-		}
-		// check for collisions on Y axis
-		if anyOf(collidePlayer(types.Vec2f{X: player.X, Y: player.Y + player.yVelocity}, world)) {
-			player.yVelocity = 0
-		}
-		// check for corner collisions
-		if countCollisions(collidePlayer(types.Vec2f{X: player.X + player.xVelocity, Y: player.Y + player.yVelocity}, world)) == 1 {
-			// "bounce" off the corner
-			player.xVelocity = -player.xVelocity * 0.1
-			player.yVelocity = -player.yVelocity * 0.1
-		}
+	dx, dy := player.movement.ToFloat()
+	speed := config.PlayerSpeed
+	if player.sprinting {
+		speed *= config.PlayerSprintMultiplier
 	}
 
+	player.xVelocity += dx * speed
+	player.yVelocity += dy * speed
+
+	velocity := physics.ResolveCollisions(
+		types.Vec2f{X: player.X, Y: player.Y},
+		types.Vec2f{X: player.xVelocity, Y: player.yVelocity},
+		hitbox, world,
+	)
+	player.xVelocity, player.yVelocity = velocity.X, velocity.Y
+
 	// multiply velocity by block speed modifier
-	speedModifier := 1.0
-	if block, ok := world.BlockAt(uint64(player.X), uint64(player.Y)).(types.CollidableBlock); ok {
-		speedModifier = block.PlayerSpeed()
-	}
+	speedModifier := physics.SpeedModifier(types.Vec2f{X: player.X, Y: player.Y}, world)
 
 	interactWithBlocks(types.Vec2f{X: player.X, Y: player.Y}, world)
 
@@ -171,7 +97,25 @@ func (player *Player) Update(movement MovementVector, world types.World) {
 	player.Y = util.Clamp(player.Y, 0, float64(config.WorldHeight))
 
 	player.updateMovementDirection()
+	player.nextAnimationFrame()
 
 	player.xVelocity *= 0.75
 	player.yVelocity *= 0.75
 }
+
+// Facing returns coordinates of the block in front of the player
+func (player *Player) Facing() types.Vec2u {
+	x, y := player.X, player.Y
+	// half a block away from the edge of the hitbox
+	switch player.movementDirection {
+	case Left:
+		x -= hitbox.Left + 0.5
+	case Right:
+		x += hitbox.Right + 0.5
+	case Up:
+		y -= hitbox.Top + 0.5
+	case Down:
+		y += hitbox.Bottom + 0.5
+	}
+	return types.Vec2u{X: uint64(math.Max(x, 0)), Y: uint64(math.Max(y, 0))}
+}
@@ -2,11 +2,13 @@ package player
 
 import (
 	"encoding/gob"
-	"github.com/3elDU/bamboo/blocks"
+	"errors"
 	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/util"
 	"github.com/3elDU/bamboo/world"
+	"io"
+	"io/fs"
 	"log"
-	"math/rand"
 	"os"
 	"path/filepath"
 	"time"
@@ -15,11 +17,22 @@ import (
 	"github.com/google/uuid"
 )
 
+// EntityType of the player.
+// It isn't registered with world.RegisterEntity, since the player is saved separately, not with the chunks
+const EntityType = types.PlayerEntity
+
 type Player struct {
 	// Note that these are block coordinates, not pixel coordinates
 	X, Y                 float64
 	xVelocity, yVelocity float64
 
+	// set by the game from the keyboard input, applied on the next Update()
+	movement  MovementVector
+	sprinting bool
+
+	// nil in saves, made before the stats were introduced
+	Stats *Stats
+
 	movementDirection MovementDirection
 	animationFrame    uint8
 	lastFrameChange   time.Time
@@ -40,6 +53,7 @@ const (
 
 type MovementVector struct {
 	Left, Right, Up, Down bool
+	Sprint                bool
 }
 
 func (mvec MovementVector) ToFloat() (vx, vy float64) {
@@ -60,21 +74,28 @@ func (mvec MovementVector) ToFloat() (vx, vy float64) {
 	return
 }
 
-func LoadPlayer(baseUUID uuid.UUID) *Player {
-	saveDir := filepath.Join(config.WorldSaveDirectory, baseUUID.String())
+// LoadPlayer returns world.LoadError with world.ErrNotFound kind, if the player file doesn't exist
+func LoadPlayer(baseUUID uuid.UUID) (*Player, error) {
+	path := filepath.Join(config.WorldSaveDirectory, baseUUID.String(), config.PlayerInfoFile)
 
-	f, err := os.Open(filepath.Join(saveDir, config.PlayerInfoFile))
+	f, err := os.Open(path)
 	if err != nil {
-		// if file does not exist, just return an empty object
-		return &Player{X: float64(config.PlayerStartX), Y: float64(config.PlayerStartY)}
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, &world.LoadError{Kind: world.ErrNotFound, Path: path, Err: err}
+		}
+		return nil, err
 	}
+	defer f.Close()
 
 	player := new(Player)
 	if err := gob.NewDecoder(f).Decode(player); err != nil {
-		log.Panicf("LoadPlayer() - failed to decode metadata - %v", err)
+		return nil, &world.LoadError{Kind: world.ErrCorrupt, Path: path, Err: err}
+	}
+	if player.Stats == nil {
+		player.Stats = NewStats()
 	}
 
-	return player
+	return player, nil
 }
 
 func (player *Player) Save(metadata types.Save) {
@@ -84,58 +105,57 @@ func (player *Player) Save(metadata types.Save) {
 	player.SelectedWorld = metadata
 
 	// make a save directory, if it doesn't exist yet
-	os.Mkdir(saveDir, os.ModePerm)
+	os.MkdirAll(saveDir, os.ModePerm)
 
-	// open world metadata file
-	f, err := os.Create(filepath.Join(saveDir, config.PlayerInfoFile))
+	// write player metadata through a temporary file, so a crash won't leave it truncated
+	err := util.WriteFileAtomic(filepath.Join(saveDir, config.PlayerInfoFile), func(w io.Writer) error {
+		return gob.NewEncoder(w).Encode(player)
+	})
 	if err != nil {
-		log.Panicf("failed to create player metadata file")
+		log.Panicf("failed to write player metadata - %v", err)
 	}
-	defer f.Close()
+}
 
-	if err := gob.NewEncoder(f).Encode(player); err != nil {
-		log.Panicf("failed to write player metadata")
-	}
+// Creates a new player at the spawn point
+func NewPlayer(w types.World) *Player {
+	return NewPlayerAt(world.SpawnPoint(w), w.Metadata())
 }
 
-func isValidSpawnpoint(blockType types.BlockType) bool {
-	validBlocks := []types.BlockType{
-		blocks.Sand, blocks.Grass, blocks.ShortGrass, blocks.TallGrass, blocks.Flowers, blocks.RedMushroom, blocks.WhiteMushroom,
-		blocks.CaveFloor,
-	}
+// Creates a new player at the given position, e.g. the one, sent by the server
+func NewPlayerAt(pos types.Vec2f, metadata types.Save) *Player {
+	return &Player{X: pos.X, Y: pos.Y, Stats: NewStats(), SelectedWorld: metadata}
+}
 
-	for _, blockType2 := range validBlocks {
-		if blockType == blockType2 {
-			return true
-		}
-	}
-	return false
+func (player *Player) Type() types.EntityType {
+	return EntityType
 }
 
-// Creates a new player, picking a valid spawn point
-func NewPlayer(w types.World) *Player {
-	// use the same seed for reproducible spawnpoint generation
-	rng := rand.New(rand.NewSource(1))
-
-	x, y := 0, 0
-	it := 1
-	for {
-		// Pick X and Y coordinates from 256 to 768
-		x = rng.Intn(768-256) + 256
-		y = rng.Intn(768-256) + 256
-
-		// create a new generator so that it doesn't overwrite chunks in the world
-		c := world.NewChunk(uint64(x)/16, uint64(y)/16)
-		w.Generator().GenerateImmediately(c)
-		blockType := c.At(uint(x%16), uint(y%16)).Type()
-
-		if isValidSpawnpoint(blockType) {
-			break
-		}
+func (player *Player) Position() types.Vec2f {
+	return types.Vec2f{X: player.X, Y: player.Y}
+}
 
-		it++
-	}
-	log.Printf("picked spawn point (%v, %v), took %v iterations", x, y, it)
+func (player *Player) SetPosition(pos types.Vec2f) {
+	player.X, player.Y = pos.X, pos.Y
+}
+
+func (player *Player) Velocity() types.Vec2f {
+	return types.Vec2f{X: player.xVelocity, Y: player.yVelocity}
+}
+
+func (player *Player) SetVelocity(velocity types.Vec2f) {
+	player.xVelocity, player.yVelocity = velocity.X, velocity.Y
+}
+
+// SetMovement sets the direction, the player will move to on the next update
+func (player *Player) SetMovement(movement MovementVector) {
+	player.movement = movement
+}
+
+// The player is saved to player.gob, so there is no state to save with the chunk
+func (player *Player) State() interface{} {
+	return nil
+}
 
-	return &Player{X: float64(x), Y: float64(y), SelectedWorld: w.Metadata()}
+func (player *Player) LoadState(interface{}) error {
+	return nil
 }
@@ -0,0 +1,119 @@
+/*
+	Survival stats of the player: health, hunger and stamina
+*/
+
+package player
+
+import (
+	"math"
+
+	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/util"
+)
+
+// All stats are in range from 0 to their maximum
+// Rates are measured per tick
+const (
+	MaxHealth  float64 = 100
+	MaxHunger  float64 = 100
+	MaxStamina float64 = 100
+
+	// full hunger lasts for about 20 minutes
+	HungerRate float64 = MaxHunger / (20 * 60 * 60)
+	// sprinting makes the player hungry three times faster
+	SprintHungerRate = HungerRate * 3
+
+	// stamina is enough for about 4 seconds of sprinting
+	SprintStaminaRate float64 = MaxStamina / (4 * 60)
+	// and it fully restores in 10 seconds
+	StaminaRegenRate float64 = MaxStamina / (10 * 60)
+	// stamina under this value isn't enough to start sprinting
+	MinSprintStamina float64 = 10
+
+	// health regenerates only while the player is well fed
+	RegenHunger     float64 = MaxHunger * 0.75
+	HealthRegenRate float64 = MaxHealth / (60 * 60)
+	// starving player loses all health in about 30 seconds
+	StarvationRate float64 = MaxHealth / (30 * 60)
+)
+
+type Stats struct {
+	Health, Hunger, Stamina float64
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		Health:  MaxHealth,
+		Hunger:  MaxHunger,
+		Stamina: MaxStamina,
+	}
+}
+
+func (stats *Stats) Dead() bool {
+	return stats.Health <= 0
+}
+
+func (stats *Stats) clamp() {
+	stats.Health = util.Clamp(stats.Health, 0, MaxHealth)
+	stats.Hunger = util.Clamp(stats.Hunger, 0, MaxHunger)
+	stats.Stamina = util.Clamp(stats.Stamina, 0, MaxStamina)
+}
+
+// returns true, if the player is able to sprint at the moment
+func (player *Player) canSprint() bool {
+	if !player.movement.Sprint || player.Stats.Hunger <= 0 {
+		return false
+	}
+	// once started, sprinting continues until the stamina runs out
+	if player.sprinting {
+		return player.Stats.Stamina > 0
+	}
+	return player.Stats.Stamina >= MinSprintStamina
+}
+
+// updates the stats, depending on what the player is doing
+func (player *Player) updateStats() {
+	stats := player.Stats
+	// dead players don't regenerate
+	if stats.Dead() {
+		return
+	}
+
+	dx, dy := player.movement.ToFloat()
+	player.sprinting = (dx != 0 || dy != 0) && player.canSprint()
+
+	if player.sprinting {
+		stats.Stamina -= SprintStaminaRate
+		stats.Hunger -= SprintHungerRate
+	} else {
+		stats.Hunger -= HungerRate
+		if stats.Hunger > 0 {
+			stats.Stamina += StaminaRegenRate
+		}
+	}
+
+	switch {
+	case stats.Hunger <= 0:
+		stats.Health -= StarvationRate
+	case stats.Hunger >= RegenHunger:
+		stats.Health += HealthRegenRate
+	}
+
+	stats.clamp()
+}
+
+// Damage implements types.Damageable
+func (player *Player) Damage(amount float64) {
+	player.Stats.Health = math.Max(player.Stats.Health-amount, 0)
+}
+
+func (player *Player) Dead() bool {
+	return player.Stats.Dead()
+}
+
+// Eat applies the effects of the food to the player
+func (player *Player) Eat(food items.Food) {
+	player.Stats.Hunger += food.Hunger
+	player.Stats.Health += food.Health
+	player.Stats.clamp()
+}
@@ -2,8 +2,12 @@ package widgets
 
 import (
 	"fmt"
+	"image"
 	"image/color"
+	"math"
 
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/util"
 	"github.com/3elDU/bamboo/widget"
 	"github.com/hajimehoshi/ebiten/v2"
 	"golang.org/x/image/font"
@@ -74,3 +78,58 @@ func (w *SimpleTextWidget) Render() widget.Text {
 		Anchor: w.Anc,
 	}
 }
+
+// Bar is a single bar of StatusBarsWidget
+type Bar struct {
+	// Value returns how much the bar is filled, from 0 to 1
+	Value func() float64
+	Color color.Color
+}
+
+// StatusBarsWidget draws horizontal bars, one under another, e.g. player's health and hunger
+type StatusBarsWidget struct {
+	Bars []Bar
+	Anc  widget.Anchor
+
+	image *ebiten.Image
+}
+
+// Size of a single bar, in pixels, before UI scaling
+const (
+	barWidth   = 64
+	barHeight  = 4
+	barSpacing = 2
+)
+
+func (w *StatusBarsWidget) Update() {
+
+}
+
+func (w *StatusBarsWidget) Anchor() widget.Anchor {
+	return w.Anc
+}
+
+func (w *StatusBarsWidget) Render() *ebiten.Image {
+	scaling := int(config.UIScaling)
+	if w.image == nil {
+		w.image = ebiten.NewImage(
+			(barWidth+barSpacing*2)*scaling,
+			((barHeight+barSpacing)*len(w.Bars)+barSpacing)*scaling,
+		)
+	}
+	w.image.Clear()
+
+	for i, bar := range w.Bars {
+		x := barSpacing * scaling
+		y := (barSpacing + (barHeight+barSpacing)*i) * scaling
+		filled := int(math.Round(util.Clamp(bar.Value(), 0, 1) * barWidth * float64(scaling)))
+
+		background := image.Rect(x, y, x+barWidth*scaling, y+barHeight*scaling)
+		w.image.SubImage(background).(*ebiten.Image).Fill(color.RGBA{A: 128})
+		if filled > 0 {
+			w.image.SubImage(image.Rect(x, y, x+filled, y+barHeight*scaling)).(*ebiten.Image).Fill(bar.Color)
+		}
+	}
+
+	return w.image
+}
@@ -23,6 +23,7 @@ require (
 	github.com/google/uuid v1.3.0
 	github.com/hajimehoshi/file2byteslice v0.0.0-20210813153925-5340248a8f41 // indirect
 	github.com/jezek/xgb v1.0.1 // indirect
+	github.com/yuin/gopher-lua v1.1.1
 	golang.org/x/exp/shiny v0.0.0-20221011111909-0220f59fc3e4 // indirect
 	golang.org/x/mobile v0.0.0-20220722155234-aaac322e2105 // indirect
 	golang.org/x/sys v0.0.0-20220818161305-2296e01440c6 // indirect
@@ -2,6 +2,7 @@ package items
 
 import (
 	"encoding/gob"
+	"fmt"
 
 	"github.com/3elDU/bamboo/types"
 )
@@ -12,23 +13,50 @@ func init() {
 
 type BaseItemState struct {
 	Type types.ItemType
+	// nil, if the item has no metadata
+	Metadata types.ItemMetadata
 }
 
 type baseItem struct {
-	id types.ItemType
+	id       types.ItemType
+	metadata types.ItemMetadata
 }
 
 func (i *baseItem) Type() types.ItemType {
 	return i.id
 }
 
+func (i *baseItem) MaxStackSize() uint8 {
+	return types.DefaultMaxStackSize
+}
+
+func (i *baseItem) Metadata() types.ItemMetadata {
+	if i.metadata == nil {
+		i.metadata = make(types.ItemMetadata)
+	}
+	return i.metadata
+}
+
 func (i *baseItem) State() interface{} {
-	return BaseItemState{
+	state := BaseItemState{
 		Type: i.id,
 	}
+	// empty metadata is saved as nil, so the items with and without it are considered the same
+	if len(i.metadata) > 0 {
+		state.Metadata = make(types.ItemMetadata, len(i.metadata))
+		for key, value := range i.metadata {
+			state.Metadata[key] = value
+		}
+	}
+	return state
 }
 
-func (i *baseItem) LoadState(s interface{}) {
-	state := s.(BaseItemState)
+func (i *baseItem) LoadState(s interface{}) error {
+	state, ok := s.(BaseItemState)
+	if !ok {
+		return fmt.Errorf("%T - invalid state type; expected %T, got %T", i, BaseItemState{}, s)
+	}
 	i.id = state.Type
+	i.metadata = state.Metadata
+	return nil
 }
@@ -1,18 +1,29 @@
 package items
 
 import (
+	"encoding/gob"
+	"fmt"
+
 	"github.com/3elDU/bamboo/asset_loader"
 	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/event"
 	"github.com/3elDU/bamboo/types"
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
 /*
 	An item, that represents a block, that can be placed. As simple as that
 */
 
+func init() {
+	gob.Register(ItemFromBlockState{})
+}
+
 type ItemFromBlockState struct {
-	Texture   string
+	// Only the metadata is used, the type comes from the block
+	BaseItemState
+	Texture string
+	// Registered name of the block. Numeric block type is kept for inventories saved before the names were introduced
+	Block     string
 	BlockType types.BlockType
 }
 
@@ -32,24 +43,44 @@ func NewItemFromBlock(block types.DrawableBlock) *ItemFromBlock {
 	}
 }
 
-func (i *ItemFromBlock) Texture() *ebiten.Image {
-	return i.texture.Texture()
-}
-
 func (i *ItemFromBlock) Use(world types.World, pos types.Vec2u) {
-	world.ChunkAtB(pos.X, pos.Y).
-		SetBlock(uint(pos.X%16), uint(pos.Y%16), blocks.GetBlockByID(i.blockType))
+	// the item is used each tick, while the key is held, so the same block isn't placed again
+	if world.BlockAt(pos.X, pos.Y).Type() == i.blockType {
+		return
+	}
+
+	block := blocks.GetBlockByID(i.blockType)
+	if !event.Fire(world.Events(), &types.BlockPlacedEvent{Pos: pos, Block: block}) {
+		return
+	}
+	// through the world, so that the light of the neighbor chunks is updated, and the server gets the change
+	world.PlaceBlock(pos.X, pos.Y, block)
 }
 
 func (i *ItemFromBlock) State() interface{} {
 	return ItemFromBlockState{
-		Texture:   i.texture.Name(),
-		BlockType: i.blockType,
+		BaseItemState: i.baseItem.State().(BaseItemState),
+		Texture:       i.texture.Name(),
+		Block:         blocks.Name(i.blockType),
+		BlockType:     i.blockType,
 	}
 }
 
-func (i *ItemFromBlock) LoadState(s interface{}) {
-	state := s.(ItemFromBlockState)
-	i.texture = asset_loader.Texture(state.Texture)
+func (i *ItemFromBlock) LoadState(s interface{}) error {
+	state, ok := s.(ItemFromBlockState)
+	if !ok {
+		return fmt.Errorf("%T - invalid state type; expected %T, got %T", i, ItemFromBlockState{}, s)
+	}
+	i.metadata = state.Metadata
 	i.blockType = state.BlockType
+	if state.Block != "" {
+		blockType, exists := blocks.TypeByName(state.Block)
+		if !exists {
+			return fmt.Errorf("%T - unknown block %q", i, state.Block)
+		}
+		i.blockType = blockType
+	}
+	i.id = types.ItemType(i.blockType)
+	i.texture = asset_loader.Texture(state.Texture)
+	return nil
 }
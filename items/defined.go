@@ -0,0 +1,101 @@
+/*
+	Items, that are defined at runtime, e.g. by mods.
+	Their IDs are derived from the names, so they stay the same between the runs,
+	regardless of the order, in which the items are registered.
+*/
+
+package items
+
+import (
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+)
+
+func init() {
+	gob.Register(DefinedItemState{})
+}
+
+// IDs of the defined items start here, after the items from the code
+const firstDefinedItem types.ItemType = 1 << 24
+
+type ItemDefinition struct {
+	Name    string
+	Texture string
+	// Defaults to types.DefaultMaxStackSize
+	MaxStackSize uint8
+	// Called, when the item is used on a block. Can be nil
+	Use func(world types.World, pos types.Vec2u)
+}
+
+type DefinedItemState struct {
+	BaseItemState
+}
+
+type DefinedItem struct {
+	baseItem
+	definition *ItemDefinition
+}
+
+// RegisterDefinition registers an item, defined at runtime.
+// Unlike Register(), it returns an error instead of panicking
+func RegisterDefinition(definition ItemDefinition) error {
+	if definition.Name == "" {
+		return fmt.Errorf("item name is not set")
+	}
+	if _, exists := itemsByName[definition.Name]; exists {
+		return fmt.Errorf("item %v is already registered", definition.Name)
+	}
+	if _, exists := blocks.TypeByName(definition.Name); exists {
+		return fmt.Errorf("item %v has the same name as a block", definition.Name)
+	}
+	if _, exists := asset_loader.GlobalAssets.Textures[definition.Texture]; !exists {
+		return fmt.Errorf("item %v - texture %v doesn't exist", definition.Name, definition.Texture)
+	}
+	if definition.MaxStackSize == 0 {
+		definition.MaxStackSize = types.DefaultMaxStackSize
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(definition.Name))
+	id := firstDefinedItem + types.ItemType(hash.Sum32()%(1<<24))
+	if existing, exists := itemsByType[id]; exists {
+		return fmt.Errorf("item %v has the same ID as %v, choose another name", definition.Name, existing.name)
+	}
+
+	Register(definition.Name, id, func() types.Item {
+		return &DefinedItem{
+			baseItem:   baseItem{id: id},
+			definition: &definition,
+		}
+	}, nil)
+	return nil
+}
+
+func (i *DefinedItem) MaxStackSize() uint8 {
+	return i.definition.MaxStackSize
+}
+
+func (i *DefinedItem) Use(world types.World, pos types.Vec2u) {
+	if i.definition.Use != nil {
+		i.definition.Use(world, pos)
+	}
+}
+
+func (i *DefinedItem) State() interface{} {
+	return DefinedItemState{
+		BaseItemState: i.baseItem.State().(BaseItemState),
+	}
+}
+
+func (i *DefinedItem) LoadState(s interface{}) error {
+	state, ok := s.(DefinedItemState)
+	if !ok {
+		return fmt.Errorf("%T - invalid state type; expected %T, got %T", i, DefinedItemState{}, s)
+	}
+	return i.baseItem.LoadState(state.BaseItemState)
+}
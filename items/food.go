@@ -0,0 +1,25 @@
+package items
+
+import (
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+)
+
+// Food describes what happens, when the item is eaten.
+// Negative values hurt the player
+type Food struct {
+	Hunger float64
+	Health float64
+}
+
+var foods = map[types.ItemType]Food{
+	types.ItemType(blocks.WhiteMushroom): {Hunger: 25},
+	// red mushrooms are poisonous, but still better than starving
+	types.ItemType(blocks.RedMushroom): {Hunger: 10, Health: -30},
+}
+
+// FoodOf returns false, if the item isn't edible
+func FoodOf(item types.Item) (Food, bool) {
+	food, edible := foods[item.Type()]
+	return food, edible
+}
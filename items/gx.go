@@ -0,0 +1,26 @@
+//go:build !headless
+
+// Textures of the items. They are left out, when building with the "headless" tag
+
+package items
+
+import (
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func (i *ItemFromBlock) Texture() *ebiten.Image {
+	return i.texture.Texture()
+}
+
+func (i *MaterialItem) Texture() *ebiten.Image {
+	return asset_loader.Texture(materialTextures[i.id]).Texture()
+}
+
+func (i *ToolItem) Texture() *ebiten.Image {
+	return asset_loader.Texture(i.kind.texture).Texture()
+}
+
+func (i *DefinedItem) Texture() *ebiten.Image {
+	return asset_loader.Texture(i.definition.Texture).Texture()
+}
@@ -0,0 +1,123 @@
+/*
+	Item registry.
+	Block items don't need to be registered, since they are made from any block with a texture,
+	and share IDs and names with the blocks.
+	Other items register their name, ID and constructor here.
+*/
+
+package items
+
+import (
+	"encoding/gob"
+	"log"
+	"strings"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+)
+
+// Items, that aren't blocks. Block items share IDs with block types, so these start far away from them.
+// Unlike block types, these IDs are saved, so they must never change
+const (
+	Pickaxe types.ItemType = 1<<16 + iota
+	Axe
+	Shovel
+	Planks
+	Stick
+)
+
+type registeredItem struct {
+	name        string
+	itemType    types.ItemType
+	constructor func() types.Item
+}
+
+var (
+	itemsByName = make(map[string]*registeredItem)
+	itemsByType = make(map[types.ItemType]*registeredItem)
+)
+
+// Register adds an item to the registry. The same rules as for blocks.Register() apply
+func Register(name string, itemType types.ItemType, constructor func() types.Item, state interface{}) {
+	if _, exists := itemsByName[name]; exists {
+		log.Panicf("items.Register() - item with name %v is already registered", name)
+	}
+	if _, exists := blocks.TypeByName(name); exists {
+		log.Panicf("items.Register() - item %v has the same name as a block", name)
+	}
+	if existing, exists := itemsByType[itemType]; exists {
+		log.Panicf("items.Register() - item type %v is already registered as %v", itemType, existing.name)
+	}
+
+	if state != nil {
+		gob.Register(state)
+	}
+
+	item := &registeredItem{
+		name:        name,
+		itemType:    itemType,
+		constructor: constructor,
+	}
+	itemsByName[name] = item
+	itemsByType[itemType] = item
+}
+
+// GetItemByID returns an empty item, which state then can be loaded with LoadState()
+func GetItemByID(id types.ItemType) types.Item {
+	if item, exists := itemsByType[id]; exists {
+		return item.constructor()
+	}
+
+	// the rest are placeable blocks, and share IDs with them
+	return &ItemFromBlock{
+		baseItem: baseItem{
+			id: id,
+		},
+		blockType: types.BlockType(id),
+	}
+}
+
+// NameOf returns the name of the item, which can be passed to GetItemByName()
+func NameOf(item types.Item) string {
+	if registered, exists := itemsByType[item.Type()]; exists {
+		return registered.name
+	}
+	return blocks.Name(types.BlockType(item.Type()))
+}
+
+// GetItemByName returns a new item with the given name.
+// Names of the blocks give items, that place these blocks
+// The second value is false, if there is no such item
+func GetItemByName(name string) (types.Item, bool) {
+	if item, exists := itemsByName[name]; exists {
+		return item.constructor(), true
+	}
+
+	block, exists := blocks.GetBlockByName(name)
+	if !exists {
+		return nil, false
+	}
+	drawable, ok := block.(types.DrawableBlock)
+	if !ok {
+		return nil, false
+	}
+	return NewItemFromBlock(drawable), true
+}
+
+// Copy returns a copy of the item, with the same state and metadata,
+// which can be modified without affecting the original
+func Copy(item types.Item) types.Item {
+	itemCopy := GetItemByID(item.Type())
+	if err := itemCopy.LoadState(item.State()); err != nil {
+		log.Panicf("items.Copy() - %v", err)
+	}
+	return itemCopy
+}
+
+// DisplayName returns the custom name of the item, if it has one, or its registered name otherwise
+func DisplayName(item types.Item) string {
+	if name, ok := item.Metadata()[types.CustomNameKey].(string); ok && name != "" {
+		return name
+	}
+	return strings.ReplaceAll(NameOf(item), "_", " ")
+}
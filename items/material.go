@@ -0,0 +1,55 @@
+package items
+
+import (
+	"fmt"
+
+	"github.com/3elDU/bamboo/types"
+)
+
+/*
+	Crafting materials. They can't be placed or used, only crafted into something else
+*/
+
+func init() {
+	Register("planks", Planks, func() types.Item { return NewMaterialItem(Planks) }, MaterialState{})
+	Register("stick", Stick, func() types.Item { return NewMaterialItem(Stick) }, nil)
+}
+
+var materialTextures = map[types.ItemType]string{
+	Planks: "planks",
+	Stick:  "stick",
+}
+
+type MaterialState struct {
+	BaseItemState
+}
+
+type MaterialItem struct {
+	baseItem
+}
+
+func NewMaterialItem(id types.ItemType) *MaterialItem {
+	return &MaterialItem{
+		baseItem: baseItem{
+			id: id,
+		},
+	}
+}
+
+func (i *MaterialItem) Use(_ types.World, _ types.Vec2u) {
+
+}
+
+func (i *MaterialItem) State() interface{} {
+	return MaterialState{
+		BaseItemState: i.baseItem.State().(BaseItemState),
+	}
+}
+
+func (i *MaterialItem) LoadState(s interface{}) error {
+	state, ok := s.(MaterialState)
+	if !ok {
+		return fmt.Errorf("%T - invalid state type; expected %T, got %T", i, MaterialState{}, s)
+	}
+	return i.baseItem.LoadState(state.BaseItemState)
+}
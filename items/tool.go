@@ -0,0 +1,100 @@
+package items
+
+import (
+	"fmt"
+
+	"github.com/3elDU/bamboo/types"
+)
+
+func init() {
+	Register("pickaxe", Pickaxe, func() types.Item { return NewToolItem(Pickaxe) }, ToolState{})
+	Register("axe", Axe, func() types.Item { return NewToolItem(Axe) }, nil)
+	Register("shovel", Shovel, func() types.Item { return NewToolItem(Shovel) }, nil)
+}
+
+const (
+	// How many times faster the tool breaks blocks of its category
+	ToolSpeed = 4
+	// How many blocks a new tool can break
+	ToolDurability = 100
+)
+
+type toolKind struct {
+	texture  string
+	category types.BlockCategory
+}
+
+var toolKinds = map[types.ItemType]toolKind{
+	Pickaxe: {texture: "pickaxe", category: types.StoneCategory},
+	Axe:     {texture: "axe", category: types.WoodCategory},
+	Shovel:  {texture: "shovel", category: types.GroundCategory},
+}
+
+type ToolState struct {
+	BaseItemState
+}
+
+// ToolItem implements types.Tool
+type ToolItem struct {
+	baseItem
+	kind toolKind
+}
+
+func NewToolItem(id types.ItemType) *ToolItem {
+	return &ToolItem{
+		baseItem: baseItem{
+			id: id,
+		},
+		kind: toolKinds[id],
+	}
+}
+
+// tools don't stack
+func (i *ToolItem) MaxStackSize() uint8 {
+	return 1
+}
+
+// Durability returns the number of blocks the tool can still break
+func (i *ToolItem) Durability() int {
+	// new tools don't have the durability in their metadata
+	if durability, ok := i.Metadata()[types.DurabilityKey].(int); ok {
+		return durability
+	}
+	return ToolDurability
+}
+
+func (i *ToolItem) Wear() bool {
+	durability := i.Durability() - 1
+	i.Metadata()[types.DurabilityKey] = durability
+	return durability <= 0
+}
+
+func (i *ToolItem) MiningSpeed(category types.BlockCategory) float64 {
+	if category != types.NoCategory && category == i.kind.category {
+		return ToolSpeed
+	}
+	return 1
+}
+
+// tools are used by holding them while breaking blocks
+func (i *ToolItem) Use(_ types.World, _ types.Vec2u) {
+
+}
+
+func (i *ToolItem) State() interface{} {
+	return ToolState{
+		BaseItemState: i.baseItem.State().(BaseItemState),
+	}
+}
+
+func (i *ToolItem) LoadState(s interface{}) error {
+	state, ok := s.(ToolState)
+	if !ok {
+		return fmt.Errorf("%T - invalid state type; expected %T, got %T", i, ToolState{}, s)
+	}
+	if err := i.baseItem.LoadState(state.BaseItemState); err != nil {
+		return err
+	}
+	i.kind = toolKinds[i.id]
+	return nil
+}
@@ -9,9 +9,11 @@ import (
 	"time"
 
 	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/commands"
 	"github.com/3elDU/bamboo/config"
 	"github.com/3elDU/bamboo/scene_manager"
 	"github.com/3elDU/bamboo/scenes"
+	"github.com/3elDU/bamboo/scripting"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/pkg/profile"
 	"golang.org/x/exp/slices"
@@ -30,6 +32,10 @@ func init() {
 	// load assets
 	asset_loader.LoadAssets(config.AssetDirectory)
 
+	// mods add blocks, items and commands, so they are loaded before anything else
+	scripting.LoadMods(config.ModDirectory)
+	commands.RegisterModCommands()
+
 	// set window options
 	ebiten.SetWindowSize(640, 480)
 	ebiten.SetWindowTitle("bamboo devtest")
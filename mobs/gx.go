@@ -0,0 +1,23 @@
+//go:build !headless
+
+package mobs
+
+import (
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/types"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func (m *Mob) Render(screen *ebiten.Image, pos types.Vec2f, scaling float64) {
+	tex := asset_loader.Texture(m.kind.Texture).Texture()
+	w, h := tex.Size()
+
+	opts := &ebiten.DrawImageOptions{}
+	if m.facingRight {
+		opts.GeoM.Scale(-1, 1)
+		opts.GeoM.Translate(float64(w), 0)
+	}
+	opts.GeoM.Scale(scaling, scaling)
+	opts.GeoM.Translate(pos.X-float64(w)/2*scaling, pos.Y-float64(h)/2*scaling)
+	screen.DrawImage(tex, opts)
+}
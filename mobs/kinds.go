@@ -0,0 +1,96 @@
+package mobs
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	Rabbit = &Kind{
+		Type:        "rabbit",
+		Texture:     "rabbit",
+		Behavior:    Flee,
+		WalkSpeed:   0.005,
+		RunSpeed:    0.02,
+		SightRadius: 5,
+		Habitat:     []types.BlockType{blocks.Grass, blocks.ShortGrass, blocks.TallGrass, blocks.Flowers},
+		SpawnChance: 0.1,
+		GroupSize:   3,
+	}
+
+	Crab = &Kind{
+		Type:        "crab",
+		Texture:     "crab",
+		Behavior:    Wander,
+		WalkSpeed:   0.004,
+		SightRadius: 0,
+		Habitat:     []types.BlockType{blocks.Sand},
+		SpawnChance: 0.15,
+		GroupSize:   2,
+	}
+
+	CaveCreature = &Kind{
+		Type:        "cave_creature",
+		Texture:     "cave_creature",
+		Behavior:    Chase,
+		WalkSpeed:   0.004,
+		RunSpeed:    0.012,
+		SightRadius: 8,
+		Damage:      10,
+		Habitat:     []types.BlockType{blocks.CaveFloor},
+		SpawnChance: 0.05,
+		GroupSize:   1,
+	}
+
+	Kinds = []*Kind{Rabbit, Crab, CaveCreature}
+)
+
+func init() {
+	for _, kind := range Kinds {
+		kind := kind
+		world.RegisterEntity(kind.Type, func() types.Entity { return newMob(kind) }, nil)
+	}
+	world.RegisterSpawner(spawn)
+}
+
+// random generator, unique for the world and the chunk,
+// so the same seed gives the same mobs
+func chunkRandom(w types.World, chunk types.Chunk) *rand.Rand {
+	hash := fnv.New64a()
+	binary.Write(hash, binary.LittleEndian, [3]uint64{uint64(w.Seed()), chunk.Coords().X, chunk.Coords().Y})
+	return rand.New(rand.NewSource(int64(hash.Sum64())))
+}
+
+// spawns groups of mobs on their habitat blocks in a freshly generated chunk
+func spawn(w types.World, chunk types.Chunk) {
+	rng := chunkRandom(w, chunk)
+	origin := chunk.BlockCoords()
+
+	for _, kind := range Kinds {
+		if rng.Float64() >= kind.SpawnChance {
+			continue
+		}
+
+		spawned := 0
+		for attempt := 0; attempt < kind.GroupSize*4 && spawned < kind.GroupSize; attempt++ {
+			x, y := uint(rng.Intn(16)), uint(rng.Intn(16))
+			if !slices.Contains(kind.Habitat, chunk.At(x, y).Type()) {
+				continue
+			}
+
+			mob := newMob(kind)
+			mob.SetPosition(types.Vec2f{
+				X: float64(origin.X+uint64(x)) + 0.5,
+				Y: float64(origin.Y+uint64(y)) + 0.5,
+			})
+			w.AddEntity(mob)
+			spawned++
+		}
+	}
+}
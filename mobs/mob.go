@@ -0,0 +1,272 @@
+/*
+	Mobs - animals and creatures, walking around the world.
+	All mobs share the same code, and differ only by their Kind
+*/
+
+package mobs
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/3elDU/bamboo/pathfinding"
+	"github.com/3elDU/bamboo/physics"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+	"golang.org/x/exp/slices"
+)
+
+func init() {
+	gob.Register(MobState{})
+}
+
+type Behavior int
+
+const (
+	// Ignores the player
+	Wander Behavior = iota
+	// Runs away from the player
+	Flee
+	// Runs towards the player
+	Chase
+)
+
+// Kind describes a type of mobs
+type Kind struct {
+	Type types.EntityType
+	// Texture faces left, it is flipped when the mob moves right
+	Texture string
+
+	Behavior Behavior
+	// Speed while wandering and running, in blocks per tick
+	WalkSpeed, RunSpeed float64
+	// How far the mob notices the player, in blocks
+	SightRadius float64
+	// Damage, dealt to the player on contact, once per AttackDelay ticks
+	Damage float64
+
+	// Blocks, the mob spawns on. While wandering, the mob doesn't leave them
+	Habitat []types.BlockType
+	// Chance of a group of mobs spawning in a freshly generated chunk
+	SpawnChance float64
+	GroupSize   int
+}
+
+var hitbox = physics.Hitbox{Left: .3, Top: .3, Right: .3, Bottom: .3}
+
+// chasing mobs don't look for paths further than that
+const pathNodes = 400
+
+const AttackDelay = 60
+
+// The wander countdown isn't saved: it changes every tick, and the chunk would have to be saved every tick too
+type MobState struct {
+	world.BaseEntityState
+	Direction types.Vec2f
+}
+
+type Mob struct {
+	world.BaseEntity
+	kind *Kind
+
+	// direction of wandering
+	direction types.Vec2f
+	// ticks left, until the mob picks a new direction
+	wanderTicks int
+	facingRight bool
+
+	// path to the chased player, it isn't saved, since the player will be somewhere else anyway
+	search      *pathfinding.Search
+	path        []types.Vec2u
+	repathTicks int
+	// ticks left, until the mob can attack again
+	attackTicks int
+}
+
+func newMob(kind *Kind) *Mob {
+	return &Mob{kind: kind}
+}
+
+func (m *Mob) Type() types.EntityType {
+	return m.kind.Type
+}
+
+func (m *Mob) Kind() *Kind {
+	return m.kind
+}
+
+func (m *Mob) inHabitat(w types.World, pos types.Vec2f) bool {
+	return slices.Contains(m.kind.Habitat, w.BlockAt(uint64(pos.X), uint64(pos.Y)).Type())
+}
+
+// returns the nearest player, in the sight radius
+func (m *Mob) findPlayer(w types.World) (types.Entity, bool) {
+	// mobs, that ignore the player, don't need to look for it
+	if m.kind.Behavior == Wander {
+		return nil, false
+	}
+
+	pos := m.Position()
+	var (
+		nearest  types.Entity
+		distance = math.Inf(1)
+	)
+	for _, entity := range w.EntitiesAround(pos, m.kind.SightRadius) {
+		if entity.Type() != types.PlayerEntity {
+			continue
+		}
+		p := entity.Position()
+		if d := math.Hypot(p.X-pos.X, p.Y-pos.Y); d < distance {
+			nearest, distance = entity, d
+		}
+	}
+	return nearest, nearest != nil
+}
+
+func (m *Mob) attack(target types.Entity) {
+	damageable, ok := target.(types.Damageable)
+	if !ok || m.kind.Damage == 0 || m.attackTicks > 0 {
+		return
+	}
+	damageable.Damage(m.kind.Damage)
+	m.attackTicks = AttackDelay
+}
+
+func normalize(v types.Vec2f) types.Vec2f {
+	length := math.Hypot(v.X, v.Y)
+	if length == 0 {
+		return types.Vec2f{}
+	}
+	return types.Vec2f{X: v.X / length, Y: v.Y / length}
+}
+
+// picks the direction and the speed of the mob for the current tick
+func (m *Mob) think(w types.World) (types.Vec2f, float64) {
+	pos := m.Position()
+
+	if player, found := m.findPlayer(w); found {
+		target := player.Position()
+		switch m.kind.Behavior {
+		case Flee:
+			return normalize(types.Vec2f{X: pos.X - target.X, Y: pos.Y - target.Y}), m.kind.RunSpeed
+		case Chase:
+			// stop right next to the player, and attack it
+			if math.Hypot(target.X-pos.X, target.Y-pos.Y) < 0.5 {
+				m.attack(player)
+				return types.Vec2f{}, 0
+			}
+			return m.chase(w, target), m.kind.RunSpeed
+		}
+	}
+	m.search, m.path = nil, nil
+
+	m.wanderTicks--
+	ahead := types.Vec2f{X: pos.X + m.direction.X, Y: pos.Y + m.direction.Y}
+	if m.wanderTicks <= 0 || !m.inHabitat(w, ahead) {
+		m.wanderTicks = 60 + rand.Intn(120)
+		// stand still half of the time
+		if rand.Intn(2) == 0 {
+			m.direction = types.Vec2f{}
+		} else {
+			angle := rand.Float64() * 2 * math.Pi
+			m.direction = types.Vec2f{X: math.Cos(angle), Y: math.Sin(angle)}
+		}
+		return types.Vec2f{}, 0
+	}
+	return m.direction, m.kind.WalkSpeed
+}
+
+// returns direction to the next block of the path to the target
+// The path is searched again every second, since the target keeps moving
+func (m *Mob) chase(w types.World, target types.Vec2f) types.Vec2f {
+	pos := m.Position()
+
+	m.repathTicks--
+	if m.search == nil && m.repathTicks <= 0 {
+		m.repathTicks = 60
+		m.search = pathfinding.NewSearch(w,
+			types.Vec2u{X: uint64(pos.X), Y: uint64(pos.Y)},
+			types.Vec2u{X: uint64(target.X), Y: uint64(target.Y)},
+			pathNodes,
+		)
+	}
+	if m.search != nil {
+		switch m.search.Step(pathNodes / 4) {
+		case pathfinding.Found:
+			m.path = m.search.Path()
+			m.search = nil
+		case pathfinding.NotFound:
+			m.path = nil
+			m.search = nil
+		}
+	}
+
+	// skip blocks, that are already reached
+	for len(m.path) > 0 {
+		next := types.Vec2f{X: float64(m.path[0].X) + 0.5, Y: float64(m.path[0].Y) + 0.5}
+		if math.Hypot(next.X-pos.X, next.Y-pos.Y) > 0.3 {
+			return normalize(types.Vec2f{X: next.X - pos.X, Y: next.Y - pos.Y})
+		}
+		m.path = m.path[1:]
+	}
+
+	// without a path, just run straight to the target
+	return normalize(types.Vec2f{X: target.X - pos.X, Y: target.Y - pos.Y})
+}
+
+func (m *Mob) Update(w types.World) {
+	if m.attackTicks > 0 {
+		m.attackTicks--
+	}
+	direction, speed := m.think(w)
+
+	pos, velocity := m.Position(), m.Velocity()
+	velocity.X += direction.X * speed
+	velocity.Y += direction.Y * speed
+
+	velocity = physics.ResolveCollisions(pos, velocity, hitbox, w)
+	speedModifier := physics.SpeedModifier(pos, w)
+
+	pos.X += velocity.X * speedModifier
+	pos.Y += velocity.Y * speedModifier
+
+	velocity.X *= 0.75
+	velocity.Y *= 0.75
+	// stop completely, instead of creeping for a long time, so the chunk isn't saved on every tick
+	if math.Abs(velocity.X) < 0.0001 && math.Abs(velocity.Y) < 0.0001 {
+		velocity = types.Vec2f{}
+	}
+
+	m.SetPosition(pos)
+	m.SetVelocity(velocity)
+}
+
+// SetPosition turns the mob towards the movement.
+// In multiplayer, clients don't update the mobs, they are only moved by the server
+func (m *Mob) SetPosition(pos types.Vec2f) {
+	if dx := pos.X - m.Position().X; math.Abs(dx) > 0.001 {
+		m.facingRight = dx > 0
+	}
+	m.BaseEntity.SetPosition(pos)
+}
+
+func (m *Mob) State() interface{} {
+	return MobState{
+		BaseEntityState: m.BaseEntity.State().(world.BaseEntityState),
+		Direction:       m.direction,
+	}
+}
+
+func (m *Mob) LoadState(s interface{}) error {
+	state, ok := s.(MobState)
+	if !ok {
+		return fmt.Errorf("%T - invalid state type; expected %T, got %T", m, MobState{}, s)
+	}
+	if err := m.BaseEntity.LoadState(state.BaseEntityState); err != nil {
+		return err
+	}
+	m.direction = state.Direction
+	return nil
+}
@@ -0,0 +1,565 @@
+// Multiplayer client
+//
+// Client implements world.Remote, so a world.World can be replicated from the server.
+// Messages are read on a separate goroutine, and queued until the game picks them up on its update
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+	"github.com/3elDU/bamboo/world_type"
+)
+
+const dialTimeout = 5 * time.Second
+
+var ErrDisconnected = errors.New("disconnected from the server")
+
+type PlayerEventType uint8
+
+const (
+	PlayerJoined PlayerEventType = iota
+	PlayerMoved
+	PlayerLeft
+)
+
+// ChatMessage is a message, sent by one of the players
+type ChatMessage struct {
+	Name string
+	Text string
+}
+
+// PlayerEvent is something, that happened to another player
+type PlayerEvent struct {
+	Type PlayerEventType
+	ID   uint32
+	// set for PlayerJoined
+	Name string
+	// set for PlayerMoved
+	Position types.Vec2f
+}
+
+type Client struct {
+	conn     net.Conn
+	id       uint32
+	metadata types.Save
+	// where the server has placed the player
+	spawn types.Vec2f
+
+	// chunks, that were requested, but not received yet. Accessed only from the game
+	requested map[types.Vec2u]bool
+	// the last position, sent to the server
+	lastPosition types.Vec2f
+
+	outgoing chan message
+
+	// filled by the reader goroutine. The queues are unbounded,
+	// so the reader never blocks, even when the game doesn't update ( e.g. on the death screen )
+	mutex   sync.Mutex
+	chunks  []*world.Chunk
+	changes []world.BlockChange
+	drops   [][]string
+	times   []types.WorldTime
+	players []PlayerEvent
+	chat    []ChatMessage
+	// positions, the player was moved back to by the server
+	positions []types.Vec2f
+	// chunks, that the server refused to send
+	denied   []types.Vec2u
+	entities []world.EntityUpdate
+	damage   []float64
+	// reason, why the connection was closed, nil while it is open
+	err error
+}
+
+// Dial connects to the server, and waits until it accepts the player
+func Dial(address, name string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	e := new(encoder)
+	e.write(ProtocolVersion)
+	e.writeString(name)
+	if err := writeMessage(conn, e.message(msgHello)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(dialTimeout))
+	msg, err := readMessage(conn)
+	conn.SetReadDeadline(time.Time{})
+	if err == nil {
+		err = checkWelcome(msg)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client := &Client{
+		conn:      conn,
+		requested: make(map[types.Vec2u]bool),
+		outgoing:  make(chan message, outgoingQueueSize),
+	}
+	var worldType uint8
+	d := newDecoder(msg.payload)
+	d.read(&client.id, &client.metadata.Seed, &worldType, &client.metadata.Time, &client.metadata.BaseUUID, &client.metadata.UUID)
+	client.metadata.Name = d.readString()
+	d.read(&client.spawn.X, &client.spawn.Y)
+	if d.err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("invalid welcome message - %w", d.err)
+	}
+	client.metadata.WorldType = world_type.WorldType(worldType)
+
+	log.Printf("Client - joined %v as player %v", address, client.id)
+
+	go client.runReader()
+	go client.runWriter()
+	return client, nil
+}
+
+// checkWelcome returns the reason of the refusal, if the server didn't accept the player
+func checkWelcome(msg message) error {
+	switch msg.kind {
+	case msgWelcome:
+		return nil
+	case msgDisconnect:
+		reason := newDecoder(msg.payload).readString()
+		return fmt.Errorf("%w - %v", ErrDisconnected, reason)
+	default:
+		return fmt.Errorf("expected welcome message, got %v", msg.kind)
+	}
+}
+
+// Metadata returns the metadata of the world on the server
+func (client *Client) Metadata() types.Save {
+	return client.metadata
+}
+
+func (client *Client) ID() uint32 {
+	return client.id
+}
+
+// Spawn returns the position, the server has placed the player at, when it joined
+func (client *Client) Spawn() types.Vec2f {
+	return client.spawn
+}
+
+// Err returns the reason, why the connection was closed, or nil, if the client is still connected
+func (client *Client) Err() error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	return client.err
+}
+
+func (client *Client) Close() {
+	client.disconnect(ErrDisconnected)
+}
+
+func (client *Client) disconnect(err error) {
+	client.mutex.Lock()
+	if client.err == nil {
+		client.err = err
+		close(client.outgoing)
+	}
+	client.mutex.Unlock()
+	client.conn.Close()
+}
+
+func (client *Client) send(msg message) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if client.err != nil {
+		return
+	}
+
+	select {
+	case client.outgoing <- msg:
+	default:
+		log.Println("Client - outgoing queue is full, dropping a message")
+	}
+}
+
+func (client *Client) runWriter() {
+	for msg := range client.outgoing {
+		if err := writeMessage(client.conn, msg); err != nil {
+			client.disconnect(err)
+			return
+		}
+	}
+}
+
+func (client *Client) runReader() {
+	for {
+		msg, err := readMessage(client.conn)
+		if err == nil {
+			err = client.handle(msg)
+		}
+		if err != nil {
+			log.Printf("Client - connection closed - %v", err)
+			client.disconnect(err)
+			return
+		}
+	}
+}
+
+// handle is called on the reader goroutine
+func (client *Client) handle(msg message) error {
+	d := newDecoder(msg.payload)
+
+	switch msg.kind {
+	case msgDisconnect:
+		return fmt.Errorf("%w - %v", ErrDisconnected, d.readString())
+
+	case msgChunk:
+		chunk, err := decodeChunk(msg.payload)
+		if err != nil {
+			return err
+		}
+		client.mutex.Lock()
+		client.chunks = append(client.chunks, chunk)
+		client.mutex.Unlock()
+
+	case msgChunkDenied:
+		var coords types.Vec2u
+		d.read(&coords.X, &coords.Y)
+		if d.err != nil {
+			return d.err
+		}
+		client.mutex.Lock()
+		client.denied = append(client.denied, coords)
+		client.mutex.Unlock()
+
+	case msgBlockChange:
+		change, err := decodeBlockChange(msg.payload)
+		if err != nil {
+			return err
+		}
+		client.mutex.Lock()
+		client.changes = append(client.changes, change)
+		client.mutex.Unlock()
+
+	case msgEntitySpawn, msgEntityMove, msgEntityDespawn:
+		var update world.EntityUpdate
+		var err error
+		switch msg.kind {
+		case msgEntitySpawn:
+			update, err = decodeEntity(msg.payload)
+		case msgEntityMove:
+			d.read(&update.ID, &update.Position.X, &update.Position.Y)
+			err = d.err
+		case msgEntityDespawn:
+			d.read(&update.ID)
+			update.Removed = true
+			err = d.err
+		}
+		if err != nil {
+			return err
+		}
+		client.mutex.Lock()
+		client.entities = append(client.entities, update)
+		client.mutex.Unlock()
+
+	case msgDamage:
+		var damage float64
+		d.read(&damage)
+		if d.err != nil {
+			return d.err
+		}
+		client.mutex.Lock()
+		client.damage = append(client.damage, damage)
+		client.mutex.Unlock()
+
+	case msgDrops:
+		var count uint16
+		d.read(&count)
+		drops := make([]string, 0, count)
+		for i := uint16(0); i < count && d.err == nil; i++ {
+			drops = append(drops, d.readString())
+		}
+		if d.err != nil {
+			return d.err
+		}
+		client.mutex.Lock()
+		client.drops = append(client.drops, drops)
+		client.mutex.Unlock()
+
+	case msgTime:
+		var time types.WorldTime
+		d.read(&time)
+		if d.err != nil {
+			return d.err
+		}
+		client.mutex.Lock()
+		client.times = append(client.times, time)
+		client.mutex.Unlock()
+
+	case msgPlayerJoined, msgPlayerMove, msgPlayerLeft:
+		event := PlayerEvent{}
+		d.read(&event.ID)
+		switch msg.kind {
+		case msgPlayerJoined:
+			event.Type = PlayerJoined
+			event.Name = d.readString()
+		case msgPlayerMove:
+			event.Type = PlayerMoved
+			d.read(&event.Position.X, &event.Position.Y)
+		case msgPlayerLeft:
+			event.Type = PlayerLeft
+		}
+		if d.err != nil {
+			return d.err
+		}
+		client.mutex.Lock()
+		client.players = append(client.players, event)
+		client.mutex.Unlock()
+
+	case msgPlayerPosition:
+		var pos types.Vec2f
+		d.read(&pos.X, &pos.Y)
+		if d.err != nil {
+			return d.err
+		}
+		client.mutex.Lock()
+		client.positions = append(client.positions, pos)
+		client.mutex.Unlock()
+
+	case msgChat:
+		chat := ChatMessage{Name: d.readString(), Text: d.readString()}
+		if d.err != nil {
+			return d.err
+		}
+		client.mutex.Lock()
+		client.chat = append(client.chat, chat)
+		client.mutex.Unlock()
+
+	default:
+		return fmt.Errorf("unexpected message type %v", msg.kind)
+	}
+	return nil
+}
+
+// RequestChunk implements world.Remote
+func (client *Client) RequestChunk(cx, cy uint64) {
+	coords := types.Vec2u{X: cx, Y: cy}
+	if client.requested[coords] {
+		return
+	}
+	client.requested[coords] = true
+
+	e := new(encoder)
+	e.write(cx, cy)
+	client.send(e.message(msgChunkRequest))
+}
+
+// PlaceBlock implements world.Remote
+func (client *Client) PlaceBlock(bx, by uint64, block types.Block) {
+	e := new(encoder)
+	e.write(bx, by)
+	e.writeString(blocks.Name(block.Type()))
+	client.send(e.message(msgPlaceBlock))
+}
+
+// StartBreaking implements world.Remote
+func (client *Client) StartBreaking(bx, by uint64) {
+	e := new(encoder)
+	e.write(bx, by)
+	client.send(e.message(msgStartBreaking))
+}
+
+// BreakBlock implements world.Remote
+func (client *Client) BreakBlock(bx, by uint64) {
+	e := new(encoder)
+	e.write(bx, by)
+	client.send(e.message(msgBreakBlock))
+}
+
+// SendPosition sends the position of the player to the server, if it has changed
+func (client *Client) SendPosition(pos types.Vec2f) {
+	if pos == client.lastPosition {
+		return
+	}
+	client.lastPosition = pos
+
+	e := new(encoder)
+	e.write(pos.X, pos.Y)
+	client.send(e.message(msgPlayerMove))
+}
+
+// Craft tells the server, that the player has crafted the recipe with the given name.
+// The server counts the items of the player, so it knows which blocks the player can place
+func (client *Client) Craft(recipe string) {
+	e := new(encoder)
+	e.writeString(recipe)
+	client.send(e.message(msgCraft))
+}
+
+// Respawn tells the server, that the player has died, and was moved to the spawn point
+func (client *Client) Respawn() {
+	client.send(message{kind: msgRespawn})
+}
+
+// SendChat sends the chat message to all players, including this one.
+// Messages longer than MaxChatLength are cut
+func (client *Client) SendChat(text string) {
+	if len(text) > MaxChatLength {
+		text = text[:MaxChatLength]
+	}
+
+	e := new(encoder)
+	e.writeString(text)
+	client.send(e.message(msgChat))
+}
+
+// ReceiveChunk implements world.Remote
+func (client *Client) ReceiveChunk() (*world.Chunk, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.chunks) == 0 {
+		return nil, false
+	}
+
+	chunk := client.chunks[0]
+	client.chunks = client.chunks[1:]
+	delete(client.requested, chunk.Coords())
+	return chunk, true
+}
+
+// ReceiveDenied implements world.Remote
+func (client *Client) ReceiveDenied() (types.Vec2u, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.denied) == 0 {
+		return types.Vec2u{}, false
+	}
+
+	coords := client.denied[0]
+	client.denied = client.denied[1:]
+	delete(client.requested, coords)
+	return coords, true
+}
+
+// ReceiveBlock implements world.Remote
+func (client *Client) ReceiveBlock() (world.BlockChange, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.changes) == 0 {
+		return world.BlockChange{}, false
+	}
+
+	change := client.changes[0]
+	client.changes = client.changes[1:]
+	return change, true
+}
+
+// ReceiveEntity implements world.Remote
+func (client *Client) ReceiveEntity() (world.EntityUpdate, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.entities) == 0 {
+		return world.EntityUpdate{}, false
+	}
+
+	update := client.entities[0]
+	client.entities = client.entities[1:]
+	return update, true
+}
+
+// ReceiveDamage returns the next damage, dealt to the player by the mobs.
+// The second value is false, if there is none
+func (client *Client) ReceiveDamage() (float64, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.damage) == 0 {
+		return 0, false
+	}
+
+	damage := client.damage[0]
+	client.damage = client.damage[1:]
+	return damage, true
+}
+
+// ReceiveDrops returns the names of the items, dropped by the next block, that the player has broken.
+// The second value is false, if there are none
+func (client *Client) ReceiveDrops() ([]string, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.drops) == 0 {
+		return nil, false
+	}
+
+	drops := client.drops[0]
+	client.drops = client.drops[1:]
+	return drops, true
+}
+
+// ReceiveTime implements world.Remote
+func (client *Client) ReceiveTime() (types.WorldTime, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.times) == 0 {
+		return 0, false
+	}
+
+	time := client.times[0]
+	client.times = client.times[1:]
+	return time, true
+}
+
+// ReceivePlayer returns the next event about other players.
+// The second value is false, if there are none
+func (client *Client) ReceivePlayer() (PlayerEvent, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.players) == 0 {
+		return PlayerEvent{}, false
+	}
+
+	event := client.players[0]
+	client.players = client.players[1:]
+	return event, true
+}
+
+// ReceivePosition returns the position, the player must be moved back to, since the server has rejected its move.
+// The second value is false, if there are none
+func (client *Client) ReceivePosition() (types.Vec2f, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.positions) == 0 {
+		return types.Vec2f{}, false
+	}
+
+	pos := client.positions[0]
+	client.positions = client.positions[1:]
+	// the position is already on the server, so it isn't sent back
+	client.lastPosition = pos
+	return pos, true
+}
+
+// ReceiveChat returns the next chat message.
+// The second value is false, if there are none
+func (client *Client) ReceiveChat() (ChatMessage, bool) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if len(client.chat) == 0 {
+		return ChatMessage{}, false
+	}
+
+	chat := client.chat[0]
+	client.chat = client.chat[1:]
+	return chat, true
+}
+
+// ensures, that the client can be used as a remote for the world
+var _ world.Remote = (*Client)(nil)
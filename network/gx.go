@@ -0,0 +1,30 @@
+//go:build !headless
+
+package network
+
+import (
+	"image"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/colors"
+	"github.com/3elDU/bamboo/font"
+	"github.com/3elDU/bamboo/types"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// pos is the position of the player's feet on the screen
+func (p *RemotePlayer) Render(screen *ebiten.Image, pos types.Vec2f, scaling float64) {
+	// the first frame of the walking animation
+	tex := asset_loader.Texture(p.texture).Texture().SubImage(image.Rect(0, 0, 16, 32)).(*ebiten.Image)
+
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Scale(scaling, scaling)
+	opts.GeoM.Translate(pos.X-8*scaling, pos.Y-16*scaling)
+	screen.DrawImage(tex, opts)
+
+	// name above the head
+	font.RenderFont(screen, p.Name,
+		pos.X-font.GetStringWidth(p.Name, 1)/2, pos.Y-16*scaling-font.GetStringHeight(p.Name, 1),
+		colors.White,
+	)
+}
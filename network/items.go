@@ -0,0 +1,58 @@
+// Items of the players, as the server sees them
+//
+// The inventory itself is kept by the game. The server only counts the items, the player has got from the blocks
+// it broke and the recipes it crafted, so it knows which blocks the player can place, and which tools it has.
+// Placing a block doesn't take the item, same as in singleplayer
+
+package network
+
+import (
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/types"
+)
+
+// itemCounts maps the item names to their quantity
+type itemCounts map[string]int
+
+func (counts itemCounts) add(names []string) {
+	for _, name := range names {
+		counts[name]++
+	}
+}
+
+// craft takes the ingredients of the recipe, and adds its result.
+// Returns false, if the recipe is unknown, or the player doesn't have the ingredients
+func (counts itemCounts) craft(recipe string) bool {
+	definition, exists := asset_loader.GlobalAssets.RecipeDefinitions[recipe]
+	if !exists {
+		return false
+	}
+	ingredients := definition.IngredientCounts()
+	for name, count := range ingredients {
+		if counts[name] < count {
+			return false
+		}
+	}
+
+	for name, count := range ingredients {
+		counts[name] -= count
+	}
+	counts[definition.Result.Item] += definition.Result.Count
+	return true
+}
+
+// miningSpeed returns how fast the player breaks blocks of the category with the best tool it has
+func (counts itemCounts) miningSpeed(category types.BlockCategory) float64 {
+	speed := 1.0
+	for name, count := range counts {
+		if count <= 0 {
+			continue
+		}
+		item, _ := items.GetItemByName(name)
+		if tool, ok := item.(types.Tool); ok && tool.MiningSpeed(category) > speed {
+			speed = tool.MiningSpeed(category)
+		}
+	}
+	return speed
+}
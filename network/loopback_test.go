@@ -0,0 +1,178 @@
+//go:build headless
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/event"
+	"github.com/3elDU/bamboo/mobs"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+	"github.com/3elDU/bamboo/world_type"
+	"github.com/google/uuid"
+)
+
+const receiveTimeout = 5 * time.Second
+
+// receive polls the client until it returns a value, since the messages are read on another goroutine
+func receive[T any](t *testing.T, what string, poll func() (T, bool)) T {
+	t.Helper()
+	deadline := time.Now().Add(receiveTimeout)
+	for time.Now().Before(deadline) {
+		if value, ok := poll(); ok {
+			return value
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("didn't receive %v in %v", what, receiveTimeout)
+	panic("unreachable")
+}
+
+// TestLoopback runs the server and the client in the same process
+func TestLoopback(t *testing.T) {
+	config.WorldSaveDirectory = t.TempDir()
+
+	hosted := world.NewWorld(types.Save{
+		Name:      "Loopback",
+		BaseUUID:  uuid.New(),
+		UUID:      uuid.New(),
+		Seed:      1,
+		WorldType: world_type.Overworld,
+	})
+	// before the server goroutine starts, since the bus isn't safe for concurrent use
+	// the player joins at the spawn point, so the blocks are picked next to it
+	spawn := world.SpawnPoint(hosted)
+	target := types.Vec2u{X: uint64(spawn.X), Y: uint64(spawn.Y)}
+	cx, cy := target.X/16, target.Y/16
+	// both blocks are in the same chunk
+	protected := types.Vec2u{X: target.X + 1, Y: target.Y}
+	if target.X%16 == 15 {
+		protected.X = target.X - 1
+	}
+	// out of reach, but in the same chunk, so the server has it loaded
+	far := types.Vec2u{X: target.X + 6, Y: target.Y}
+	if target.X%16 >= 8 {
+		far.X = target.X - 6
+	}
+
+	event.Subscribe(hosted.Events(), event.Normal, func(ev *types.BlockPlacedEvent) {
+		if ev.Pos == protected {
+			ev.Cancel()
+		}
+	})
+
+	// right next to the player, so it attacks at once
+	creature, err := world.NewEntity(mobs.CaveCreature.Type, mobs.MobState{
+		BaseEntityState: world.BaseEntityState{Position: types.Vec2f{X: spawn.X + 0.2, Y: spawn.Y}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosted.AddEntity(creature)
+
+	server := NewDedicatedServer(hosted)
+	// the player doesn't break anything, so it gets the blocks to place
+	server.SetPlayerItems("tester", map[string]int{"stone": 1, "sand": 1})
+	if err := server.Listen("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client, err := Dial(server.Addr().String(), "tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if client.Spawn() != spawn {
+		t.Fatalf("joined at %v, expected the spawn point %v", client.Spawn(), spawn)
+	}
+
+	client.RequestChunk(cx, cy)
+	chunk := receive(t, "the chunk", client.ReceiveChunk)
+	if chunk.Coords() != (types.Vec2u{X: cx, Y: cy}) {
+		t.Fatalf("requested chunk %v, %v, got %v", cx, cy, chunk.Coords())
+	}
+
+	// too far from the player
+	client.RequestChunk(cx+uint64(maxChunkDistance)+1, cy)
+	denied := receive(t, "the denied chunk", client.ReceiveDenied)
+	if denied != (types.Vec2u{X: cx + uint64(maxChunkDistance) + 1, Y: cy}) {
+		t.Fatalf("requested a chunk too far from the player, the server denied %v", denied)
+	}
+
+	// a block, that differs from the ones in the chunk, so the server doesn't ignore it
+	at := func(pos types.Vec2u) string {
+		return blocks.Name(chunk.At(uint(pos.X%16), uint(pos.Y%16)).Type())
+	}
+	name := "stone"
+	if at(target) == name || at(protected) == name {
+		name = "sand"
+	}
+	block, _ := blocks.GetBlockByName(name)
+
+	client.PlaceBlock(target.X, target.Y, block)
+	change := receive(t, "the placed block", client.ReceiveBlock)
+	if change.X != target.X || change.Y != target.Y || blocks.Name(change.Block.Type()) != name {
+		t.Fatalf("placed %v at %v, %v, the server sent %v at %v, %v",
+			name, target.X, target.Y, blocks.Name(change.Block.Type()), change.X, change.Y)
+	}
+
+	// the player doesn't have it
+	entrance, _ := blocks.GetBlockByName("cave_entrance")
+	client.PlaceBlock(target.X, target.Y, entrance)
+	change = receive(t, "the rejected block", client.ReceiveBlock)
+	if blocks.Name(change.Block.Type()) != name {
+		t.Fatalf("placed a cave entrance, the player doesn't have, the server sent %v instead of %v",
+			blocks.Name(change.Block.Type()), name)
+	}
+
+	// broken faster, than the hardness allows
+	client.StartBreaking(target.X, target.Y)
+	client.BreakBlock(target.X, target.Y)
+	change = receive(t, "the rejected break", client.ReceiveBlock)
+	if blocks.Name(change.Block.Type()) != name {
+		t.Fatalf("broke %v right away, the server sent %v", name, blocks.Name(change.Block.Type()))
+	}
+
+	// cancelled by the server's subscriber
+	client.PlaceBlock(protected.X, protected.Y, block)
+	change = receive(t, "the cancelled block", client.ReceiveBlock)
+	if change.X != protected.X || blocks.Name(change.Block.Type()) != at(protected) {
+		t.Fatalf("placed %v at %v, %v, which is cancelled on the server, but the server sent %v",
+			name, protected.X, protected.Y, blocks.Name(change.Block.Type()))
+	}
+
+	// out of reach, the server sends back the block, that is already there
+	client.BreakBlock(far.X, far.Y)
+	change = receive(t, "the rejected change", client.ReceiveBlock)
+	if change.X != far.X || blocks.Name(change.Block.Type()) != at(far) {
+		t.Fatalf("broke a block out of reach, the server sent %v instead of %v", blocks.Name(change.Block.Type()), at(far))
+	}
+	if _, ok := client.ReceiveDrops(); ok {
+		t.Fatal("got the drops of a block out of reach")
+	}
+
+	// other mobs may have spawned around the player too
+	receive(t, "the cave creature", func() (world.EntityUpdate, bool) {
+		for {
+			update, ok := client.ReceiveEntity()
+			if !ok || update.Entity != nil && update.Entity.Type() == mobs.CaveCreature.Type {
+				return update, ok
+			}
+		}
+	})
+	if damage := receive(t, "the damage", client.ReceiveDamage); damage != mobs.CaveCreature.Damage {
+		t.Fatalf("the cave creature dealt %v damage, expected %v", damage, mobs.CaveCreature.Damage)
+	}
+
+	// too far to walk in a tick, the server moves the player back
+	client.SendPosition(types.Vec2f{X: spawn.X + 10, Y: spawn.Y + 10})
+	pos := receive(t, "the corrected position", client.ReceivePosition)
+	if pos != spawn {
+		t.Fatalf("teleported from %v, the server moved the player to %v", spawn, pos)
+	}
+}
@@ -0,0 +1,69 @@
+package network
+
+import (
+	"math"
+
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+)
+
+// RemotePlayer is another player, connected to the same server.
+// On the server, it stands for each connected client in the world.
+// It isn't simulated, its position comes from the network
+type RemotePlayer struct {
+	world.BaseEntity
+
+	ID   uint32
+	Name string
+
+	// player texture, facing the last movement direction
+	texture string
+	// on the server, damage, dealt by the mobs, that isn't sent to the client yet
+	damage float64
+}
+
+func NewRemotePlayer(id uint32, name string, pos types.Vec2f) *RemotePlayer {
+	p := &RemotePlayer{ID: id, Name: name, texture: "player_down"}
+	p.BaseEntity.SetPosition(pos)
+	return p
+}
+
+func (p *RemotePlayer) Type() types.EntityType {
+	return types.PlayerEntity
+}
+
+// SetPosition turns the player towards the direction of the movement
+func (p *RemotePlayer) SetPosition(pos types.Vec2f) {
+	dx, dy := pos.X-p.Position().X, pos.Y-p.Position().Y
+	switch {
+	case dx == 0 && dy == 0:
+	case math.Abs(dx) > math.Abs(dy) && dx < 0:
+		p.texture = "player_left"
+	case math.Abs(dx) > math.Abs(dy):
+		p.texture = "player_right"
+	case dy < 0:
+		p.texture = "player_up"
+	default:
+		p.texture = "player_down"
+	}
+	p.BaseEntity.SetPosition(pos)
+}
+
+func (p *RemotePlayer) Update(_ types.World) {
+
+}
+
+// Damage implements types.Damageable.
+// The health is kept by the game, so the server passes the damage to the client
+func (p *RemotePlayer) Damage(amount float64) {
+	p.damage += amount
+}
+
+// remote players aren't saved with the chunks
+func (p *RemotePlayer) State() interface{} {
+	return nil
+}
+
+func (p *RemotePlayer) LoadState(interface{}) error {
+	return nil
+}
@@ -0,0 +1,396 @@
+/*
+	Binary protocol, spoken between the server and the clients over TCP.
+
+	Each message is a header, followed by the payload:
+		type   uint8
+		length uint32, length of the payload
+	Numbers are big-endian, strings and byte slices are prefixed with their length.
+	Block states are arbitrary values, so they are encoded with gob, the same way as they are saved to the disk.
+*/
+
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+)
+
+// ProtocolVersion must be increased on each incompatible change of the protocol
+const ProtocolVersion uint16 = 4
+
+// Messages larger than that are considered corrupt
+const maxMessageSize = 1 << 20
+
+// Longest chat message, in bytes, the server accepts
+const MaxChatLength = 256
+
+type messageType uint8
+
+const (
+	// client -> server: protocol version, player name
+	msgHello messageType = iota + 1
+	// server -> client: player ID, world metadata, player position
+	msgWelcome
+	// server -> client: the reason, why the connection is closed
+	msgDisconnect
+
+	// client -> server: chunk coordinates
+	msgChunkRequest
+	// server -> client: chunk coordinates, block palette, blocks and their states
+	msgChunk
+	// server -> client: block coordinates and the block, that was changed
+	msgBlockChange
+	// client -> server: block coordinates and the name of the block, the player places
+	msgPlaceBlock
+	// client -> server: block coordinates of the block, the player has started to break
+	msgStartBreaking
+	// client -> server: block coordinates of the block, the player breaks
+	msgBreakBlock
+	// server -> client: names of the items, dropped by the block, the player has broken
+	msgDrops
+	// server -> client: world time
+	msgTime
+
+	// server -> client: player ID, player name
+	msgPlayerJoined
+	// client -> server: player position;
+	// server -> client: player ID, player position
+	msgPlayerMove
+	// server -> client: player ID
+	msgPlayerLeft
+	// server -> client: position, the player is moved back to, after the server has rejected its move
+	msgPlayerPosition
+	// client -> server: the player has died, and starts again at the spawn point
+	msgRespawn
+
+	// server -> client: chunk coordinates of the requested chunk, that is too far from the player
+	msgChunkDenied
+	// client -> server: name of the recipe, the player has crafted
+	msgCraft
+
+	// server -> client: entity ID, entity type and its state, for an entity, that appeared near the player
+	msgEntitySpawn
+	// server -> client: entity ID, entity position
+	msgEntityMove
+	// server -> client: entity ID, for an entity, that is gone, or too far from the player
+	msgEntityDespawn
+	// server -> client: damage, dealt to the player by the mobs
+	msgDamage
+
+	// client -> server: chat message;
+	// server -> client: sender name, chat message
+	msgChat
+)
+
+var ErrMessageTooLarge = errors.New("message is too large")
+
+type message struct {
+	kind    messageType
+	payload []byte
+}
+
+func writeMessage(w io.Writer, msg message) error {
+	header := make([]byte, 5)
+	header[0] = byte(msg.kind)
+	binary.BigEndian.PutUint32(header[1:], uint32(len(msg.payload)))
+
+	// a single write, so the header and the payload aren't sent as separate packets
+	_, err := w.Write(append(header, msg.payload...))
+	return err
+}
+
+func readMessage(r io.Reader) (message, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return message{}, err
+	}
+
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxMessageSize {
+		return message{}, fmt.Errorf("%w - %v bytes", ErrMessageTooLarge, length)
+	}
+
+	msg := message{kind: messageType(header[0]), payload: make([]byte, length)}
+	if _, err := io.ReadFull(r, msg.payload); err != nil {
+		return message{}, err
+	}
+	return msg, nil
+}
+
+// encoder builds the payload of a message
+type encoder struct {
+	bytes.Buffer
+}
+
+// write appends fixed-size values, see binary.Write()
+func (e *encoder) write(values ...interface{}) {
+	for _, value := range values {
+		// writes to bytes.Buffer never fail
+		binary.Write(&e.Buffer, binary.BigEndian, value)
+	}
+}
+
+func (e *encoder) writeString(s string) {
+	e.write(uint16(len(s)))
+	e.WriteString(s)
+}
+
+func (e *encoder) writeBytes(b []byte) {
+	e.write(uint32(len(b)))
+	e.Write(b)
+}
+
+func (e *encoder) message(kind messageType) message {
+	return message{kind: kind, payload: e.Bytes()}
+}
+
+// decoder reads the payload of a message.
+// After the first error, all reads are skipped, so it is enough to check decoder.err at the end
+type decoder struct {
+	r   *bytes.Reader
+	err error
+}
+
+func newDecoder(payload []byte) *decoder {
+	return &decoder{r: bytes.NewReader(payload)}
+}
+
+// read reads fixed-size values into the pointers, see binary.Read()
+func (d *decoder) read(values ...interface{}) {
+	for _, value := range values {
+		if d.err != nil {
+			return
+		}
+		d.err = binary.Read(d.r, binary.BigEndian, value)
+	}
+}
+
+func (d *decoder) readBytes() []byte {
+	var length uint32
+	d.read(&length)
+	if d.err != nil {
+		return nil
+	}
+	if int64(length) > int64(d.r.Len()) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+
+	b := make([]byte, length)
+	_, d.err = io.ReadFull(d.r, b)
+	return b
+}
+
+func (d *decoder) readString() string {
+	var length uint16
+	d.read(&length)
+	if d.err != nil {
+		return ""
+	}
+	if int(length) > d.r.Len() {
+		d.err = io.ErrUnexpectedEOF
+		return ""
+	}
+
+	b := make([]byte, length)
+	_, d.err = io.ReadFull(d.r, b)
+	return string(b)
+}
+
+// gob needs a concrete type at the top level, so the states are wrapped into a struct
+type wireState struct {
+	State interface{}
+}
+
+type wireChunkStates struct {
+	States [16][16]interface{}
+}
+
+func encodeGob(value interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeGob(data []byte, value interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
+
+// blocks are sent by their registered names, since numeric block types may differ between versions
+func encodeBlock(e *encoder, block types.Block) error {
+	state, err := encodeGob(wireState{State: block.State()})
+	if err != nil {
+		return err
+	}
+	e.writeString(blocks.Name(block.Type()))
+	e.writeBytes(state)
+	return nil
+}
+
+func decodeBlock(d *decoder) (types.Block, error) {
+	name := d.readString()
+	data := d.readBytes()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	block, exists := blocks.GetBlockByName(name)
+	if !exists {
+		return nil, fmt.Errorf("unknown block %q", name)
+	}
+	state := wireState{}
+	if err := decodeGob(data, &state); err != nil {
+		return nil, err
+	}
+	if err := block.LoadState(state.State); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func encodeBlockChange(x, y uint64, block types.Block) (message, error) {
+	e := new(encoder)
+	e.write(x, y)
+	if err := encodeBlock(e, block); err != nil {
+		return message{}, err
+	}
+	return e.message(msgBlockChange), nil
+}
+
+func decodeBlockChange(payload []byte) (world.BlockChange, error) {
+	change := world.BlockChange{}
+	d := newDecoder(payload)
+	d.read(&change.X, &change.Y)
+	block, err := decodeBlock(d)
+	if err != nil {
+		return change, err
+	}
+	change.Block = block
+	return change, nil
+}
+
+func encodeEntity(id uint32, entity types.Entity) (message, error) {
+	state, err := encodeGob(wireState{State: entity.State()})
+	if err != nil {
+		return message{}, err
+	}
+	e := new(encoder)
+	e.write(id)
+	e.writeString(string(entity.Type()))
+	e.writeBytes(state)
+	return e.message(msgEntitySpawn), nil
+}
+
+func decodeEntity(payload []byte) (world.EntityUpdate, error) {
+	update := world.EntityUpdate{}
+	d := newDecoder(payload)
+	d.read(&update.ID)
+	entityType := types.EntityType(d.readString())
+	data := d.readBytes()
+	if d.err != nil {
+		return update, d.err
+	}
+
+	state := wireState{}
+	if err := decodeGob(data, &state); err != nil {
+		return update, err
+	}
+	entity, err := world.NewEntity(entityType, state.State)
+	if err != nil {
+		return update, err
+	}
+	update.Entity = entity
+	update.Position = entity.Position()
+	return update, nil
+}
+
+// Chunks carry their own palette of block names, so each block takes only two bytes, plus its state
+func encodeChunk(chunk *world.Chunk) (message, error) {
+	var (
+		names   []string
+		ids     = make(map[types.BlockType]uint16)
+		palette [16][16]uint16
+		states  wireChunkStates
+	)
+	for x := uint(0); x < 16; x++ {
+		for y := uint(0); y < 16; y++ {
+			block := chunk.At(x, y)
+			id, exists := ids[block.Type()]
+			if !exists {
+				id = uint16(len(names))
+				ids[block.Type()] = id
+				names = append(names, blocks.Name(block.Type()))
+			}
+			palette[x][y] = id
+			states.States[x][y] = block.State()
+		}
+	}
+
+	// all states are encoded at once, so gob sends each type description only once
+	encodedStates, err := encodeGob(states)
+	if err != nil {
+		return message{}, err
+	}
+
+	e := new(encoder)
+	e.write(chunk.Coords().X, chunk.Coords().Y, uint16(len(names)))
+	for _, name := range names {
+		e.writeString(name)
+	}
+	e.write(palette)
+	e.writeBytes(encodedStates)
+	return e.message(msgChunk), nil
+}
+
+func decodeChunk(payload []byte) (*world.Chunk, error) {
+	var (
+		cx, cy  uint64
+		count   uint16
+		palette [16][16]uint16
+		states  wireChunkStates
+	)
+
+	d := newDecoder(payload)
+	d.read(&cx, &cy, &count)
+	names := make([]string, 0, count)
+	for i := uint16(0); i < count && d.err == nil; i++ {
+		names = append(names, d.readString())
+	}
+	d.read(&palette)
+	encodedStates := d.readBytes()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if err := decodeGob(encodedStates, &states); err != nil {
+		return nil, err
+	}
+
+	chunk := world.NewChunk(cx, cy)
+	for x := uint(0); x < 16; x++ {
+		for y := uint(0); y < 16; y++ {
+			id := palette[x][y]
+			if int(id) >= len(names) {
+				return nil, fmt.Errorf("chunk %v, %v - block ID %v is out of the palette", cx, cy, id)
+			}
+			block, exists := blocks.GetBlockByName(names[id])
+			if !exists {
+				return nil, fmt.Errorf("chunk %v, %v - unknown block %q", cx, cy, names[id])
+			}
+			if err := block.LoadState(states.States[x][y]); err != nil {
+				return nil, fmt.Errorf("chunk %v, %v - %w", cx, cy, err)
+			}
+			chunk.SetBlock(x, y, block)
+		}
+	}
+	return chunk, nil
+}
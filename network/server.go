@@ -0,0 +1,851 @@
+// Multiplayer server
+//
+// The server owns the world, along with its generator and SaverLoader, and is the only one to modify it.
+// Clients request chunks, and tell which blocks the players place and break.
+// The server checks, that the player can reach the block, has the item to place, and has been breaking the block
+// for long enough, applies the change, and sends it to all clients.
+// Players are moved by their clients, the server only checks, that they don't move faster than they can.
+// Mobs are simulated by the server, which sends them to the players nearby, along with the damage they deal.
+// The world is updated on a single goroutine, so messages from the clients are queued, and handled on each tick.
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/clock"
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/event"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world"
+)
+
+const (
+	// Chunks around the players are kept loaded on the server, so mobs and blocks around them are updated
+	ViewDistance int64 = 2
+	// The world time is sent to the clients once in that many ticks
+	timeSyncDelay uint64 = 60
+	// A client, that doesn't read the messages fast enough, is disconnected, when its queue fills up
+	outgoingQueueSize = 1024
+	handshakeTimeout  = 5 * time.Second
+	// How far from the player, in blocks, the blocks can be placed and broken.
+	// The player itself reaches only the neighbor blocks, the rest is for the latency
+	maxReach = 3
+	// How far from the player, in chunks, the clients can request chunks.
+	// It is a bit more, than what the game can show on a large screen
+	maxChunkDistance int64 = 8
+	// How far from the player, in blocks, the entities are sent to the client
+	entityViewDistance = 64
+	// Messages of a client arrive in bursts, so a block can be broken that many ticks earlier, than the server expects
+	breakSlackTicks = 10
+
+	// The fastest a player can move, in blocks per tick, sprinting diagonally.
+	// The velocity grows by the acceleration each tick, and loses a quarter of it,
+	// so the player moves at most 4 times the acceleration along each axis
+	maxPlayerSpeed = config.PlayerSpeed * config.PlayerSprintMultiplier * 4 * math.Sqrt2
+	// Moves are checked against an allowance, that grows with the ticks.
+	// It is capped at that many ticks, so the moves, that arrive at once after a lag, aren't rejected
+	moveAllowanceTicks = 60
+)
+
+// session is a client, connected to the server
+type session struct {
+	id   uint32
+	name string
+	conn net.Conn
+
+	// the player in the server world
+	player *RemotePlayer
+	// how far the player can move, before its moves are rejected, and the tick of the last move
+	allowance float64
+	lastMove  uint64
+
+	items itemCounts
+	// the block, the player has started to break, and the tick, when it started
+	breaking      types.Vec2u
+	breakingSince uint64
+	isBreaking    bool
+
+	// entities, the client knows about, and their positions, last sent to it
+	entities map[uint32]types.Vec2f
+
+	// messages are written by a separate goroutine, so a slow client doesn't stall the server
+	outgoing chan message
+}
+
+// send drops the client, if its queue is full
+func (s *session) send(msg message) {
+	select {
+	case s.outgoing <- msg:
+	default:
+		log.Printf("Server - client %v (%v) is too slow, disconnecting", s.id, s.name)
+		s.conn.Close()
+	}
+}
+
+// reaches returns false, if the block is too far from the player
+func (s *session) reaches(x, y uint64) bool {
+	pos := s.player.Position()
+	dx, dy := float64(x)+0.5-pos.X, float64(y)+0.5-pos.Y
+	return dx*dx+dy*dy <= maxReach*maxReach
+}
+
+func (s *session) runWriter() {
+	for msg := range s.outgoing {
+		if err := writeMessage(s.conn, msg); err != nil {
+			// the reader will notice the closed connection, and remove the client
+			s.conn.Close()
+			return
+		}
+	}
+}
+
+// a message, received from the client
+// msgHello and msgDisconnect are used to notify the server goroutine, that the client joined or left
+type clientMessage struct {
+	session *session
+	message
+}
+
+type Server struct {
+	world    *world.World
+	listener net.Listener
+
+	incoming chan clientMessage
+
+	// accessed only from the server goroutine
+	sessions map[uint32]*session
+	// clients, waiting for the chunks, that are still being generated or loaded
+	pendingChunks map[types.Vec2u][]*session
+	ticks         uint64
+	// set for the dedicated server, which has no scene manager to advance the clock
+	dedicated bool
+	// IDs of the entities, sent to the clients. Entities, that are gone from the world, are forgotten
+	entityIDs    map[types.Entity]uint32
+	nextEntityID uint32
+	// picked on the first join
+	spawn *types.Vec2f
+	// where the players were, when they left, and what they had, so they continue from there on the next join
+	positions   map[string]types.Vec2f
+	inventories map[string]itemCounts
+
+	// IDs are assigned by the goroutines, accepting connections
+	idMutex sync.Mutex
+	nextID  uint32
+
+	closeOnce sync.Once
+	// closed to stop the server goroutine
+	done chan struct{}
+	// closed, when the server goroutine has saved the world, and exited
+	stopped chan struct{}
+}
+
+func NewServer(w *world.World) *Server {
+	return &Server{
+		world:         w,
+		incoming:      make(chan clientMessage, 1024),
+		sessions:      make(map[uint32]*session),
+		pendingChunks: make(map[types.Vec2u][]*session),
+		positions:     make(map[string]types.Vec2f),
+		inventories:   make(map[string]itemCounts),
+		entityIDs:     make(map[types.Entity]uint32),
+		nextEntityID:  1,
+		nextID:        1,
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// NewDedicatedServer creates a server, that runs without the game, e.g. in cmd/bamboo-server.
+// The server advances the clock itself, so that the chunks are unloaded
+func NewDedicatedServer(w *world.World) *Server {
+	server := NewServer(w)
+	server.dedicated = true
+	return server
+}
+
+// SetPlayerPosition places the player with the given name at the position, when it joins.
+// Must be called before Listen
+func (server *Server) SetPlayerPosition(name string, pos types.Vec2f) {
+	server.positions[name] = pos
+}
+
+// SetPlayerItems gives the items to the player with the given name, when it joins, see itemCounts.
+// Must be called before Listen
+func (server *Server) SetPlayerItems(name string, counts map[string]int) {
+	server.inventories[name] = counts
+}
+
+// Listen starts accepting the clients on the given address, and starts updating the world
+func (server *Server) Listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	server.listener = listener
+	log.Printf("Server - listening on %v", listener.Addr())
+
+	go server.acceptLoop()
+	go server.run()
+	return nil
+}
+
+// Addr returns the address, the server is listening on
+func (server *Server) Addr() net.Addr {
+	return server.listener.Addr()
+}
+
+func (server *Server) World() *world.World {
+	return server.world
+}
+
+// Close disconnects all clients, saves the world, and closes its files.
+// It returns after the world is closed
+func (server *Server) Close() {
+	server.stop()
+	<-server.stopped
+}
+
+// stop tells the server goroutine to shut down, without waiting for it
+func (server *Server) stop() {
+	server.closeOnce.Do(func() {
+		server.listener.Close()
+		close(server.done)
+	})
+}
+
+// Done is closed, when the server has stopped, either by Close(), or because the world can't be used anymore
+func (server *Server) Done() <-chan struct{} {
+	return server.stopped
+}
+
+func (server *Server) acceptLoop() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Server.acceptLoop() - %v", err)
+			}
+			return
+		}
+		go server.handleConnection(conn)
+	}
+}
+
+// handshake reads the hello message, and returns the player name
+func handshake(conn net.Conn) (string, error) {
+	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	msg, err := readMessage(conn)
+	if err != nil {
+		return "", err
+	}
+	if msg.kind != msgHello {
+		return "", fmt.Errorf("expected hello message, got %v", msg.kind)
+	}
+
+	var version uint16
+	d := newDecoder(msg.payload)
+	d.read(&version)
+	name := d.readString()
+	if d.err != nil {
+		return "", d.err
+	}
+	if version != ProtocolVersion {
+		return "", fmt.Errorf("protocol version %v, expected %v", version, ProtocolVersion)
+	}
+	return name, nil
+}
+
+// refuse tells the client, why it can't join, and closes the connection
+func refuse(conn net.Conn, reason string) {
+	e := new(encoder)
+	e.writeString(reason)
+	writeMessage(conn, e.message(msgDisconnect))
+	conn.Close()
+}
+
+// handleConnection reads the messages from the client, and passes them to the server goroutine
+func (server *Server) handleConnection(conn net.Conn) {
+	name, err := handshake(conn)
+	if err != nil {
+		log.Printf("Server - handshake with %v failed - %v", conn.RemoteAddr(), err)
+		refuse(conn, err.Error())
+		return
+	}
+
+	server.idMutex.Lock()
+	id := server.nextID
+	server.nextID++
+	server.idMutex.Unlock()
+
+	s := &session{
+		id:       id,
+		name:     name,
+		conn:     conn,
+		outgoing: make(chan message, outgoingQueueSize),
+	}
+	go s.runWriter()
+
+	// the messages of the client are passed through the same queue as its join and leave,
+	// so they are always handled in order
+	if !server.queue(clientMessage{session: s, message: message{kind: msgHello}}) {
+		conn.Close()
+		return
+	}
+	for {
+		msg, err := readMessage(conn)
+		if err != nil {
+			log.Printf("Server - client %v (%v) disconnected - %v", s.id, s.name, err)
+			server.queue(clientMessage{session: s, message: message{kind: msgDisconnect}})
+			return
+		}
+		if !server.queue(clientMessage{session: s, message: msg}) {
+			return
+		}
+	}
+}
+
+// queue returns false, if the server is shutting down
+func (server *Server) queue(msg clientMessage) bool {
+	select {
+	case server.incoming <- msg:
+		return true
+	case <-server.done:
+		return false
+	}
+}
+
+// run is the server goroutine, it updates the world at 60 ticks per second
+func (server *Server) run() {
+	defer close(server.stopped)
+
+	ticker := time.NewTicker(time.Second / 60)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-server.done:
+			server.shutdown()
+			return
+		case <-ticker.C:
+			server.tick()
+			if err := server.world.Err(); err != nil {
+				log.Printf("Server - can't continue in this world - %v", err)
+				server.stop()
+			}
+		}
+	}
+}
+
+func (server *Server) tick() {
+	// handle everything, that was received since the last tick
+	for handled := false; !handled; {
+		select {
+		case msg := <-server.incoming:
+			server.handle(msg)
+		default:
+			handled = true
+		}
+	}
+
+	if server.dedicated {
+		clock.Advance()
+	}
+	server.world.Update()
+	server.sendPendingChunks()
+	server.sendEntities()
+	server.sendDamage()
+
+	server.ticks++
+	if server.ticks%timeSyncDelay == 0 {
+		server.keepChunksLoaded()
+		e := new(encoder)
+		e.write(uint64(server.world.Time()))
+		server.broadcast(e.message(msgTime), 0)
+	}
+	if server.ticks%config.WorldAutosaveDelay == 0 {
+		server.world.Save()
+	}
+}
+
+func (server *Server) shutdown() {
+	log.Println("Server - shutting down")
+	for _, s := range server.sessions {
+		refuse(s.conn, "Server closed")
+		close(s.outgoing)
+	}
+	server.world.Close()
+}
+
+// broadcast sends the message to all clients, except the given one. 0 means no exception
+func (server *Server) broadcast(msg message, except uint32) {
+	for id, s := range server.sessions {
+		if id != except {
+			s.send(msg)
+		}
+	}
+}
+
+// handle is called on the server goroutine for each message from the clients
+func (server *Server) handle(msg clientMessage) {
+	s := msg.session
+	switch msg.kind {
+	case msgHello:
+		server.join(s)
+		return
+	case msgDisconnect:
+		server.leave(s)
+		return
+	}
+
+	// messages, that can still be in the queue after the client was dropped
+	if _, exists := server.sessions[s.id]; !exists {
+		return
+	}
+
+	var err error
+	switch msg.kind {
+	case msgChunkRequest:
+		err = server.handleChunkRequest(s, msg.payload)
+	case msgPlaceBlock:
+		err = server.handlePlaceBlock(s, msg.payload)
+	case msgStartBreaking:
+		err = server.handleStartBreaking(s, msg.payload)
+	case msgBreakBlock:
+		err = server.handleBreakBlock(s, msg.payload)
+	case msgCraft:
+		err = server.handleCraft(s, msg.payload)
+	case msgPlayerMove:
+		err = server.handlePlayerMove(s, msg.payload)
+	case msgRespawn:
+		server.respawn(s)
+	case msgChat:
+		err = server.handleChat(s, msg.payload)
+	default:
+		err = fmt.Errorf("unexpected message type %v", msg.kind)
+	}
+
+	if err != nil {
+		log.Printf("Server - invalid message from client %v (%v) - %v", s.id, s.name, err)
+		s.conn.Close()
+	}
+}
+
+func (server *Server) join(s *session) {
+	log.Printf("Server - client %v (%v) joined from %v", s.id, s.name, s.conn.RemoteAddr())
+
+	pos, exists := server.positions[s.name]
+	if !exists {
+		pos = server.spawnPoint()
+	}
+	s.player = NewRemotePlayer(s.id, s.name, pos)
+	s.items = server.inventories[s.name]
+	if s.items == nil {
+		s.items = make(itemCounts)
+	}
+	s.lastMove = server.ticks
+	s.entities = make(map[uint32]types.Vec2f)
+	server.world.AddEntity(s.player)
+
+	metadata := server.world.Metadata()
+	e := new(encoder)
+	e.write(s.id, metadata.Seed, uint8(metadata.WorldType), uint64(metadata.Time), metadata.BaseUUID, metadata.UUID)
+	e.writeString(metadata.Name)
+	e.write(pos.X, pos.Y)
+	s.send(e.message(msgWelcome))
+
+	// tell the new client about the others, and the others about it
+	for _, other := range server.sessions {
+		s.send(playerJoinedMessage(other))
+		s.send(playerMoveMessage(other))
+	}
+
+	server.sessions[s.id] = s
+	server.broadcast(playerJoinedMessage(s), s.id)
+	server.broadcast(playerMoveMessage(s), s.id)
+}
+
+func (server *Server) spawnPoint() types.Vec2f {
+	if server.spawn == nil {
+		spawn := world.SpawnPoint(server.world)
+		server.spawn = &spawn
+	}
+	return *server.spawn
+}
+
+func (server *Server) leave(s *session) {
+	if _, exists := server.sessions[s.id]; !exists {
+		return
+	}
+	delete(server.sessions, s.id)
+	close(s.outgoing)
+	s.conn.Close()
+
+	server.world.RemoveEntity(s.player)
+	server.positions[s.name] = s.player.Position()
+	server.inventories[s.name] = s.items
+	for coords, waiting := range server.pendingChunks {
+		for i := 0; i < len(waiting); i++ {
+			if waiting[i] == s {
+				waiting = append(waiting[:i], waiting[i+1:]...)
+				i--
+			}
+		}
+		server.pendingChunks[coords] = waiting
+	}
+
+	e := new(encoder)
+	e.write(s.id)
+	server.broadcast(e.message(msgPlayerLeft), 0)
+}
+
+func playerJoinedMessage(s *session) message {
+	e := new(encoder)
+	e.write(s.id)
+	e.writeString(s.name)
+	return e.message(msgPlayerJoined)
+}
+
+func playerMoveMessage(s *session) message {
+	pos := s.player.Position()
+	e := new(encoder)
+	e.write(s.id, pos.X, pos.Y)
+	return e.message(msgPlayerMove)
+}
+
+func validBlockCoords(x, y uint64) bool {
+	return x < config.WorldWidth && y < config.WorldHeight
+}
+
+func (server *Server) handleChunkRequest(s *session, payload []byte) error {
+	var cx, cy uint64
+	d := newDecoder(payload)
+	d.read(&cx, &cy)
+	if d.err != nil {
+		return d.err
+	}
+	// clients render a bit beyond the world border, those chunks stay dummy for them
+	if !validBlockCoords(cx*16, cy*16) {
+		return nil
+	}
+
+	// the client requests the chunk again, once the player comes closer
+	pos := s.player.Position()
+	dx, dy := int64(cx)-int64(pos.X)/16, int64(cy)-int64(pos.Y)/16
+	if dx < -maxChunkDistance || dx > maxChunkDistance || dy < -maxChunkDistance || dy > maxChunkDistance {
+		e := new(encoder)
+		e.write(cx, cy)
+		s.send(e.message(msgChunkDenied))
+		return nil
+	}
+
+	coords := types.Vec2u{X: cx, Y: cy}
+	for _, waiting := range server.pendingChunks[coords] {
+		if waiting == s {
+			return nil
+		}
+	}
+	server.pendingChunks[coords] = append(server.pendingChunks[coords], s)
+	return nil
+}
+
+// sendPendingChunks sends the chunks, that are ready, to the clients, that requested them
+func (server *Server) sendPendingChunks() {
+	for coords, waiting := range server.pendingChunks {
+		chunk, loaded := server.world.LoadedChunk(coords.X, coords.Y)
+		if !loaded {
+			continue
+		}
+		delete(server.pendingChunks, coords)
+
+		msg, err := encodeChunk(chunk)
+		if err != nil {
+			log.Printf("Server - failed to encode chunk %v, %v - %v", coords.X, coords.Y, err)
+			continue
+		}
+		for _, s := range waiting {
+			s.send(msg)
+		}
+	}
+}
+
+// sendEntities tells the clients about the entities around their players, which have appeared, moved, or are gone.
+// Players aren't saved with the chunks, and are sent separately, see msgPlayerMove
+func (server *Server) sendEntities() {
+	seen := make(map[types.Entity]bool)
+
+	for _, s := range server.sessions {
+		visible := make(map[uint32]bool)
+		for _, entity := range server.world.EntitiesAround(s.player.Position(), entityViewDistance) {
+			if !world.EntityRegistered(entity.Type()) {
+				continue
+			}
+			seen[entity] = true
+			id := server.entityID(entity)
+			visible[id] = true
+
+			pos := entity.Position()
+			last, known := s.entities[id]
+			switch {
+			case !known:
+				msg, err := encodeEntity(id, entity)
+				if err != nil {
+					log.Printf("Server - failed to encode entity %v - %v", entity.Type(), err)
+					continue
+				}
+				s.send(msg)
+			case last != pos:
+				e := new(encoder)
+				e.write(id, pos.X, pos.Y)
+				s.send(e.message(msgEntityMove))
+			}
+			s.entities[id] = pos
+		}
+
+		for id := range s.entities {
+			if !visible[id] {
+				e := new(encoder)
+				e.write(id)
+				s.send(e.message(msgEntityDespawn))
+				delete(s.entities, id)
+			}
+		}
+	}
+
+	for entity := range server.entityIDs {
+		if !seen[entity] {
+			delete(server.entityIDs, entity)
+		}
+	}
+}
+
+func (server *Server) entityID(entity types.Entity) uint32 {
+	id, exists := server.entityIDs[entity]
+	if !exists {
+		id = server.nextEntityID
+		server.nextEntityID++
+		server.entityIDs[entity] = id
+	}
+	return id
+}
+
+// sendDamage passes the damage, dealt to the players by the mobs, to their clients
+func (server *Server) sendDamage() {
+	for _, s := range server.sessions {
+		if s.player.damage == 0 {
+			continue
+		}
+		e := new(encoder)
+		e.write(s.player.damage)
+		s.send(e.message(msgDamage))
+		s.player.damage = 0
+	}
+}
+
+// the chunk must be loaded on the server, to check the change
+func (server *Server) blockLoaded(x, y uint64) bool {
+	if !validBlockCoords(x, y) {
+		return false
+	}
+	_, loaded := server.world.LoadedChunk(x/16, y/16)
+	return loaded
+}
+
+func (server *Server) handlePlaceBlock(s *session, payload []byte) error {
+	var x, y uint64
+	d := newDecoder(payload)
+	d.read(&x, &y)
+	name := d.readString()
+	if d.err != nil {
+		return d.err
+	}
+	block, exists := blocks.GetBlockByName(name)
+	if !exists {
+		return fmt.Errorf("unknown block %q", name)
+	}
+	if !server.blockLoaded(x, y) {
+		return nil
+	}
+
+	if !s.reaches(x, y) || s.items[name] <= 0 || server.world.BlockAt(x, y).Type() == block.Type() {
+		return server.rejectBlockChange(s, x, y)
+	}
+	// the scripts on the server can cancel the change, as in singleplayer
+	pos := types.Vec2u{X: x, Y: y}
+	if !event.Fire(server.world.Events(), &types.BlockPlacedEvent{Pos: pos, Block: block}) {
+		return server.rejectBlockChange(s, x, y)
+	}
+	server.world.PlaceBlock(x, y, block)
+	return server.broadcastBlock(x, y)
+}
+
+func (server *Server) handleBreakBlock(s *session, payload []byte) error {
+	var x, y uint64
+	d := newDecoder(payload)
+	d.read(&x, &y)
+	if d.err != nil {
+		return d.err
+	}
+	if !server.blockLoaded(x, y) {
+		return nil
+	}
+
+	pos := types.Vec2u{X: x, Y: y}
+	block, _, breakable := server.world.BreakableAt(x, y)
+	if !breakable || !s.reaches(x, y) || !s.isBreaking || s.breaking != pos {
+		return server.rejectBlockChange(s, x, y)
+	}
+	s.isBreaking = false
+	// hardness is in seconds, and there are 60 ticks in a second
+	elapsed := server.ticks - s.breakingSince + breakSlackTicks
+	if float64(elapsed) < block.Hardness()*60/s.items.miningSpeed(block.Category()) {
+		return server.rejectBlockChange(s, x, y)
+	}
+	if !event.Fire(server.world.Events(), &types.BlockBrokenEvent{Pos: pos, Block: block}) {
+		return server.rejectBlockChange(s, x, y)
+	}
+	server.world.BreakBlock(x, y)
+
+	drops := block.Drops()
+	s.items.add(drops)
+	e := new(encoder)
+	e.write(uint16(len(drops)))
+	for _, name := range drops {
+		e.writeString(name)
+	}
+	s.send(e.message(msgDrops))
+	return server.broadcastBlock(x, y)
+}
+
+func (server *Server) handleStartBreaking(s *session, payload []byte) error {
+	var pos types.Vec2u
+	d := newDecoder(payload)
+	d.read(&pos.X, &pos.Y)
+	if d.err != nil {
+		return d.err
+	}
+	s.breaking = pos
+	s.breakingSince = server.ticks
+	s.isBreaking = true
+	return nil
+}
+
+func (server *Server) handleCraft(s *session, payload []byte) error {
+	d := newDecoder(payload)
+	recipe := d.readString()
+	if d.err != nil {
+		return d.err
+	}
+	// the result isn't counted, so the player can't place it
+	if !s.items.craft(recipe) {
+		log.Printf("Server - client %v (%v) can't craft %v", s.id, s.name, recipe)
+	}
+	return nil
+}
+
+// rejectBlockChange sends the block back to the client, which has already changed it on its side
+func (server *Server) rejectBlockChange(s *session, x, y uint64) error {
+	msg, err := encodeBlockChange(x, y, server.world.BlockAt(x, y))
+	if err != nil {
+		return err
+	}
+	s.send(msg)
+	return nil
+}
+
+// broadcastBlock sends the block to all clients.
+// The client, that changed it, gets it back as well, so everyone ends up with the same state
+func (server *Server) broadcastBlock(x, y uint64) error {
+	msg, err := encodeBlockChange(x, y, server.world.BlockAt(x, y))
+	if err != nil {
+		return err
+	}
+	server.broadcast(msg, 0)
+	return nil
+}
+
+func (server *Server) handlePlayerMove(s *session, payload []byte) error {
+	var pos types.Vec2f
+	d := newDecoder(payload)
+	d.read(&pos.X, &pos.Y)
+	if d.err != nil {
+		return d.err
+	}
+	// written this way, so NaN doesn't pass
+	if !(pos.X >= 0 && pos.X < float64(config.WorldWidth) && pos.Y >= 0 && pos.Y < float64(config.WorldHeight)) {
+		return fmt.Errorf("position %v, %v is outside of the world", pos.X, pos.Y)
+	}
+
+	elapsed := server.ticks - s.lastMove
+	s.lastMove = server.ticks
+	s.allowance = math.Min(s.allowance+float64(elapsed)*maxPlayerSpeed, moveAllowanceTicks*maxPlayerSpeed)
+
+	from := s.player.Position()
+	distance := math.Hypot(pos.X-from.X, pos.Y-from.Y)
+	if distance > s.allowance {
+		// the client keeps the player where the server has it
+		e := new(encoder)
+		e.write(from.X, from.Y)
+		s.send(e.message(msgPlayerPosition))
+		return nil
+	}
+	s.allowance -= distance
+
+	s.player.SetPosition(pos)
+	server.broadcast(playerMoveMessage(s), s.id)
+	return nil
+}
+
+// respawn moves the player to the spawn point.
+// The client keeps the health of the player, so the server trusts it, that the player has died
+func (server *Server) respawn(s *session) {
+	s.player.SetPosition(server.spawnPoint())
+	s.allowance = 0
+	server.broadcast(playerMoveMessage(s), s.id)
+}
+
+func (server *Server) handleChat(s *session, payload []byte) error {
+	d := newDecoder(payload)
+	text := strings.TrimSpace(d.readString())
+	if d.err != nil {
+		return d.err
+	}
+	if len(text) > MaxChatLength {
+		return fmt.Errorf("chat message is %v bytes long", len(text))
+	}
+	if text == "" {
+		return nil
+	}
+	log.Printf("Server - <%v> %v", s.name, text)
+
+	e := new(encoder)
+	e.writeString(s.name)
+	e.writeString(text)
+	server.broadcast(e.message(msgChat), 0)
+	return nil
+}
+
+// keepChunksLoaded touches the chunks around the players, so they aren't unloaded
+func (server *Server) keepChunksLoaded() {
+	for _, s := range server.sessions {
+		pos := s.player.Position()
+		cx, cy := int64(pos.X)/16, int64(pos.Y)/16
+		for x := cx - ViewDistance; x <= cx+ViewDistance; x++ {
+			for y := cy - ViewDistance; y <= cy+ViewDistance; y++ {
+				if x >= 0 && y >= 0 && validBlockCoords(uint64(x)*16, uint64(y)*16) {
+					server.world.ChunkAt(uint64(x), uint64(y))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,246 @@
+/*
+	A* pathfinding over the block grid.
+
+	Searches are incremental: Step() expands a limited number of nodes,
+	and all searches together share a fixed budget of nodes per tick,
+	so even many searches at once never stall the world update.
+*/
+
+package pathfinding
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+
+	"github.com/3elDU/bamboo/clock"
+	"github.com/3elDU/bamboo/types"
+)
+
+const (
+	// How many nodes all searches together may expand in a single tick
+	MaxNodesPerTick = 2000
+	// Default limit of nodes for a single search, after which it gives up
+	DefaultMaxNodes = 4000
+)
+
+type Status int
+
+const (
+	InProgress Status = iota
+	Found
+	// The goal is unreachable, or the search exceeded its node limit
+	NotFound
+)
+
+// budget of nodes, left for the current tick.
+// Searches run both on the server goroutine and on the game's one, so it is guarded by the mutex
+var budget struct {
+	sync.Mutex
+	tick uint64
+	left int
+}
+
+// takes up to n nodes from the budget of the current tick
+func take(n int) int {
+	budget.Lock()
+	defer budget.Unlock()
+
+	if tick := clock.Ticks(); tick != budget.tick {
+		budget.tick = tick
+		budget.left = MaxNodesPerTick
+	}
+	if n > budget.left {
+		n = budget.left
+	}
+	budget.left -= n
+	return n
+}
+
+// Cost returns the cost of walking through the block, or false if the block can't be walked through
+// Blocks in unloaded chunks are never walkable, since it is unknown what's there
+func Cost(world types.World, x, y uint64) (float64, bool) {
+	if !world.ChunkExists(x/16, y/16) {
+		return 0, false
+	}
+
+	block, ok := world.BlockAt(x, y).(types.CollidableBlock)
+	if !ok {
+		return 1, true
+	}
+	if block.Collidable() || block.PlayerSpeed() <= 0 {
+		return 0, false
+	}
+	// slower blocks are more expensive to cross, e.g. water with speed 0.2 costs as much as 5 grass blocks
+	return 1 / block.PlayerSpeed(), true
+}
+
+type node struct {
+	pos types.Vec2u
+	// cost from the start, and estimated total cost through this node
+	cost, estimate float64
+	index          int
+}
+
+type openSet []*node
+
+func (s openSet) Len() int           { return len(s) }
+func (s openSet) Less(i, j int) bool { return s[i].estimate < s[j].estimate }
+func (s openSet) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+	s[i].index, s[j].index = i, j
+}
+func (s *openSet) Push(x interface{}) {
+	n := x.(*node)
+	n.index = len(*s)
+	*s = append(*s, n)
+}
+func (s *openSet) Pop() interface{} {
+	old := *s
+	n := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return n
+}
+
+// Search is a single path search, from start to goal
+type Search struct {
+	world       types.World
+	start, goal types.Vec2u
+	maxNodes    int
+
+	open     openSet
+	nodes    map[types.Vec2u]*node
+	closed   map[types.Vec2u]bool
+	cameFrom map[types.Vec2u]types.Vec2u
+	expanded int
+
+	status Status
+	path   []types.Vec2u
+}
+
+// NewSearch starts a search of the path between two blocks
+// maxNodes limits how many nodes the search can expand in total, before it gives up
+func NewSearch(world types.World, start, goal types.Vec2u, maxNodes int) *Search {
+	s := &Search{
+		world: world,
+		start: start, goal: goal,
+		maxNodes: maxNodes,
+		nodes:    make(map[types.Vec2u]*node),
+		closed:   make(map[types.Vec2u]bool),
+		cameFrom: make(map[types.Vec2u]types.Vec2u),
+	}
+
+	if _, walkable := Cost(world, goal.X, goal.Y); !walkable {
+		s.status = NotFound
+		return s
+	}
+
+	startNode := &node{pos: start, estimate: heuristic(start, goal)}
+	s.nodes[start] = startNode
+	heap.Push(&s.open, startNode)
+	return s
+}
+
+// octile distance, the exact cost of the path on an empty grid with diagonal moves
+func heuristic(a, b types.Vec2u) float64 {
+	dx := math.Abs(float64(a.X) - float64(b.X))
+	dy := math.Abs(float64(a.Y) - float64(b.Y))
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+var directions = [8]types.Vec2i{
+	{X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: -1}, {X: 0, Y: 1},
+	{X: -1, Y: -1}, {X: 1, Y: -1}, {X: -1, Y: 1}, {X: 1, Y: 1},
+}
+
+func (s *Search) walkable(x, y uint64) bool {
+	_, ok := Cost(s.world, x, y)
+	return ok
+}
+
+// Step expands up to n nodes, limited by the budget of the current tick
+// Returns the status of the search, the path is available through Path(), once it is found
+func (s *Search) Step(n int) Status {
+	if s.status != InProgress {
+		return s.status
+	}
+
+	for n = take(n); n > 0; n-- {
+		if s.open.Len() == 0 || s.expanded >= s.maxNodes {
+			s.status = NotFound
+			return s.status
+		}
+
+		current := heap.Pop(&s.open).(*node)
+		if current.pos == s.goal {
+			s.status = Found
+			s.path = s.reconstruct()
+			return s.status
+		}
+		s.closed[current.pos] = true
+		s.expanded++
+
+		for _, dir := range directions {
+			x, y := current.pos.X+uint64(dir.X), current.pos.Y+uint64(dir.Y)
+			neighbor := types.Vec2u{X: x, Y: y}
+			if s.closed[neighbor] {
+				continue
+			}
+
+			cost, walkable := Cost(s.world, x, y)
+			if !walkable {
+				continue
+			}
+			distance := 1.0
+			if dir.X != 0 && dir.Y != 0 {
+				// don't cut corners of the solid blocks
+				if !s.walkable(current.pos.X+uint64(dir.X), current.pos.Y) || !s.walkable(current.pos.X, current.pos.Y+uint64(dir.Y)) {
+					continue
+				}
+				distance = math.Sqrt2
+			}
+
+			newCost := current.cost + distance*cost
+			existing, seen := s.nodes[neighbor]
+			if seen && newCost >= existing.cost {
+				continue
+			}
+
+			s.cameFrom[neighbor] = current.pos
+			if seen {
+				existing.cost = newCost
+				existing.estimate = newCost + heuristic(neighbor, s.goal)
+				heap.Fix(&s.open, existing.index)
+			} else {
+				next := &node{pos: neighbor, cost: newCost, estimate: newCost + heuristic(neighbor, s.goal)}
+				s.nodes[neighbor] = next
+				heap.Push(&s.open, next)
+			}
+		}
+	}
+
+	return s.status
+}
+
+func (s *Search) reconstruct() []types.Vec2u {
+	path := []types.Vec2u{s.goal}
+	for pos := s.goal; pos != s.start; {
+		pos = s.cameFrom[pos]
+		path = append(path, pos)
+	}
+	// reverse, so the path goes from start to goal
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func (s *Search) Status() Status {
+	return s.status
+}
+
+// Path returns blocks from start to goal, including both of them
+// Returns nil, until the path is found
+func (s *Search) Path() []types.Vec2u {
+	return s.path
+}
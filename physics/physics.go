@@ -0,0 +1,115 @@
+/*
+	Collision of entities with the blocks.
+	Used by the player and the mobs alike
+*/
+
+package physics
+
+import (
+	"github.com/3elDU/bamboo/types"
+)
+
+// Hitbox of the entity, relative to its position
+// Collision is checked at the four corners of the hitbox
+type Hitbox struct {
+	Left, Top, Right, Bottom float64
+}
+
+// corners go in order: top-left, top-right, bottom-left, bottom-right
+func (h Hitbox) Points(origin types.Vec2f) [4]types.Vec2f {
+	return [4]types.Vec2f{
+		{X: origin.X - h.Left, Y: origin.Y - h.Top},
+		{X: origin.X + h.Right, Y: origin.Y - h.Top},
+		{X: origin.X - h.Left, Y: origin.Y + h.Bottom},
+		{X: origin.X + h.Right, Y: origin.Y + h.Bottom},
+	}
+}
+
+// Collision points for each block are specified in local space ( e.g. relative to the block itself ),
+// so for collision to work we need to convert them to global space first
+func ConvertToGlobalSpace(block types.Block, points [4]types.Vec2f) [4]types.Vec2f {
+	return [4]types.Vec2f{
+		{X: points[0].X + float64(block.Coords().X), Y: points[0].Y + float64(block.Coords().Y)},
+		{X: points[1].X + float64(block.Coords().X), Y: points[1].Y + float64(block.Coords().Y)},
+		{X: points[2].X + float64(block.Coords().X), Y: points[2].Y + float64(block.Coords().Y)},
+		{X: points[3].X + float64(block.Coords().X), Y: points[3].Y + float64(block.Coords().Y)},
+	}
+}
+
+// Collide checks collision between the hitbox and the blocks
+// returns collision value for each corner
+func Collide(origin types.Vec2f, hitbox Hitbox, world types.World) (collisions [4]bool) {
+	for i, point := range hitbox.Points(origin) {
+		block, isCollidable := world.BlockAt(uint64(point.X), uint64(point.Y)).(types.CollidableBlock)
+		if !isCollidable {
+			continue
+		}
+		if !block.Collidable() {
+			continue
+		}
+
+		blockCollisionPoints := ConvertToGlobalSpace(block, block.CollisionPoints())
+
+		var blockCollisions [4]bool
+		blockCollisions[0] = point.X < blockCollisionPoints[3].X || point.Y < blockCollisionPoints[3].Y
+		blockCollisions[1] = point.X > blockCollisionPoints[2].X || point.Y < blockCollisionPoints[2].Y
+		blockCollisions[2] = point.X < blockCollisionPoints[1].X || point.Y > blockCollisionPoints[1].Y
+		blockCollisions[3] = point.X > blockCollisionPoints[0].X || point.Y > blockCollisionPoints[0].Y
+		// if current point collides with any corner of the block, set the collision to true
+		collisions[i] = AnyOf(blockCollisions)
+	}
+
+	return
+}
+
+// returns true if any of collisions is true
+func AnyOf(collisions [4]bool) bool {
+	for _, collision := range collisions {
+		if collision {
+			return true
+		}
+	}
+	return false
+}
+
+func CountCollisions(collisions [4]bool) (count uint) {
+	for _, collision := range collisions {
+		if collision {
+			count++
+		}
+	}
+	return
+}
+
+// ResolveCollisions zeroes velocity on the axes, where the hitbox would collide with the blocks
+func ResolveCollisions(pos, velocity types.Vec2f, hitbox Hitbox, world types.World) types.Vec2f {
+	// if the entity somehow got stuck in the block, skip collision check
+	if AnyOf(Collide(pos, hitbox, world)) {
+		return velocity
+	}
+
+	// check for collisions on X axis
+	if AnyOf(Collide(types.Vec2f{X: pos.X + velocity.X, Y: pos.Y}, hitbox, world)) {
+		velocity.X = 0
+	}
+	// check for collisions on Y axis
+	if AnyOf(Collide(types.Vec2f{X: pos.X, Y: pos.Y + velocity.Y}, hitbox, world)) {
+		velocity.Y = 0
+	}
+	// check for corner collisions
+	if CountCollisions(Collide(types.Vec2f{X: pos.X + velocity.X, Y: pos.Y + velocity.Y}, hitbox, world)) == 1 {
+		// "bounce" off the corner
+		velocity.X = -velocity.X * 0.1
+		velocity.Y = -velocity.Y * 0.1
+	}
+
+	return velocity
+}
+
+// SpeedModifier returns how fast entities move through the block at the given position
+func SpeedModifier(pos types.Vec2f, world types.World) float64 {
+	if block, ok := world.BlockAt(uint64(pos.X), uint64(pos.Y)).(types.CollidableBlock); ok {
+		return block.PlayerSpeed()
+	}
+	return 1
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"reflect"
 
+	"github.com/3elDU/bamboo/clock"
 	"github.com/hajimehoshi/ebiten/v2"
 	"golang.org/x/exp/slices"
 )
@@ -28,10 +29,6 @@ type sceneManager struct {
 	currentScene Scene
 	stack        []Scene
 
-	// tick counter
-	// can be retrieved through Ticks() function
-	counter uint64
-
 	// special flag, that is set in SceneManager.Exit()
 	terminated bool
 }
@@ -47,7 +44,7 @@ func init() {
 // Ticks Returns internal tick counter that is incremented on each Update() call
 // Can be used for different timing purposes
 func Ticks() uint64 {
-	return manager.counter
+	return clock.Ticks()
 }
 
 // Pop must be called from Scene.Update()
@@ -137,7 +134,7 @@ func (manager *sceneManager) Update() error {
 
 	manager.currentScene.Update()
 
-	manager.counter++
+	clock.Advance()
 
 	return nil
 }
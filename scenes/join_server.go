@@ -0,0 +1,85 @@
+package scenes
+
+import (
+	"log"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/colors"
+	"github.com/3elDU/bamboo/game"
+	"github.com/3elDU/bamboo/scene_manager"
+	"github.com/3elDU/bamboo/ui"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// JoinServerScene asks for the address of the server, and the name of the player
+type JoinServerScene struct {
+	view ui.View
+
+	// first string is the server address, second is the player name
+	formData chan []string
+
+	goBack chan bool
+}
+
+func NewJoinServerScene() *JoinServerScene {
+	s := &JoinServerScene{
+		formData: make(chan []string, 1),
+		goBack:   make(chan bool, 1),
+	}
+	s.updateUI("")
+	return s
+}
+
+// updateUI rebuilds the view, showing the error, if it isn't empty
+func (s *JoinServerScene) updateUI(errorMessage string) {
+	view := ui.Stack(ui.StackOptions{Direction: ui.VerticalStack},
+		ui.Form(
+			"Join",
+			s.formData,
+			ui.FormPrompt{Title: "Server address"},
+			ui.FormPrompt{Title: "Player name"},
+		),
+	)
+	if errorMessage != "" {
+		view.AddChild(ui.Label(ui.LabelOptions{Color: colors.Red, Scaling: 1}, errorMessage))
+	}
+	view.AddChild(ui.Button(func() { s.goBack <- true }, ui.Label(ui.DefaultLabelOptions(), "Go back")))
+
+	s.view = ui.Screen(ui.BackgroundImage(ui.BackgroundTile, asset_loader.Texture("snow").Texture(), ui.Center(view)))
+}
+
+func (s *JoinServerScene) Update() {
+	if err := s.view.Update(); err != nil {
+		log.Panicf("failed to update a view: %v", err)
+	}
+
+	select {
+	case <-s.goBack:
+		scene_manager.Pop()
+	case formData := <-s.formData:
+		address, name := formData[0], formData[1]
+		if name == "" {
+			name = "player"
+		}
+
+		gameScene, err := game.JoinGameScene(address, name)
+		if err != nil {
+			log.Printf("JoinServerScene - failed to join %v - %v", address, err)
+			s.updateUI("Failed to join the server")
+			break
+		}
+		scene_manager.QPushAndSwitch(gameScene)
+	default:
+	}
+}
+
+func (*JoinServerScene) Destroy() {
+	log.Println("JoinServerScene.Destroy() called")
+}
+
+func (s *JoinServerScene) Draw(screen *ebiten.Image) {
+	err := s.view.Draw(screen, 0, 0)
+	if err != nil {
+		log.Panicln(err)
+	}
+}
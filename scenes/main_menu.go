@@ -37,6 +37,10 @@ func NewMainMenuScene() *MainMenu {
 							func() { buttonPressed <- 1 },
 							ui.Label(ui.DefaultLabelOptions(), "Singleplayer"),
 						),
+						ui.Button(
+							func() { buttonPressed <- 4 },
+							ui.Label(ui.DefaultLabelOptions(), "Multiplayer"),
+						),
 						ui.Button(
 							func() { buttonPressed <- 2 },
 							ui.Label(ui.DefaultLabelOptions(), "About"),
@@ -69,6 +73,9 @@ func (s *MainMenu) Update() {
 		case 3: // Exit
 			log.Println("mainMenu - \"Exit\" button pressed")
 			scene_manager.Exit()
+		case 4: // Multiplayer
+			log.Println("mainMenu - \"Multiplayer\" button pressed")
+			scene_manager.PushAndSwitch(NewJoinServerScene())
 		}
 	default:
 	}
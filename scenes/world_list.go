@@ -1,13 +1,14 @@
 package scenes
 
 import (
-	"encoding/gob"
+	"errors"
 	"fmt"
+	"github.com/3elDU/bamboo/colors"
 	"github.com/3elDU/bamboo/types"
 	"github.com/3elDU/bamboo/world_type"
+	"github.com/google/uuid"
 	"io/fs"
 	"log"
-	"os"
 	"path/filepath"
 
 	"github.com/3elDU/bamboo/asset_loader"
@@ -19,14 +20,27 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+// Entry in the world list
+type worldEntry struct {
+	metadata types.Save
+	// if not nil, the world can't be played
+	err error
+}
+
 type WorldListScene struct {
-	worldList []types.Save
+	worldList []worldEntry
 	view      ui.View
 
+	// errors, that happened while loading worlds, keyed by base UUID.
+	// Kept separately from the world list, because the list is rescanned every second
+	loadErrors map[uuid.UUID]error
+
 	// when the world will be selected by the user,
 	// world name will be transmitted through this channel
 	selectedWorld chan types.Save
 	deleteWorld   chan types.Save
+	// the world will be hosted for other players
+	hostWorld chan types.Save
 
 	// when the "New world" button will be pressed
 	// the event will be transmitted through this channel
@@ -37,7 +51,7 @@ type WorldListScene struct {
 
 // Scan scans the save folder for worlds
 func (s *WorldListScene) Scan() {
-	worldList := make([]types.Save, 0)
+	worldList := make([]worldEntry, 0)
 	filepath.WalkDir(config.WorldSaveDirectory, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -49,32 +63,49 @@ func (s *WorldListScene) Scan() {
 		}
 
 		// read world metadata
-		worldInfo, err := os.Open(filepath.Join(path, "world.gob"))
-		if err != nil {
-			// skip the directory, if it doesn't have the "world.gob" file inside of it,
-			// or if it is inaccesible for some other reason
-			// but don't throw an error!
+		worldMetadata, err := world.ReadMetadata(path)
+		switch {
+		case errors.Is(err, world.ErrNotFound):
+			// skip the directory, if it doesn't have the "world.gob" file inside of it
+			return nil
+		case errors.Is(err, world.ErrCorrupt):
+			// world type is unknown, so show the world anyway, so it could at least be deleted.
+			// save directories are named after UUIDs, so they can be recovered from the path
+			worldMetadata.UUID, _ = uuid.Parse(filepath.Base(path))
+			worldMetadata.BaseUUID, _ = uuid.Parse(filepath.Base(filepath.Dir(path)))
+			worldList = append(worldList, worldEntry{metadata: worldMetadata, err: err})
+			return nil
+		case err != nil && !errors.Is(err, world.ErrVersionMismatch):
+			// inaccesible for some other reason, but don't throw an error!
+			log.Printf("worldListScene.Scan() - %v", err)
 			return nil
-		}
-		defer worldInfo.Close()
-
-		decoder := gob.NewDecoder(worldInfo)
-		worldMetadata := new(types.Save)
-		if err = decoder.Decode(worldMetadata); err != nil {
-			return err
 		}
 
 		if worldMetadata.WorldType != world_type.Overworld {
 			return nil
 		}
 
-		worldList = append(worldList, *worldMetadata)
+		worldList = append(worldList, worldEntry{metadata: worldMetadata, err: err})
 
 		return nil
 	})
 	s.worldList = worldList
 }
 
+// returns a short, human-readable description of the loading error
+func describeLoadError(err error) string {
+	switch {
+	case errors.Is(err, world.ErrCorrupt):
+		return "The world is corrupt"
+	case errors.Is(err, world.ErrVersionMismatch):
+		return "The world was saved by a newer version"
+	case errors.Is(err, world.ErrNotFound):
+		return "Some of the world files are missing"
+	default:
+		return "Failed to load the world"
+	}
+}
+
 func (s *WorldListScene) UpdateUI() {
 	view := ui.Stack(ui.StackOptions{
 		Direction: ui.VerticalStack,
@@ -85,18 +116,38 @@ func (s *WorldListScene) UpdateUI() {
 		Direction: ui.VerticalStack,
 		Spacing:   1,
 	})
-	for _, currentWorld := range s.worldList {
+	for _, entry := range s.worldList {
+		currentWorld := entry.metadata
+		err := entry.err
+		if loadErr, exists := s.loadErrors[currentWorld.BaseUUID]; exists && err == nil {
+			err = loadErr
+		}
+
 		// assemble a view for each world
-		worldList.AddChild(ui.Stack(ui.StackOptions{Direction: ui.VerticalStack, Spacing: 0.5},
-			ui.Stack(ui.StackOptions{Direction: ui.HorizontalStack, Spacing: 1},
+		worldView := ui.Stack(ui.StackOptions{Direction: ui.VerticalStack, Spacing: 0.5})
+		if errors.Is(err, world.ErrCorrupt) && currentWorld.Name == "" {
+			worldView.AddChild(ui.Label(ui.DefaultLabelOptions(), fmt.Sprintf("Unknown world %v", currentWorld.BaseUUID)))
+		} else {
+			worldView.AddChild(ui.Stack(ui.StackOptions{Direction: ui.HorizontalStack, Spacing: 1},
 				ui.Label(ui.DefaultLabelOptions(), fmt.Sprintf("Name: %v", currentWorld.Name)),
 				ui.Label(ui.DefaultLabelOptions(), fmt.Sprintf("Seed: %v", currentWorld.Seed)),
-			),
-			ui.Stack(ui.StackOptions{Direction: ui.HorizontalStack, Spacing: 1},
-				ui.Button(func() { s.selectedWorld <- currentWorld }, ui.Label(ui.DefaultLabelOptions(), "Play")),
-				ui.Button(func() { s.deleteWorld <- currentWorld }, ui.Label(ui.DefaultLabelOptions(), "Delete")),
-			),
-		))
+			))
+		}
+
+		buttons := ui.Stack(ui.StackOptions{Direction: ui.HorizontalStack, Spacing: 1})
+		if err == nil {
+			buttons.AddChild(ui.Button(func() { s.selectedWorld <- currentWorld }, ui.Label(ui.DefaultLabelOptions(), "Play")))
+			buttons.AddChild(ui.Button(func() { s.hostWorld <- currentWorld }, ui.Label(ui.DefaultLabelOptions(), "Host")))
+		} else {
+			worldView.AddChild(ui.Label(ui.LabelOptions{Color: colors.Red, Scaling: 1}, describeLoadError(err)))
+		}
+		// without the base UUID, there is no way to know which directory to delete
+		if currentWorld.BaseUUID != uuid.Nil {
+			buttons.AddChild(ui.Button(func() { s.deleteWorld <- currentWorld }, ui.Label(ui.DefaultLabelOptions(), "Delete")))
+		}
+		worldView.AddChild(buttons)
+
+		worldList.AddChild(worldView)
 	}
 	view.AddChild(worldList)
 
@@ -114,8 +165,10 @@ func (s *WorldListScene) UpdateUI() {
 
 func NewWorldListScene() *WorldListScene {
 	scene := &WorldListScene{
+		loadErrors:    make(map[uuid.UUID]error),
 		selectedWorld: make(chan types.Save, 1),
 		deleteWorld:   make(chan types.Save, 1),
+		hostWorld:     make(chan types.Save, 1),
 		newWorld:      make(chan bool, 1),
 		goBack:        make(chan bool, 1),
 	}
@@ -141,7 +194,25 @@ func (s *WorldListScene) Update() {
 	select {
 	case save := <-s.selectedWorld:
 		log.Printf("worldListScene - Selected world '%v'", save)
-		scene_manager.QPushAndSwitch(game.LoadGameScene(save))
+		gameScene, err := game.LoadGameScene(save)
+		if err != nil {
+			// keep the user in the world list, and show what went wrong
+			log.Printf("worldListScene - failed to load world '%v' - %v", save.Name, err)
+			s.loadErrors[save.BaseUUID] = err
+			s.UpdateUI()
+			break
+		}
+		scene_manager.QPushAndSwitch(gameScene)
+	case save := <-s.hostWorld:
+		log.Printf("worldListScene - Hosting world '%v'", save)
+		gameScene, err := game.HostGameScene(save)
+		if err != nil {
+			log.Printf("worldListScene - failed to host world '%v' - %v", save.Name, err)
+			s.loadErrors[save.BaseUUID] = err
+			s.UpdateUI()
+			break
+		}
+		scene_manager.QPushAndSwitch(gameScene)
 	case <-s.newWorld:
 		log.Println("worldListScene - New world")
 		scene_manager.PushAndSwitch(NewNewWorldScene())
@@ -149,6 +220,7 @@ func (s *WorldListScene) Update() {
 		scene_manager.Pop()
 	case id := <-s.deleteWorld:
 		world.DeleteWorld(id)
+		delete(s.loadErrors, id.BaseUUID)
 		s.Scan()
 	default:
 	}
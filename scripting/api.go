@@ -0,0 +1,379 @@
+/*
+	Functions, available to the scripts. Everything is in the global "bamboo" table:
+
+		bamboo.world.block_at(x, y)           -> name of the block
+		bamboo.world.set_block(x, y, name)    -> an error on the multiplayer clients, only the server can change blocks
+		bamboo.player.position()              -> x, y
+		bamboo.player.set_position(x, y)
+		bamboo.inventory.add(name, (count))   -> how many items fit
+		bamboo.inventory.count(name)          -> count
+		bamboo.inventory.remove(name, (count)) -> false, if there weren't enough items
+
+		bamboo.on(event, function(args) end)  -> returning false cancels the event, if it can be cancelled
+		bamboo.fire(event, (args))            -> runs the handlers of all mods, returns false, if one of them cancelled it
+
+		bamboo.register_block{name = ..., texture = ..., interact = function(x, y) end, ...}
+		bamboo.register_item{name = ..., texture = ..., maxStackSize = ..., use = function(x, y) end}
+		bamboo.register_command{name = ..., usage = ..., description = ..., run = function(args) return "output" end}
+
+	register_block takes the same fields as the block definitions in assets/blocks,
+	interact is called each tick, while the player walks into the block.
+	Events, fired by the game, are listed in subscribe().
+	print() writes to the log
+*/
+
+package scripting
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/event"
+	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/types"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func (m *mod) openAPI() {
+	L := m.state
+	api := L.NewTable()
+
+	L.SetField(api, "world", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"block_at":  blockAt,
+		"set_block": setBlock,
+	}))
+	L.SetField(api, "player", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"position":     playerPosition,
+		"set_position": setPlayerPosition,
+	}))
+	L.SetField(api, "inventory", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"add":    inventoryAdd,
+		"count":  inventoryCount,
+		"remove": inventoryRemove,
+	}))
+	L.SetFuncs(api, map[string]lua.LGFunction{
+		"on":               m.on,
+		"fire":             fire,
+		"register_block":   m.registerBlock,
+		"register_item":    m.registerItem,
+		"register_command": m.registerCommand,
+	})
+
+	L.SetGlobal("bamboo", api)
+	L.SetGlobal("print", L.NewFunction(m.print))
+}
+
+func (m *mod) print(L *lua.LState) int {
+	args := make([]string, L.GetTop())
+	for i := range args {
+		args[i] = L.ToStringMeta(L.Get(i + 1)).String()
+	}
+	log.Printf("mod %v - %v", m.name, strings.Join(args, " "))
+	return 0
+}
+
+// checks that the world is available, and the coordinates are inside of it
+func checkCoords(L *lua.LState, xArg, yArg int) (uint64, uint64) {
+	if current.World == nil {
+		L.RaiseError("no world is loaded")
+	}
+	x, y := L.CheckInt64(xArg), L.CheckInt64(yArg)
+	if x < 0 || y < 0 || uint64(x) >= config.WorldWidth || uint64(y) >= config.WorldHeight {
+		L.RaiseError("%v, %v is outside of the world", x, y)
+	}
+	return uint64(x), uint64(y)
+}
+
+func blockAt(L *lua.LState) int {
+	x, y := checkCoords(L, 1, 2)
+	L.Push(lua.LString(blocks.Name(current.World.BlockAt(x, y).Type())))
+	return 1
+}
+
+func setBlock(L *lua.LState) int {
+	x, y := checkCoords(L, 1, 2)
+	name := L.CheckString(3)
+	block, exists := blocks.GetBlockByName(name)
+	if !exists {
+		L.ArgError(3, fmt.Sprintf("unknown block %v", name))
+	}
+	if current.Multiplayer {
+		L.RaiseError("blocks can be changed only by the server in multiplayer")
+	}
+	current.World.SetBlock(x, y, block)
+	return 0
+}
+
+func checkPlayer(L *lua.LState) types.Entity {
+	if current.Player == nil {
+		L.RaiseError("there is no player")
+	}
+	return current.Player
+}
+
+func playerPosition(L *lua.LState) int {
+	pos := checkPlayer(L).Position()
+	L.Push(lua.LNumber(pos.X))
+	L.Push(lua.LNumber(pos.Y))
+	return 2
+}
+
+func setPlayerPosition(L *lua.LState) int {
+	player := checkPlayer(L)
+	x, y := float64(L.CheckNumber(1)), float64(L.CheckNumber(2))
+	if x < 0 || y < 0 || x >= float64(config.WorldWidth) || y >= float64(config.WorldHeight) {
+		L.RaiseError("%v, %v is outside of the world", x, y)
+	}
+	player.SetPosition(types.Vec2f{X: x, Y: y})
+	player.SetVelocity(types.Vec2f{})
+	return 0
+}
+
+func checkInventory(L *lua.LState) Inventory {
+	if current.Inventory == nil {
+		L.RaiseError("there is no inventory")
+	}
+	return current.Inventory
+}
+
+func inventoryAdd(L *lua.LState) int {
+	inventory := checkInventory(L)
+	name, count := L.CheckString(1), L.OptInt(2, 1)
+	if _, exists := items.GetItemByName(name); !exists {
+		L.ArgError(1, fmt.Sprintf("unknown item %v", name))
+	}
+
+	added := 0
+	for ; added < count; added++ {
+		item, _ := items.GetItemByName(name)
+		if !inventory.AddItem(item) {
+			break
+		}
+	}
+	L.Push(lua.LNumber(added))
+	return 1
+}
+
+func inventoryCount(L *lua.LState) int {
+	L.Push(lua.LNumber(checkInventory(L).Count(L.CheckString(1))))
+	return 1
+}
+
+func inventoryRemove(L *lua.LState) int {
+	L.Push(lua.LBool(checkInventory(L).Remove(L.CheckString(1), L.OptInt(2, 1))))
+	return 1
+}
+
+func (m *mod) on(L *lua.LState) int {
+	name, handler := L.CheckString(1), L.CheckFunction(2)
+	m.handlers[name] = append(m.handlers[name], handler)
+	return 0
+}
+
+func fire(L *lua.LState) int {
+	name := L.CheckString(1)
+	args, err := toGo(L.OptTable(2, L.NewTable()))
+	if err != nil {
+		L.ArgError(2, err.Error())
+	}
+	argMap, ok := args.(map[string]interface{})
+	if !ok {
+		// an empty table, or a list
+		argMap = make(map[string]interface{})
+	}
+	for key, value := range argMap {
+		switch value.(type) {
+		case []interface{}, map[string]interface{}:
+			L.ArgError(2, fmt.Sprintf("%v - only strings, numbers and booleans can be passed", key))
+		}
+	}
+	if current.World == nil {
+		L.RaiseError("no world is loaded")
+	}
+
+	L.Push(lua.LBool(event.Fire(current.World.Events(), &ModEvent{Name: name, Args: argMap})))
+	return 1
+}
+
+func (m *mod) checkLoading(L *lua.LState) {
+	if !m.loading {
+		L.RaiseError("registration is only possible while %v is running", scriptFile)
+	}
+}
+
+func (m *mod) registerBlock(L *lua.LState) int {
+	m.checkLoading(L)
+	table := L.CheckTable(1)
+
+	definition := asset_loader.BlockDefinition{}
+	if err := fromTable(table, &definition); err != nil {
+		L.ArgError(1, err.Error())
+	}
+
+	var interact blocks.InteractFunc
+	if fn, ok := table.RawGetString("interact").(*lua.LFunction); ok {
+		interact = func(_ types.World, block types.Vec2u, _ types.Vec2f) {
+			m.callback(fn, lua.LNumber(block.X), lua.LNumber(block.Y))
+		}
+	}
+
+	if err := blocks.RegisterDefinition(definition, interact); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+func (m *mod) registerItem(L *lua.LState) int {
+	m.checkLoading(L)
+	table := L.CheckTable(1)
+
+	var fields struct {
+		Name         string `json:"name"`
+		Texture      string `json:"texture"`
+		MaxStackSize uint8  `json:"maxStackSize"`
+	}
+	if err := fromTable(table, &fields); err != nil {
+		L.ArgError(1, err.Error())
+	}
+
+	definition := items.ItemDefinition{
+		Name:         fields.Name,
+		Texture:      fields.Texture,
+		MaxStackSize: fields.MaxStackSize,
+	}
+	if fn, ok := table.RawGetString("use").(*lua.LFunction); ok {
+		definition.Use = func(_ types.World, pos types.Vec2u) {
+			m.callback(fn, lua.LNumber(pos.X), lua.LNumber(pos.Y))
+		}
+	}
+
+	if err := items.RegisterDefinition(definition); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+func (m *mod) registerCommand(L *lua.LState) int {
+	m.checkLoading(L)
+	table := L.CheckTable(1)
+
+	var fields struct {
+		Name        string `json:"name"`
+		Usage       string `json:"usage"`
+		Description string `json:"description"`
+	}
+	if err := fromTable(table, &fields); err != nil {
+		L.ArgError(1, err.Error())
+	}
+	run, ok := table.RawGetString("run").(*lua.LFunction)
+	if fields.Name == "" || !ok {
+		L.ArgError(1, "name and run must be set")
+	}
+
+	modCommands = append(modCommands, Command{
+		Name:        fields.Name,
+		Usage:       fields.Usage,
+		Description: fields.Description,
+		Run: func(args []string) (string, error) {
+			luaArgs := L.NewTable()
+			for _, arg := range args {
+				luaArgs.Append(lua.LString(arg))
+			}
+			output, err := m.call(callTimeout, run, luaArgs)
+			if apiErr, ok := err.(*lua.ApiError); ok {
+				// the traceback doesn't fit in the console
+				return "", fmt.Errorf("mod %v - %v", m.name, apiErr.Object)
+			} else if err != nil {
+				return "", fmt.Errorf("mod %v - %w", m.name, err)
+			}
+			if output == lua.LNil {
+				return "", nil
+			}
+			return L.ToStringMeta(output).String(), nil
+		},
+	})
+	return 0
+}
+
+// fromTable decodes the table into a struct, the same way as the JSON data files are decoded.
+// Functions are skipped, so that the callbacks can be in the same table. Unknown fields are an error
+func fromTable(table *lua.LTable, v interface{}) error {
+	value, err := toGo(table)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// Tables, nested deeper than that, can't be passed from the scripts
+const maxTableDepth = 16
+
+// toGo converts a Lua value to strings, numbers, booleans, slices and maps.
+// Tables with the array part become slices, the other fields of such tables are ignored.
+// Tables, that contain themselves, are an error
+func toGo(value lua.LValue) (interface{}, error) {
+	return convertToGo(value, make(map[*lua.LTable]bool), 0)
+}
+
+// visited holds the tables, that are being converted right now, i.e. the parents of the value
+func convertToGo(value lua.LValue, visited map[*lua.LTable]bool, depth int) (interface{}, error) {
+	switch value := value.(type) {
+	case lua.LString:
+		return string(value), nil
+	case lua.LNumber:
+		return float64(value), nil
+	case lua.LBool:
+		return bool(value), nil
+	case *lua.LTable:
+		if visited[value] {
+			return nil, fmt.Errorf("table contains itself")
+		}
+		if depth >= maxTableDepth {
+			return nil, fmt.Errorf("tables are nested deeper than %v", maxTableDepth)
+		}
+		visited[value] = true
+		defer delete(visited, value)
+
+		// an empty table is also a list, e.g. drops = {}
+		if firstKey, _ := value.Next(lua.LNil); value.MaxN() > 0 || firstKey == lua.LNil {
+			list := make([]interface{}, 0, value.MaxN())
+			for i := 1; i <= value.MaxN(); i++ {
+				element, err := convertToGo(value.RawGetInt(i), visited, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, element)
+			}
+			return list, nil
+		}
+
+		fields := make(map[string]interface{})
+		var err error
+		value.ForEach(func(key, field lua.LValue) {
+			if _, isFunction := field.(*lua.LFunction); isFunction || err != nil {
+				return
+			}
+			name, ok := key.(lua.LString)
+			if !ok {
+				err = fmt.Errorf("unsupported key %v", key)
+				return
+			}
+			fields[string(name)], err = convertToGo(field, visited, depth+1)
+		})
+		return fields, err
+	default:
+		return nil, fmt.Errorf("unsupported value %v", value)
+	}
+}
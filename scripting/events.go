@@ -0,0 +1,155 @@
+package scripting
+
+import (
+	"fmt"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/event"
+	"github.com/3elDU/bamboo/items"
+	"github.com/3elDU/bamboo/types"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// ModEvent is fired on the event bus of the world by bamboo.fire().
+// Go code can subscribe to it as well
+type ModEvent struct {
+	event.Cancellable
+	Name string
+	// Strings, numbers and booleans, passed from the script
+	Args map[string]interface{}
+}
+
+// Command is a console command, registered by a mod.
+// The game adds it to the console with commands.RegisterModCommands()
+type Command struct {
+	Name        string
+	Usage       string
+	Description string
+	// Runs with the context, set with SetContext()
+	Run func(args []string) (string, error)
+}
+
+var (
+	modCommands []Command
+
+	// the bus of the current world, and the subscriptions of the scripts to it
+	subscribedBus *event.Bus
+	subscriptions []*event.Subscription
+)
+
+// Commands returns the commands, registered by the mods
+func Commands() []Command {
+	return modCommands
+}
+
+// subscribe moves the subscriptions of the scripts to another world.
+// The names of the events, passed to bamboo.on(), are listed here
+func subscribe(bus *event.Bus) {
+	for _, subscription := range subscriptions {
+		subscription.Unsubscribe()
+	}
+	subscriptions = nil
+	subscribedBus = bus
+	if bus == nil {
+		return
+	}
+
+	subscriptions = []*event.Subscription{
+		subscribeScripts(bus, "block_placed", func(ev *types.BlockPlacedEvent) map[string]interface{} {
+			return blockArgs(ev.Pos, ev.Block)
+		}),
+		subscribeScripts(bus, "block_broken", func(ev *types.BlockBrokenEvent) map[string]interface{} {
+			return blockArgs(ev.Pos, ev.Block)
+		}),
+		subscribeScripts(bus, "chunk_loaded", func(ev *types.ChunkLoadedEvent) map[string]interface{} {
+			return chunkArgs(ev.Chunk)
+		}),
+		subscribeScripts(bus, "chunk_generated", func(ev *types.ChunkGeneratedEvent) map[string]interface{} {
+			return chunkArgs(ev.Chunk)
+		}),
+		subscribeScripts(bus, "chunk_unloaded", func(ev *types.ChunkUnloadedEvent) map[string]interface{} {
+			return chunkArgs(ev.Chunk)
+		}),
+		subscribeScripts(bus, "cave_entered", func(ev *types.CaveEnteredEvent) map[string]interface{} {
+			return map[string]interface{}{"id": ev.ID.String()}
+		}),
+		subscribeScripts(bus, "player_changed_world", func(ev *types.PlayerChangedWorldEvent) map[string]interface{} {
+			return map[string]interface{}{
+				"from": ev.From.Metadata().UUID.String(),
+				"to":   ev.To.Metadata().UUID.String(),
+			}
+		}),
+		subscribeScripts(bus, "item_picked_up", func(ev *types.ItemPickedUpEvent) map[string]interface{} {
+			return map[string]interface{}{"item": items.NameOf(ev.Item)}
+		}),
+		event.Subscribe(bus, event.Normal, func(ev *ModEvent) {
+			if !dispatch(ev.Name, ev.Args) {
+				ev.Cancel()
+			}
+		}),
+	}
+}
+
+// subscribeScripts passes the events of type E to the handlers, registered with bamboo.on(name).
+// If the event is cancellable, a handler can cancel it by returning false
+func subscribeScripts[E any](bus *event.Bus, name string, args func(ev *E) map[string]interface{}) *event.Subscription {
+	return event.Subscribe(bus, event.Normal, func(ev *E) {
+		cancellable, ok := interface{}(ev).(interface{ Cancel() })
+		if !dispatch(name, args(ev)) && ok {
+			cancellable.Cancel()
+		}
+	})
+}
+
+func blockArgs(pos types.Vec2u, block types.Block) map[string]interface{} {
+	return map[string]interface{}{
+		"x":     float64(pos.X),
+		"y":     float64(pos.Y),
+		"block": blocks.Name(block.Type()),
+	}
+}
+
+// chunk coordinates, not block ones
+func chunkArgs(chunk types.Chunk) map[string]interface{} {
+	return map[string]interface{}{
+		"x": float64(chunk.Coords().X),
+		"y": float64(chunk.Coords().Y),
+	}
+}
+
+// dispatch runs the handlers of all mods, that are subscribed to the event.
+// Returns false, if any of the handlers returned false
+func dispatch(name string, args map[string]interface{}) bool {
+	result := true
+	for _, m := range mods {
+		handlers := m.handlers[name]
+		if len(handlers) == 0 {
+			continue
+		}
+		table := toLua(m.state, args)
+		for _, handler := range handlers {
+			if ret, ok := m.callback(handler, table); ok && ret == lua.LFalse {
+				result = false
+			}
+		}
+	}
+	return result
+}
+
+// toLua converts the event arguments to a table
+func toLua(L *lua.LState, args map[string]interface{}) *lua.LTable {
+	table := L.NewTable()
+	for key, value := range args {
+		switch value := value.(type) {
+		case string:
+			L.SetField(table, key, lua.LString(value))
+		case float64:
+			L.SetField(table, key, lua.LNumber(value))
+		case bool:
+			L.SetField(table, key, lua.LBool(value))
+		default:
+			L.SetField(table, key, lua.LString(fmt.Sprint(value)))
+		}
+	}
+	return table
+}
@@ -0,0 +1,225 @@
+/*
+	Lua scripting. Each subdirectory of config.ModDirectory is a mod:
+
+		mods/<name>/init.lua    - the script, which is run once, when the game starts
+		mods/<name>/textures/   - optional textures and connected texture atlases, added to the assets
+
+	Each mod runs in its own Lua state, without the io, os, package and debug libraries,
+	and every call into a script has a time limit. Errors in the scripts are logged, and never crash the game.
+	Memory isn't limited, except that string.rep() can't build strings longer than maxRepeatLength
+	The functions, available to the scripts, are described in api.go
+*/
+
+package scripting
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/3elDU/bamboo/asset_loader"
+	"github.com/3elDU/bamboo/event"
+	"github.com/3elDU/bamboo/types"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	scriptFile       = "init.lua"
+	textureDirectory = "textures"
+
+	// How long init.lua may run
+	loadTimeout = time.Second
+	// How long an event handler, a command or a block or item callback may run
+	callTimeout = 50 * time.Millisecond
+
+	// The longest string string.rep() can return, 1 MiB
+	maxRepeatLength = 1 << 20
+)
+
+// Inventory is the part of the player's inventory, that is accessible to the scripts
+type Inventory interface {
+	AddItem(item types.Item) bool
+	Count(name string) int
+	Remove(name string, count int) bool
+}
+
+// Context is what the scripts operate on.
+// Player and Inventory are nil, when there is no local player, e.g. on the dedicated server
+type Context struct {
+	World     types.World
+	Player    types.Entity
+	Inventory Inventory
+	// Set on the clients of a multiplayer server, where only the server can change the blocks
+	Multiplayer bool
+}
+
+var (
+	mods []*mod
+	// set with SetContext()
+	current Context
+)
+
+type mod struct {
+	name  string
+	state *lua.LState
+
+	// blocks, items and commands can be registered only while init.lua is running
+	loading bool
+	// number of the calls into the script, that are running right now
+	depth int
+	// event name -> handlers, registered with bamboo.on()
+	handlers map[string][]*lua.LFunction
+}
+
+// LoadMods runs the scripts of all mods in the directory. Mods, that fail to load, are skipped,
+// although the blocks, items and commands, registered before the error, stay.
+// Must be called after the assets are loaded, and before any world is loaded, since the mods can register blocks
+func LoadMods(dir string) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("scripting.LoadMods() - %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		m, err := loadMod(entry.Name(), filepath.Join(dir, entry.Name()))
+		if err != nil {
+			log.Printf("scripting.LoadMods() - mod %v failed to load - %v", entry.Name(), err)
+			continue
+		}
+		mods = append(mods, m)
+		log.Printf("Loaded mod %v", m.name)
+	}
+}
+
+func loadMod(name, dir string) (*mod, error) {
+	textures := filepath.Join(dir, textureDirectory)
+	if _, err := os.Stat(textures); err == nil {
+		if err := asset_loader.LoadModTextures(textures); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &mod{
+		name:     name,
+		state:    newSandbox(),
+		handlers: make(map[string][]*lua.LFunction),
+	}
+	m.openAPI()
+
+	script, err := m.state.LoadFile(filepath.Join(dir, scriptFile))
+	if err != nil {
+		m.state.Close()
+		return nil, err
+	}
+
+	m.loading = true
+	defer func() { m.loading = false }()
+	// the state isn't closed on errors, since the callbacks, that are already registered, still use it
+	if _, err := m.call(loadTimeout, script); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SetContext sets the world and the player, that the scripts operate on, and subscribes the scripts to the events of the world.
+// The game calls it each tick, since the player can move between the worlds
+func SetContext(ctx Context) {
+	current = ctx
+	if len(mods) == 0 {
+		return
+	}
+
+	var bus *event.Bus
+	if ctx.World != nil {
+		bus = ctx.World.Events()
+	}
+	if bus != subscribedBus {
+		subscribe(bus)
+	}
+}
+
+// newSandbox creates a Lua state with only the libraries, that can't reach outside of the game
+func newSandbox() *lua.LState {
+	state := lua.NewState(lua.Options{SkipOpenLibs: true})
+	libs := []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	}
+	for _, lib := range libs {
+		state.Push(state.NewFunction(lib.open))
+		state.Push(lua.LString(lib.name))
+		state.Call(1, 0)
+	}
+
+	// the base library can still load files
+	for _, name := range []string{"dofile", "loadfile", "require", "module"} {
+		state.SetGlobal(name, lua.LNil)
+	}
+	// string.rep("x", 1e12) would allocate all the memory before the time limit is checked
+	state.SetField(state.GetGlobal(lua.StringLibName), "rep", state.NewFunction(repeatString))
+	return state
+}
+
+// repeatString replaces string.rep(), limiting the length of the result
+func repeatString(L *lua.LState) int {
+	str, count := L.CheckString(1), L.CheckInt(2)
+	if count <= 0 || len(str) == 0 {
+		L.Push(lua.LString(""))
+		return 1
+	}
+	if count > maxRepeatLength/len(str) {
+		L.RaiseError("string.rep() - the result would be longer than %v bytes", maxRepeatLength)
+	}
+	L.Push(lua.LString(strings.Repeat(str, count)))
+	return 1
+}
+
+// call runs the function in protected mode, with the time limit.
+// Lua errors, and panics in the Go functions called from the script, are returned as errors
+func (m *mod) call(timeout time.Duration, fn *lua.LFunction, args ...lua.LValue) (lua.LValue, error) {
+	// events are fired right away, so a script can be called from itself, e.g. through bamboo.fire().
+	// The nested calls share the time limit of the outer one
+	if m.depth == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		m.state.SetContext(ctx)
+		defer m.state.RemoveContext()
+	}
+	m.depth++
+	defer func() { m.depth-- }()
+
+	if err := m.state.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, args...); err != nil {
+		return lua.LNil, err
+	}
+	ret := m.state.Get(-1)
+	m.state.Pop(1)
+	return ret, nil
+}
+
+// callback is like call, but logs the error, for the places where it can't be returned.
+// The second value is false, if there was an error
+func (m *mod) callback(fn *lua.LFunction, args ...lua.LValue) (lua.LValue, bool) {
+	ret, err := m.call(callTimeout, fn, args...)
+	if err != nil {
+		log.Printf("mod %v - %v", m.name, err)
+		return lua.LNil, false
+	}
+	return ret, true
+}
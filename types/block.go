@@ -1,9 +1,5 @@
 package types
 
-import (
-	"github.com/hajimehoshi/ebiten/v2"
-)
-
 type BlockType int
 
 type Block interface {
@@ -16,8 +12,8 @@ type Block interface {
 	Update(world World)
 
 	State() interface{}
-	// LoadState panicks on error
-	LoadState(interface{})
+	// LoadState returns an error, if the state is of the wrong type
+	LoadState(interface{}) error
 }
 
 type CollidableBlock interface {
@@ -30,7 +26,7 @@ type CollidableBlock interface {
 
 type DrawableBlock interface {
 	Block
-	Render(world World, screen *ebiten.Image, pos Vec2f)
+	blockGraphics
 	TextureName() string
 }
 
@@ -38,3 +34,37 @@ type InteractiveBlock interface {
 	Block
 	Interact(world World, playerPosition Vec2f)
 }
+
+// Light levels go from 0 ( complete darkness ) to MaxLightLevel ( daylight )
+const MaxLightLevel uint8 = 15
+
+// LightEmitter is a block, that glows in the dark, e.g. a torch
+type LightEmitter interface {
+	Block
+	// Zero means that the block doesn't emit light
+	LightLevel() uint8
+}
+
+// Tools speed up breaking of the blocks of a specific category
+type BlockCategory string
+
+const (
+	NoCategory     BlockCategory = ""
+	StoneCategory  BlockCategory = "stone"
+	WoodCategory   BlockCategory = "wood"
+	GroundCategory BlockCategory = "ground"
+	PlantCategory  BlockCategory = "plant"
+)
+
+// BreakableBlock is a block, that can be broken by the player
+type BreakableBlock interface {
+	Block
+	// Time to break the block without a tool, in seconds
+	Hardness() float64
+	Category() BlockCategory
+	// Names of the items, that go to the inventory, when the block is broken
+	Drops() []string
+	// Block, that is left after breaking this one.
+	// If nil, the ground from the world generator is used ( e.g. grass under a tree )
+	BreaksInto() Block
+}
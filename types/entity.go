@@ -0,0 +1,33 @@
+package types
+
+// Stable name of the entity type, e.g. "rabbit"
+// It is used to save entities, so it must never change after the entity is released
+type EntityType string
+
+// Type of the player entity
+const PlayerEntity EntityType = "player"
+
+// Entity is anything in the world, that isn't bound to the block grid: the player, mobs, dropped items
+type Entity interface {
+	Type() EntityType
+
+	// Position in block coordinates
+	Position() Vec2f
+	SetPosition(pos Vec2f)
+	// Velocity in blocks per tick
+	Velocity() Vec2f
+	SetVelocity(velocity Vec2f)
+
+	Update(world World)
+	entityGraphics
+
+	State() interface{}
+	// LoadState returns an error, if the state is of the wrong type
+	LoadState(interface{}) error
+}
+
+// Damageable is an entity, that has health and can be hurt, e.g. the player
+type Damageable interface {
+	Entity
+	Damage(amount float64)
+}
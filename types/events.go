@@ -0,0 +1,62 @@
+package types
+
+import (
+	"github.com/3elDU/bamboo/event"
+	"github.com/google/uuid"
+)
+
+/*
+	Events, fired on the event bus of the world ( World.Events() ).
+	The ones, that embed event.Cancellable, are fired before the thing happens, and can prevent it
+*/
+
+// BlockPlacedEvent is fired, when the player places a block.
+// In multiplayer, it is fired both on the client and on the server, and either of them can cancel it
+type BlockPlacedEvent struct {
+	event.Cancellable
+	Pos   Vec2u
+	Block Block
+}
+
+// BlockBrokenEvent is fired, when the player breaks a block, before it drops the items.
+// Same as BlockPlacedEvent, it is fired on the client and on the server
+type BlockBrokenEvent struct {
+	event.Cancellable
+	Pos   Vec2u
+	Block Block
+}
+
+// ChunkLoadedEvent is fired, when a chunk is loaded from the disk, or received from the server
+type ChunkLoadedEvent struct {
+	Chunk Chunk
+}
+
+// ChunkGeneratedEvent is fired instead of ChunkLoadedEvent, when a chunk is generated for the first time
+type ChunkGeneratedEvent struct {
+	Chunk Chunk
+}
+
+// ChunkUnloadedEvent is fired, when a chunk wasn't accessed for a while. If cancelled, the chunk stays loaded
+type ChunkUnloadedEvent struct {
+	event.Cancellable
+	Chunk Chunk
+}
+
+// CaveEnteredEvent is fired, when the player walks into a cave entrance
+type CaveEnteredEvent struct {
+	event.Cancellable
+	// UUID of the cave world
+	ID uuid.UUID
+}
+
+// PlayerChangedWorldEvent is fired on both worlds, after the player has moved from one to another
+type PlayerChangedWorldEvent struct {
+	Player   Entity
+	From, To World
+}
+
+// ItemPickedUpEvent is fired, before the item goes to the inventory of the player
+type ItemPickedUpEvent struct {
+	event.Cancellable
+	Item Item
+}
@@ -10,6 +10,8 @@ type WorldGenerator interface {
 	GenerateDummy(chunk Chunk)
 	// Returns generated chunks, if any
 	Receive() []Chunk
+	// Returns the ground block at those coordinates, as if there were no trees, foliage and structures
+	BaseAt(x, y uint64) Block
 
 	// Runs a generator main loop.
 	// Must be called with `go Run()`
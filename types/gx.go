@@ -0,0 +1,46 @@
+//go:build !headless
+
+/*
+	Parts of the interfaces, that depend on the graphics.
+	They are left out, when building with the "headless" tag ( see headless.go ),
+	so that the world can be simulated without ebiten, e.g. on the dedicated server.
+*/
+
+package types
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+type blockGraphics interface {
+	Render(world World, screen *ebiten.Image, pos Vec2f)
+}
+
+type chunkGraphics interface {
+	Render(world World)
+	Texture() *ebiten.Image
+}
+
+type entityGraphics interface {
+	// pos is the position of the entity on the screen, in pixels
+	Render(screen *ebiten.Image, pos Vec2f, scaling float64)
+}
+
+type itemGraphics interface {
+	Texture() *ebiten.Image
+}
+
+type textureGraphics interface {
+	Texture() *ebiten.Image
+	// Returns size of the texture, multiplied by ui scaling
+	// Useful for UI elements
+	ScaledSize() (float64, float64)
+}
+
+type connectedTextureGraphics interface {
+	Texture() *ebiten.Image
+}
+
+type worldGraphics interface {
+	Render(screen *ebiten.Image, playerX float64, playerY float64, scaling float64)
+}
@@ -0,0 +1,19 @@
+//go:build headless
+
+// Without the graphics, the interfaces have no rendering methods ( see gx.go )
+
+package types
+
+type blockGraphics interface{}
+
+type chunkGraphics interface{}
+
+type entityGraphics interface{}
+
+type itemGraphics interface{}
+
+type textureGraphics interface{}
+
+type connectedTextureGraphics interface{}
+
+type worldGraphics interface{}
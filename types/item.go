@@ -1,15 +1,52 @@
 package types
 
-import "github.com/hajimehoshi/ebiten/v2"
+import "reflect"
 
 type ItemType uint
 
+// Stack size of the items, that don't declare their own
+const DefaultMaxStackSize uint8 = 50
+
+// Keys of the well-known item metadata
+const (
+	// int, remaining uses of the tool
+	DurabilityKey = "durability"
+	// string, shown instead of the item name
+	CustomNameKey = "custom_name"
+)
+
+// ItemMetadata is arbitrary data, attached to the item, which is saved along with it.
+// Values must be encodable with gob. Types other than the basic ones must be registered with gob.Register()
+type ItemMetadata map[string]interface{}
+
 type Item interface {
-	Texture() *ebiten.Image
+	itemGraphics
 	Type() ItemType
 
+	// Maximum quantity of such items in one slot
+	MaxStackSize() uint8
+	// Metadata returns the metadata of the item. Changes to it are saved
+	Metadata() ItemMetadata
+
+	// State includes the metadata
 	State() interface{}
-	LoadState(interface{})
+	// LoadState returns an error, if the state is of the wrong type
+	LoadState(interface{}) error
 
 	Use(world World, pos Vec2u)
 }
+
+// SameItems returns true, if the items are of the same type, and have the same state,
+// so they can be stacked together
+func SameItems(a, b Item) bool {
+	return a.Type() == b.Type() && reflect.DeepEqual(a.State(), b.State())
+}
+
+// Tool is an item, that speeds up breaking of blocks
+type Tool interface {
+	Item
+	// Multiplier of the breaking speed. 1 means that the tool doesn't help with such blocks
+	MiningSpeed(category BlockCategory) float64
+	// Wear is called, when the tool breaks a block. Returns true, if the tool is worn out
+	Wear() bool
+}
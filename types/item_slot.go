@@ -7,8 +7,14 @@ type ItemSlot struct {
 	Empty    bool
 }
 
+// Stacks returns true if the item can be put into the slot along with its items,
+// not taking the free space into account
+func (slot *ItemSlot) Stacks(item Item) bool {
+	return slot.Empty || SameItems(slot.Item, item)
+}
+
 // Returns true if item has been successfully added
-// False if there is no space, or item is of different type
+// False if there is no space, or the item is different
 func (slot *ItemSlot) AddItem(item Item) bool {
 	if slot.Empty {
 		slot.Item = item
@@ -17,11 +23,11 @@ func (slot *ItemSlot) AddItem(item Item) bool {
 		return true
 	}
 
-	if item.Type() != slot.Item.Type() {
+	if !slot.Stacks(item) {
 		return false
 	}
 
-	if slot.Quantity > 50 {
+	if slot.Quantity >= slot.Item.MaxStackSize() {
 		return false
 	}
 
@@ -31,8 +37,43 @@ func (slot *ItemSlot) AddItem(item Item) bool {
 
 func (slot *ItemSlot) RemoveItem(count uint8) {
 	if slot.Quantity <= count {
+		slot.Item = nil
 		slot.Quantity = 0
+		slot.Empty = true
 	} else {
 		slot.Quantity -= count
 	}
 }
+
+// MoveTo moves up to count items to the other slot, as many as fit there.
+// Returns the number of moved items.
+// If only a part of the stack is moved to an empty slot, both slots share the item afterwards
+func (slot *ItemSlot) MoveTo(other *ItemSlot, count uint8) uint8 {
+	if slot.Empty || !other.Stacks(slot.Item) {
+		return 0
+	}
+
+	maxStack := slot.Item.MaxStackSize()
+	if other.Quantity >= maxStack {
+		return 0
+	}
+
+	if count > slot.Quantity {
+		count = slot.Quantity
+	}
+	if space := maxStack - other.Quantity; count > space {
+		count = space
+	}
+	if count == 0 {
+		return 0
+	}
+
+	if other.Empty {
+		other.Item = slot.Item
+		other.Quantity = 0
+		other.Empty = false
+	}
+	other.Quantity += count
+	slot.RemoveItem(count)
+	return count
+}
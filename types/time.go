@@ -0,0 +1,32 @@
+package types
+
+import (
+	"math"
+
+	"github.com/3elDU/bamboo/config"
+)
+
+// WorldTime is the age of the world in ticks.
+// It is saved with the world, unlike scene_manager.Ticks(), which starts from zero on each launch
+type WorldTime uint64
+
+// Day returns the number of full days, passed since the world creation
+func (t WorldTime) Day() uint64 {
+	return uint64(t) / config.DayLength
+}
+
+// TimeOfDay returns a value from 0 to 1.
+// 0 is the sunrise, 0.25 is the noon, 0.5 is the sunset and 0.75 is the midnight
+func (t WorldTime) TimeOfDay() float64 {
+	return float64(uint64(t)%config.DayLength) / float64(config.DayLength)
+}
+
+func (t WorldTime) IsNight() bool {
+	return t.TimeOfDay() >= 0.5
+}
+
+// Daylight returns the brightness of the sky, from 0 at night to 1 at day.
+// The sun rises and sets smoothly, instead of switching instantly
+func (t WorldTime) Daylight() float64 {
+	return math.Max(0, math.Min(1, 0.5+2*math.Sin(2*math.Pi*t.TimeOfDay())))
+}
@@ -1,23 +1,36 @@
 package types
 
 import (
+	"github.com/3elDU/bamboo/event"
 	"github.com/3elDU/bamboo/world_type"
 	"github.com/google/uuid"
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type World interface {
 	BlockAt(bx uint64, by uint64) Block
 	SetBlock(bx, by uint64, block Block)
+	// Changes, made by the player. In multiplayer they are checked by the server
+	PlaceBlock(bx, by uint64, block Block)
+	BreakBlock(bx, by uint64)
 	CheckNeighbors(cx uint64, cy uint64) bool
 	ChunkAt(cx uint64, cy uint64) Chunk
 	ChunkAtB(bx uint64, by uint64) Chunk
 	ChunkExists(cx uint64, cy uint64) bool
 	GetNeighbors(cx uint64, cy uint64) []Chunk
+	// Adds the entity to the chunk at its position
+	AddEntity(entity Entity)
+	RemoveEntity(entity Entity)
+	// Returns entities from loaded chunks, which are closer than radius to pos
+	EntitiesAround(pos Vec2f, radius float64) []Entity
 	// Returns world generator associated with this world
 	Generator() WorldGenerator
 	Metadata() Save
-	Render(screen *ebiten.Image, playerX float64, playerY float64, scaling float64)
+	// Current time of the world
+	Time() WorldTime
+	SetTime(time WorldTime)
+	// Events of this world, see types/events.go
+	Events() *event.Bus
+	worldGraphics
 	Save()
 	Seed() int64
 	Update()
@@ -33,4 +46,8 @@ type Save struct {
 	UUID      uuid.UUID
 	Seed      int64
 	WorldType world_type.WorldType
+	// Version of the save format, the world was saved with
+	Version uint
+	// World clock, advanced on each World.Update()
+	Time WorldTime
 }
@@ -389,6 +389,97 @@ func (b *ButtonComponent) Press() {
 	b.pressed = true
 }
 
+// size of the image button, before scaling. Same as the inventory slot
+const imageButtonSize = 20
+
+// ImageButtonComponent is a small square button with an image in the center, like an inventory slot
+type ImageButtonComponent struct {
+	baseView
+
+	// nil for an empty button
+	image *ebiten.Image
+	// 1x1 textures, stretched to the size of the button
+	bg, bgHover *ebiten.Image
+	opts        *ebiten.DrawImageOptions
+
+	// same as in ButtonComponent
+	pressed bool
+	handler func()
+}
+
+func ImageButton(handler func(), image *ebiten.Image) *ImageButtonComponent {
+	bg := ebiten.NewImage(1, 1)
+	bg.Fill(color.RGBA{A: 128})
+	bgHover := ebiten.NewImage(1, 1)
+	bgHover.Fill(color.RGBA{R: 255, G: 255, B: 255, A: 64})
+
+	return &ImageButtonComponent{
+		baseView: newBaseView(),
+
+		image:   image,
+		bg:      bg,
+		bgHover: bgHover,
+		opts:    &ebiten.DrawImageOptions{},
+
+		handler: handler,
+	}
+}
+func (b *ImageButtonComponent) MaxSize() (float64, float64) {
+	return b.ComputedSize()
+}
+func (b *ImageButtonComponent) ComputedSize() (float64, float64) {
+	return imageButtonSize * config.UIScaling, imageButtonSize * config.UIScaling
+}
+func (b *ImageButtonComponent) CapacityForChild(_ View) (float64, float64) {
+	return 0, 0
+}
+func (b *ImageButtonComponent) Children() []View {
+	return []View{}
+}
+func (b *ImageButtonComponent) Update() error {
+	if b.pressed {
+		go b.handler()
+	}
+	b.pressed = false
+	return nil
+}
+func (b *ImageButtonComponent) Draw(screen *ebiten.Image, x, y float64) error {
+	w, h := b.ComputedSize()
+	cx, cy := ebiten.CursorPosition()
+	mouseOver := float64(cx) > x && float64(cy) > y && float64(cx) < x+w && float64(cy) < y+h
+
+	// leave a gap between the neighbouring buttons
+	b.opts.GeoM.Reset()
+	b.opts.GeoM.Scale(w-config.UIScaling, h-config.UIScaling)
+	b.opts.GeoM.Translate(x, y)
+	if mouseOver {
+		screen.DrawImage(b.bgHover, b.opts)
+	} else {
+		screen.DrawImage(b.bg, b.opts)
+	}
+
+	if mouseOver && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		b.pressed = true
+	}
+
+	if b.image == nil {
+		return nil
+	}
+	// draw the image in the center
+	iw, ih := b.image.Size()
+	b.opts.GeoM.Reset()
+	b.opts.GeoM.Scale(config.UIScaling, config.UIScaling)
+	b.opts.GeoM.Translate(x+w/2-float64(iw)*config.UIScaling/2, y+h/2-float64(ih)*config.UIScaling/2)
+	screen.DrawImage(b.image, b.opts)
+	return nil
+}
+func (b *ImageButtonComponent) IsPressed() bool {
+	return b.pressed
+}
+func (b *ImageButtonComponent) Press() {
+	b.pressed = true
+}
+
 type BackgroundImageRenderingMode uint
 
 const (
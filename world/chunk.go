@@ -3,17 +3,18 @@ package world
 import (
 	"log"
 
-	"github.com/3elDU/bamboo/scene_manager"
+	"github.com/3elDU/bamboo/clock"
 	"github.com/3elDU/bamboo/types"
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
 type Chunk struct {
 	// those are chunk coordinates, not block coordinates
 	x, y   uint64
 	blocks [16][16]types.Block
+	// entities, standing in this chunk
+	entities []types.Entity
 
-	texture *ebiten.Image
+	chunkGraphics
 
 	// Whether a chunk has been modified since last update
 	modified bool
@@ -21,16 +22,22 @@ type Chunk struct {
 	// resets on Chunk.Render()
 	needsRedraw  bool
 	lastAccessed uint64
+	// set for placeholder chunks, shown until the real chunk is generated, loaded or received
+	dummy bool
+
+	// cached light levels, recomputed on the next render, if lightDirty is set
+	light      [16][16]uint8
+	lightDirty bool
 }
 
 // NewChunk creates new empty Chunk at specified chunk coordinates
 func NewChunk(cx, cy uint64) *Chunk {
 	return &Chunk{
 		x: cx, y: cy,
-		texture:      ebiten.NewImage(256, 256),
 		modified:     true,
 		needsRedraw:  true,
-		lastAccessed: scene_manager.Ticks(),
+		lightDirty:   true,
+		lastAccessed: clock.Ticks(),
 	}
 }
 
@@ -56,7 +63,7 @@ func (c *Chunk) At(x, y uint) types.Block {
 	if x > 16 || y > 16 {
 		log.Panicf("invalid coordinates: %v, %v", x, y)
 	}
-	c.lastAccessed = scene_manager.Ticks()
+	c.lastAccessed = clock.Ticks()
 	return c.blocks[x][y]
 }
 
@@ -68,15 +75,13 @@ func (c *Chunk) SetBlock(x, y uint, block types.Block) {
 	block.SetParentChunk(c)
 	block.SetCoords(types.Vec2u{X: c.x*16 + uint64(x), Y: c.y*16 + uint64(y)})
 	c.blocks[x][y] = block
-	c.lastAccessed = scene_manager.Ticks()
+	c.lastAccessed = clock.Ticks()
 	c.modified = true
 	c.needsRedraw = true
+	// the neighbors are invalidated by World.SetBlock
+	c.lightDirty = true
 }
 
 func (c *Chunk) TriggerRedraw() {
 	c.needsRedraw = true
 }
-
-func (c *Chunk) Texture() *ebiten.Image {
-	return c.texture
-}
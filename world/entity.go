@@ -0,0 +1,273 @@
+// Entities - everything in the world, that isn't bound to the block grid
+//
+// Each entity belongs to the chunk it is standing in, and is saved and unloaded with it.
+// Entities of types, that aren't registered with RegisterEntity ( like the player ), are never saved with chunks.
+
+package world
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/types"
+)
+
+type registeredEntity struct {
+	constructor func() types.Entity
+}
+
+var entityRegistry = make(map[types.EntityType]registeredEntity)
+
+// RegisterEntity makes the entity type persistent: entities of this type are saved with their chunk
+// constructor must return an entity, which state then can be loaded with LoadState()
+// state is a zero value of the entity state type, it is registered with gob
+func RegisterEntity(entityType types.EntityType, constructor func() types.Entity, state interface{}) {
+	if _, exists := entityRegistry[entityType]; exists {
+		log.Panicf("RegisterEntity() - entity %v is already registered", entityType)
+	}
+	if state != nil {
+		gob.Register(state)
+	}
+	entityRegistry[entityType] = registeredEntity{constructor: constructor}
+}
+
+// EntityRegistered returns true, if the entities of the type are saved with the chunks
+func EntityRegistered(entityType types.EntityType) bool {
+	_, registered := entityRegistry[entityType]
+	return registered
+}
+
+// NewEntity creates an entity of the registered type, and loads its state
+func NewEntity(entityType types.EntityType, state interface{}) (types.Entity, error) {
+	registered, exists := entityRegistry[entityType]
+	if !exists {
+		return nil, fmt.Errorf("unknown entity type %v", entityType)
+	}
+	entity := registered.constructor()
+	if err := entity.LoadState(state); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// Spawner populates a freshly generated chunk with entities
+// Chunks, loaded from the disk, already have their entities, so spawners aren't called for them
+type Spawner func(world types.World, chunk types.Chunk)
+
+var spawners []Spawner
+
+func RegisterSpawner(spawner Spawner) {
+	spawners = append(spawners, spawner)
+}
+
+func (world *World) spawnEntities(chunk *Chunk) {
+	for _, spawner := range spawners {
+		spawner(world, chunk)
+	}
+}
+
+// saved with the chunk, same as SavedBlock
+type SavedEntity struct {
+	Type  types.EntityType
+	State interface{}
+}
+
+// BaseEntity implements position and velocity, so entities don't have to do it themselves
+type BaseEntity struct {
+	position types.Vec2f
+	velocity types.Vec2f
+}
+
+// BaseEntityState is meant to be embedded into states of the entities
+type BaseEntityState struct {
+	Position types.Vec2f
+	Velocity types.Vec2f
+}
+
+func (e *BaseEntity) Position() types.Vec2f {
+	return e.position
+}
+
+func (e *BaseEntity) SetPosition(pos types.Vec2f) {
+	e.position = pos
+}
+
+func (e *BaseEntity) Velocity() types.Vec2f {
+	return e.velocity
+}
+
+func (e *BaseEntity) SetVelocity(velocity types.Vec2f) {
+	e.velocity = velocity
+}
+
+func (e *BaseEntity) State() interface{} {
+	return BaseEntityState{Position: e.position, Velocity: e.velocity}
+}
+
+func (e *BaseEntity) LoadState(s interface{}) error {
+	state, ok := s.(BaseEntityState)
+	if !ok {
+		return fmt.Errorf("%T - invalid state type; expected %T, got %T", e, BaseEntityState{}, s)
+	}
+	e.position = state.Position
+	e.velocity = state.Velocity
+	return nil
+}
+
+// chunk coordinates of the entity
+func entityChunk(entity types.Entity) types.Vec2u {
+	pos := entity.Position()
+	return types.Vec2u{X: uint64(math.Max(pos.X, 0)) / 16, Y: uint64(math.Max(pos.Y, 0)) / 16}
+}
+
+func (c *Chunk) addEntity(entity types.Entity) {
+	c.entities = append(c.entities, entity)
+	if _, persistent := entityRegistry[entity.Type()]; persistent {
+		c.modified = true
+	}
+}
+
+// returns false, if the entity isn't in the chunk
+func (c *Chunk) removeEntity(entity types.Entity) bool {
+	for i, e := range c.entities {
+		if e != entity {
+			continue
+		}
+		c.entities = append(c.entities[:i], c.entities[i+1:]...)
+		if _, persistent := entityRegistry[entity.Type()]; persistent {
+			c.modified = true
+		}
+		return true
+	}
+	return false
+}
+
+func (c *Chunk) Entities() []types.Entity {
+	return c.entities
+}
+
+func (c *Chunk) savedEntities() (saved []SavedEntity) {
+	for _, entity := range c.entities {
+		if _, persistent := entityRegistry[entity.Type()]; !persistent {
+			continue
+		}
+		saved = append(saved, SavedEntity{Type: entity.Type(), State: entity.State()})
+	}
+	return
+}
+
+// loads entities, saved with the chunk
+// Entities of unknown types are skipped, so removing an entity type doesn't break old saves
+func (c *Chunk) loadEntities(saved []SavedEntity) error {
+	for _, savedEntity := range saved {
+		if !EntityRegistered(savedEntity.Type) {
+			log.Printf("Chunk.loadEntities() - skipping entity of unknown type %v", savedEntity.Type)
+			continue
+		}
+
+		entity, err := NewEntity(savedEntity.Type, savedEntity.State)
+		if err != nil {
+			return err
+		}
+		c.entities = append(c.entities, entity)
+	}
+	return nil
+}
+
+func (world *World) AddEntity(entity types.Entity) {
+	coords := entityChunk(entity)
+	world.ChunkAt(coords.X, coords.Y)
+	world.chunks[coords].addEntity(entity)
+}
+
+func (world *World) RemoveEntity(entity types.Entity) {
+	if chunk, exists := world.chunks[entityChunk(entity)]; exists && chunk.removeEntity(entity) {
+		return
+	}
+	// the entity might have moved without updating its chunk
+	for _, chunk := range world.chunks {
+		if chunk.removeEntity(entity) {
+			return
+		}
+	}
+}
+
+func (world *World) EntitiesAround(pos types.Vec2f, radius float64) (entities []types.Entity) {
+	minX, minY := uint64(math.Max(pos.X-radius, 0))/16, uint64(math.Max(pos.Y-radius, 0))/16
+	maxX, maxY := uint64(math.Max(pos.X+radius, 0))/16, uint64(math.Max(pos.Y+radius, 0))/16
+
+	for cx := minX; cx <= maxX; cx++ {
+		for cy := minY; cy <= maxY; cy++ {
+			chunk, exists := world.chunks[types.Vec2u{X: cx, Y: cy}]
+			if !exists {
+				continue
+			}
+			for _, entity := range chunk.entities {
+				p := entity.Position()
+				if math.Hypot(p.X-pos.X, p.Y-pos.Y) < radius {
+					entities = append(entities, entity)
+				}
+			}
+		}
+	}
+	return
+}
+
+// updates all entities in loaded chunks, and moves those who crossed the chunk border to their new chunk
+func (world *World) updateEntities() {
+	type move struct {
+		entity types.Entity
+		from   *Chunk
+	}
+	var moves []move
+
+	// collect entities first, since an update can add or remove entities
+	var entities []types.Entity
+	owners := make(map[types.Entity]*Chunk)
+	for _, chunk := range world.chunks {
+		for _, entity := range chunk.entities {
+			entities = append(entities, entity)
+			owners[entity] = chunk
+		}
+	}
+
+	for _, entity := range entities {
+		_, persistent := entityRegistry[entity.Type()]
+		// on remote worlds, they are simulated by the server, and only moved by its updates
+		if persistent && world.remote != nil {
+			continue
+		}
+		var state interface{}
+		if persistent {
+			state = entity.State()
+		}
+
+		entity.Update(world)
+
+		// keep entities inside the world borders
+		pos := entity.Position()
+		pos.X = math.Min(math.Max(pos.X, 0), float64(config.WorldWidth)-0.01)
+		pos.Y = math.Min(math.Max(pos.Y, 0), float64(config.WorldHeight)-0.01)
+		entity.SetPosition(pos)
+
+		chunk := owners[entity]
+		// the chunk is saved again only if the entity has changed, e.g. moved.
+		// Entities, standing still, don't make the saver rewrite their chunks every tick
+		if persistent && !reflect.DeepEqual(state, entity.State()) {
+			chunk.modified = true
+		}
+		if entityChunk(entity) != chunk.Coords() {
+			moves = append(moves, move{entity: entity, from: chunk})
+		}
+	}
+
+	for _, m := range moves {
+		if m.from.removeEntity(m.entity) {
+			world.AddEntity(m.entity)
+		}
+	}
+}
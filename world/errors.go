@@ -0,0 +1,36 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors, that could happen while loading a world.
+// Use errors.Is() to check the kind of LoadError
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrCorrupt         = errors.New("corrupt")
+	ErrVersionMismatch = errors.New("saved with a newer format version")
+)
+
+// LoadError describes what went wrong while loading a world, a chunk or a player
+type LoadError struct {
+	// One of ErrNotFound, ErrCorrupt, ErrVersionMismatch
+	Kind error
+	// Path to the file, that failed to load
+	Path string
+	// The underlying error
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("%v: %v - %v", e.Path, e.Kind, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+func (e *LoadError) Is(target error) bool {
+	return target == e.Kind
+}
@@ -1,14 +1,39 @@
+//go:build !headless
+
 package world
 
 import (
+	"image/color"
 	"math"
+	"sort"
 
 	"github.com/3elDU/bamboo/config"
 	"github.com/3elDU/bamboo/types"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+// Opacity of the darkness overlay at zero light level.
+// Even the darkest places are slightly visible
+const maxDarkness = 0.9
+
+type chunkGraphics struct {
+	// created on the first render
+	texture *ebiten.Image
+}
+
+func (c *Chunk) Texture() *ebiten.Image {
+	if c.texture == nil {
+		c.texture = ebiten.NewImage(256, 256)
+	}
+	return c.texture
+}
+
 func (c *Chunk) Render(world types.World) {
+	if c.lightDirty {
+		c.computeLight(world)
+		c.needsRedraw = true
+	}
+
 	// do not redraw a chunk, when there is no need to
 	if !c.needsRedraw {
 		return
@@ -29,6 +54,7 @@ func (c *Chunk) Render(world types.World) {
 			})
 		}
 	}
+	c.renderDarkness()
 
 	c.needsRedraw = false
 }
@@ -66,4 +92,59 @@ func (world *World) Render(screen *ebiten.Image, playerX, playerY, scaling float
 			screen.DrawImage(chunk.Texture(), opts)
 		}
 	}
+
+	world.renderEntities(screen, playerX, playerY, scaling)
+}
+
+// a black pixel, which is stretched over the dark blocks
+var darknessTexture *ebiten.Image
+
+// draws the darkness over the chunk texture, according to the light levels
+func (c *Chunk) renderDarkness() {
+	if darknessTexture == nil {
+		darknessTexture = ebiten.NewImage(1, 1)
+		darknessTexture.Fill(color.Black)
+	}
+
+	opts := &ebiten.DrawImageOptions{}
+	for x := range c.light {
+		for y := range c.light[x] {
+			level := c.light[x][y]
+			if level >= types.MaxLightLevel {
+				continue
+			}
+
+			opts.GeoM.Reset()
+			opts.GeoM.Scale(16, 16)
+			opts.GeoM.Translate(float64(x)*16, float64(y)*16)
+			opts.ColorM.Reset()
+			opts.ColorM.Scale(1, 1, 1, maxDarkness*(1-float64(level)/float64(types.MaxLightLevel)))
+			c.Texture().DrawImage(darknessTexture, opts)
+		}
+	}
+}
+
+// renders entities of the visible chunks on top of the blocks
+// entities are sorted by Y coordinate, so the lower ones overlap the upper ones
+func (world *World) renderEntities(screen *ebiten.Image, playerX, playerY, scaling float64) {
+	screenWidth, screenHeight := screen.Size()
+	halfWidth := float64(screenWidth) / 2 / scaling / 16
+	halfHeight := float64(screenHeight) / 2 / scaling / 16
+
+	// a margin of one block, so entities partially visible on the border are drawn as well
+	entities := world.EntitiesAround(
+		types.Vec2f{X: playerX, Y: playerY},
+		math.Hypot(halfWidth, halfHeight)+1,
+	)
+	sort.SliceStable(entities, func(i, j int) bool {
+		return entities[i].Position().Y < entities[j].Position().Y
+	})
+
+	for _, entity := range entities {
+		pos := entity.Position()
+		entity.Render(screen, types.Vec2f{
+			X: (pos.X-playerX)*16*scaling + float64(screenWidth)/2,
+			Y: (pos.Y-playerY)*16*scaling + float64(screenHeight)/2,
+		}, scaling)
+	}
 }
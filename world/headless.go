@@ -0,0 +1,6 @@
+//go:build headless
+
+package world
+
+// Without the graphics, chunks are never rendered ( see gx.go )
+type chunkGraphics struct{}
@@ -0,0 +1,204 @@
+// Journal of pending chunk writes
+//
+// Writing a chunk into a region isn't atomic - a crash in the middle leaves a half-written chunk.
+// So, before touching the regions, chunks are appended to the journal and flushed to the disk.
+// Once the regions are written and flushed as well, the journal is cleared.
+// If the game crashes in between, the journal is replayed on the next world load.
+
+package world
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/3elDU/bamboo/types"
+)
+
+const (
+	journalFile = "journal.bin"
+	// encoded chunks are way smaller than that,
+	// anything bigger is surely a garbage left after the crash
+	maxChunkRecordLength = 1 << 24
+)
+
+// Header of a single journal record, followed by Length bytes of chunk data
+type journalRecordHeader struct {
+	X, Y     uint64
+	Length   uint32
+	Checksum uint32
+}
+
+// Chunk, encoded and ready to be written to the region
+type chunkRecord struct {
+	X, Y uint64
+	Data []byte
+}
+
+type journal struct {
+	mutex    sync.Mutex
+	metadata types.Save
+	file     *os.File
+}
+
+// Journals are shared between the saver goroutine and the main thread, same as regions
+var (
+	journalsMutex sync.Mutex
+	journals      = make(map[string]*journal)
+)
+
+func journalFor(metadata types.Save) (*journal, error) {
+	path := filepath.Join(saveDirectory(metadata), journalFile)
+
+	journalsMutex.Lock()
+	defer journalsMutex.Unlock()
+
+	if j, exists := journals[path]; exists {
+		return j, nil
+	}
+
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &journal{metadata: metadata, file: f}
+	journals[path] = j
+	return j, nil
+}
+
+// closes all opened journals under the given directory
+func closeJournals(dir string) {
+	journalsMutex.Lock()
+	defer journalsMutex.Unlock()
+
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	for path, j := range journals {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		j.file.Close()
+		delete(journals, path)
+	}
+}
+
+// commit durably writes given chunks to their regions
+func (j *journal) commit(records []chunkRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	// write all the records to the journal first
+	buf := new(bytes.Buffer)
+	for _, record := range records {
+		binary.Write(buf, binary.LittleEndian, journalRecordHeader{
+			X: record.X, Y: record.Y,
+			Length:   uint32(len(record.Data)),
+			Checksum: crc32.ChecksumIEEE(record.Data),
+		})
+		buf.Write(record.Data)
+	}
+	if _, err := j.file.WriteAt(buf.Bytes(), 0); err != nil {
+		return err
+	}
+	if err := j.file.Truncate(int64(buf.Len())); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+
+	// now, when the records are safe, write them to the regions
+	if err := writeRecords(j.metadata, records); err != nil {
+		return err
+	}
+
+	return j.clear()
+}
+
+func (j *journal) clear() error {
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// writes the records to their regions, and flushes touched regions to the disk
+func writeRecords(metadata types.Save, records []chunkRecord) error {
+	touched := make(map[*regionFile]bool)
+	for _, record := range records {
+		region, err := regionFor(metadata, record.X, record.Y, true)
+		if err != nil {
+			return err
+		}
+		if err := region.write(record.X, record.Y, record.Data); err != nil {
+			return err
+		}
+		touched[region] = true
+	}
+
+	for region := range touched {
+		if err := region.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRecords reads all complete and valid records from the journal.
+// A record, torn by the crash, and everything after it is ignored -
+// it never made it to the regions, so the old chunk is still there.
+func readRecords(r io.Reader) (records []chunkRecord) {
+	for {
+		var header journalRecordHeader
+		if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+			return
+		}
+
+		// a garbage header could request an enormous allocation
+		if header.Length > maxChunkRecordLength {
+			return
+		}
+
+		data := make([]byte, header.Length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return
+		}
+		if crc32.ChecksumIEEE(data) != header.Checksum {
+			return
+		}
+
+		records = append(records, chunkRecord{X: header.X, Y: header.Y, Data: data})
+	}
+}
+
+// replay writes chunks left in the journal after a crash to the regions
+// Returns number of replayed chunks
+func (j *journal) replay() (int, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	records := readRecords(bufio.NewReader(j.file))
+
+	if err := writeRecords(j.metadata, records); err != nil {
+		return 0, err
+	}
+	if err := j.clear(); err != nil {
+		return 0, err
+	}
+
+	return len(records), nil
+}
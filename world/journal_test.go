@@ -0,0 +1,128 @@
+//go:build headless
+
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/types"
+	"github.com/google/uuid"
+)
+
+// newTestSave returns metadata of a world in a temporary directory.
+// Files of the world are closed, when the test ends
+func newTestSave(t *testing.T) types.Save {
+	t.Helper()
+	config.WorldSaveDirectory = t.TempDir()
+	metadata := types.Save{Name: "Test", BaseUUID: uuid.New(), UUID: uuid.New(), Seed: 1, Version: FormatVersion}
+	t.Cleanup(func() {
+		dir := saveDirectory(metadata)
+		closeRegions(dir)
+		closeJournals(dir)
+		closePalettes(dir)
+	})
+	return metadata
+}
+
+// encodes the records, the same way journal.commit() does
+func encodeJournal(records []chunkRecord) []byte {
+	buf := new(bytes.Buffer)
+	for _, record := range records {
+		binary.Write(buf, binary.LittleEndian, journalRecordHeader{
+			X: record.X, Y: record.Y,
+			Length:   uint32(len(record.Data)),
+			Checksum: crc32.ChecksumIEEE(record.Data),
+		})
+		buf.Write(record.Data)
+	}
+	return buf.Bytes()
+}
+
+func TestJournalReplay(t *testing.T) {
+	first := chunkRecord{X: 0, Y: 0, Data: []byte("first chunk")}
+	second := chunkRecord{X: 1, Y: 0, Data: []byte("second chunk")}
+	complete := encodeJournal([]chunkRecord{first, second})
+	// the second record, with a damaged byte, followed by a valid one
+	damaged := encodeJournal([]chunkRecord{first, second})
+	damaged[len(damaged)-1] ^= 0xff
+	damaged = append(damaged, encodeJournal([]chunkRecord{{X: 2, Y: 0, Data: []byte("third chunk")}})...)
+
+	tests := []struct {
+		name    string
+		journal []byte
+		// chunks, expected in the regions after the replay
+		want []chunkRecord
+	}{
+		{
+			name: "empty journal",
+		},
+		{
+			name:    "complete records are replayed",
+			journal: complete,
+			want:    []chunkRecord{first, second},
+		},
+		{
+			name:    "torn record is dropped",
+			journal: complete[:len(complete)-3],
+			want:    []chunkRecord{first},
+		},
+		{
+			name:    "torn header is dropped",
+			journal: complete[:len(encodeJournal([]chunkRecord{first}))+5],
+			want:    []chunkRecord{first},
+		},
+		{
+			name:    "record with a wrong checksum, and everything after it are dropped",
+			journal: damaged,
+			want:    []chunkRecord{first},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := newTestSave(t)
+			os.MkdirAll(saveDirectory(metadata), os.ModePerm)
+			if err := os.WriteFile(filepath.Join(saveDirectory(metadata), journalFile), tt.journal, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			j, err := journalFor(metadata)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replayed, err := j.replay()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replayed != len(tt.want) {
+				t.Errorf("replayed %v chunks, expected %v", replayed, len(tt.want))
+			}
+
+			for _, record := range tt.want {
+				region, err := regionFor(metadata, record.X, record.Y, false)
+				if err != nil || region == nil {
+					t.Fatalf("chunk %v, %v - no region (%v)", record.X, record.Y, err)
+				}
+				data, err := region.read(record.X, record.Y)
+				if err != nil || !bytes.Equal(data, record.Data) {
+					t.Errorf("chunk %v, %v - read %q (%v), expected %q", record.X, record.Y, data, err, record.Data)
+				}
+			}
+
+			// the journal is cleared, so it isn't replayed again
+			info, err := os.Stat(filepath.Join(saveDirectory(metadata), journalFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size() != 0 {
+				t.Errorf("journal is %v bytes long after the replay", info.Size())
+			}
+		})
+	}
+}
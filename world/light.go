@@ -0,0 +1,156 @@
+/*
+	Block lighting.
+	Each chunk caches the light level of its blocks, which is the maximum of the sky light and the light from emitters.
+	The sky light follows the time of day, so the light of all chunks is recomputed, when it changes.
+	Light from emitters loses one level per block, and doesn't pass through collidable blocks.
+	Since the light fades before travelling a whole chunk, only the chunk and its neighbors
+	have to be looked at, when the light is computed, or a block is changed.
+*/
+
+package world
+
+import (
+	"math"
+
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/world_type"
+)
+
+// emitters further than that from the chunk can't reach it
+const lightMargin = int(types.MaxLightLevel) - 1
+
+// Sky light at midnight, so that the overworld is still visible without torches
+const nightSkyLight uint8 = 4
+
+// sky light of the world, as if there were no emitters
+func skyLight(world types.World) uint8 {
+	return skyLightAt(world.Metadata().WorldType, world.Time())
+}
+
+func skyLightAt(worldType world_type.WorldType, time types.WorldTime) uint8 {
+	// caves are lit only by emitters
+	if worldType == world_type.Cave {
+		return 0
+	}
+	return nightSkyLight + uint8(math.Round(time.Daylight()*float64(types.MaxLightLevel-nightSkyLight)))
+}
+
+// invalidateSkyLight marks all chunks for the light recomputation, if the sky light has changed since the previous time
+func (world *World) invalidateSkyLight(previous types.WorldTime) {
+	worldType := world.metadata.WorldType
+	if skyLightAt(worldType, previous) == skyLightAt(worldType, world.metadata.Time) {
+		return
+	}
+	for _, chunk := range world.chunks {
+		chunk.lightDirty = true
+	}
+}
+
+func lightLevelOf(block types.Block) uint8 {
+	if emitter, ok := block.(types.LightEmitter); ok {
+		return emitter.LightLevel()
+	}
+	return 0
+}
+
+func isOpaque(block types.Block) bool {
+	collidable, ok := block.(types.CollidableBlock)
+	return ok && collidable.Collidable()
+}
+
+// LightAt returns cached light level of the block, in chunk coordinates
+func (c *Chunk) LightAt(x, y uint) uint8 {
+	return c.light[x][y]
+}
+
+// computeLight fills the light cache of the chunk
+func (c *Chunk) computeLight(world types.World) {
+	c.lightDirty = false
+
+	sky := skyLight(world)
+	if sky == types.MaxLightLevel {
+		for x := range c.light {
+			for y := range c.light[x] {
+				c.light[x][y] = sky
+			}
+		}
+		return
+	}
+
+	// the chunk, surrounded by margins from the neighbor chunks
+	const size = 16 + lightMargin*2
+	var (
+		levels [size][size]uint8
+		opaque [size][size]bool
+		// emitted light is spread from the brightest blocks to the darkest,
+		// so each block is visited only once, with its final level
+		queues [types.MaxLightLevel + 1][][2]int
+	)
+
+	originX := int64(c.x*16) - int64(lightMargin)
+	originY := int64(c.y*16) - int64(lightMargin)
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			bx, by := originX+int64(x), originY+int64(y)
+			if bx < 0 || by < 0 {
+				opaque[x][y] = true
+				continue
+			}
+
+			// BlockAt returns an empty block for chunks, that aren't loaded
+			block := world.BlockAt(uint64(bx), uint64(by))
+			opaque[x][y] = isOpaque(block)
+			if level := lightLevelOf(block); level > 0 {
+				levels[x][y] = level
+				queues[level] = append(queues[level], [2]int{x, y})
+			}
+		}
+	}
+
+	for level := types.MaxLightLevel; level > 1; level-- {
+		for _, pos := range queues[level] {
+			// the block was lit brighter by some other emitter
+			if levels[pos[0]][pos[1]] != level {
+				continue
+			}
+
+			for _, side := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				x, y := pos[0]+side[0], pos[1]+side[1]
+				if x < 0 || y < 0 || x >= size || y >= size || levels[x][y] >= level-1 {
+					continue
+				}
+				levels[x][y] = level - 1
+				// light reaches the walls, but doesn't pass through them
+				if !opaque[x][y] {
+					queues[level-1] = append(queues[level-1], [2]int{x, y})
+				}
+			}
+		}
+	}
+
+	for x := range c.light {
+		for y := range c.light[x] {
+			c.light[x][y] = levels[x+lightMargin][y+lightMargin]
+			if c.light[x][y] < sky {
+				c.light[x][y] = sky
+			}
+		}
+	}
+}
+
+// invalidateLight marks the chunk and all its neighbors, including diagonal ones, for the light recomputation.
+// Called, when the block at the given position changes, or the chunk is loaded
+func (world *World) invalidateLight(cx, cy uint64) {
+	chunk, exists := world.chunks[types.Vec2u{X: cx, Y: cy}]
+	if exists {
+		chunk.lightDirty = true
+	}
+
+	// neighbors of the neighbors include the diagonal chunks
+	for _, neighbor := range world.GetNeighbors(cx, cy) {
+		neighbor.(*Chunk).lightDirty = true
+		for _, diagonal := range world.GetNeighbors(neighbor.Coords().X, neighbor.Coords().Y) {
+			diagonal.(*Chunk).lightDirty = true
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Save format versioning
+
+package world
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/3elDU/bamboo/blocks"
+)
+
+// FormatVersion is the version of the save format, written by this build of the game.
+// It is stamped into world metadata and into each saved chunk.
+// Bump it every time a block state changes, and register a migration from the previous version.
+//
+// Version 0 is used by saves, created before the versioning was introduced
+const FormatVersion uint = 2
+
+// BlockMigration upgrades a saved block from one format version to the next one
+type BlockMigration func(block SavedBlock) (SavedBlock, error)
+
+// blockMigrations[N] upgrades blocks from version N to N+1
+var blockMigrations = make(map[uint]BlockMigration)
+
+func init() {
+	// Version 1 only introduced the version field itself, block states are left as is
+	RegisterBlockMigration(0, func(block SavedBlock) (SavedBlock, error) {
+		return block, nil
+	})
+	// Version 2 moved simple blocks to data files, their states were replaced with blocks.DefinedBlockState
+	RegisterBlockMigration(1, func(block SavedBlock) (SavedBlock, error) {
+		block.State = blocks.UpgradeLegacyState(block.State)
+		return block, nil
+	})
+}
+
+// RegisterBlockMigration registers a migration from version `from` to version `from+1`
+func RegisterBlockMigration(from uint, migration BlockMigration) {
+	if _, exists := blockMigrations[from]; exists {
+		log.Panicf("RegisterBlockMigration() - migration from version %v is already registered", from)
+	}
+	blockMigrations[from] = migration
+}
+
+// migrateChunk upgrades all blocks in the chunk step by step, until it reaches FormatVersion
+// Returns true, if the chunk has been modified
+func migrateChunk(chunk *SavedChunk) (bool, error) {
+	if chunk.Version > FormatVersion {
+		return false, fmt.Errorf("chunk %v, %v was saved with newer format version %v (supported up to %v)",
+			chunk.X, chunk.Y, chunk.Version, FormatVersion)
+	}
+
+	migrated := chunk.Version != FormatVersion
+	for ; chunk.Version < FormatVersion; chunk.Version++ {
+		migration, exists := blockMigrations[chunk.Version]
+		if !exists {
+			return false, fmt.Errorf("no migration from format version %v", chunk.Version)
+		}
+
+		for x := 0; x < 16; x++ {
+			for y := 0; y < 16; y++ {
+				block, err := migration(chunk.Data[x][y])
+				if err != nil {
+					return false, fmt.Errorf("failed to migrate block %v, %v of chunk %v, %v from version %v - %v",
+						x, y, chunk.X, chunk.Y, chunk.Version, err)
+				}
+				chunk.Data[x][y] = block
+			}
+		}
+	}
+
+	return migrated, nil
+}
@@ -0,0 +1,86 @@
+//go:build headless
+
+package world
+
+import (
+	"testing"
+
+	"github.com/3elDU/bamboo/blocks"
+)
+
+func TestMigrateChunk(t *testing.T) {
+	snow := blocks.SnowState{TexturedBlockState: blocks.TexturedBlockState{Name: "snow", Rotation: 2}}
+	upgraded := blocks.DefinedBlockState{Rotation: 2}
+
+	tests := []struct {
+		name     string
+		version  uint
+		state    interface{}
+		want     interface{}
+		migrated bool
+		wantErr  bool
+	}{
+		{
+			name:     "saves without a version are upgraded",
+			version:  0,
+			state:    snow,
+			want:     upgraded,
+			migrated: true,
+		},
+		{
+			name:     "legacy states are replaced",
+			version:  1,
+			state:    snow,
+			want:     upgraded,
+			migrated: true,
+		},
+		{
+			name:    "current version is left as is",
+			version: FormatVersion,
+			state:   upgraded,
+			want:    upgraded,
+		},
+		{
+			name:    "newer version is rejected",
+			version: FormatVersion + 1,
+			state:   upgraded,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunk := &SavedChunk{Version: tt.version}
+			for x := range chunk.Data {
+				for y := range chunk.Data[x] {
+					chunk.Data[x][y].State = tt.state
+				}
+			}
+
+			migrated, err := migrateChunk(chunk)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("migrated a chunk from a newer version")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if migrated != tt.migrated {
+				t.Errorf("migrated - %v, expected %v", migrated, tt.migrated)
+			}
+			if chunk.Version != FormatVersion {
+				t.Errorf("chunk version is %v after the migration, expected %v", chunk.Version, FormatVersion)
+			}
+			for x := range chunk.Data {
+				for y := range chunk.Data[x] {
+					if chunk.Data[x][y].State != tt.want {
+						t.Fatalf("block %v, %v - state %#v, expected %#v", x, y, chunk.Data[x][y].State, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Block palette
+//
+// Numeric block types depend on the order of blocks in the code, so they can't be saved as is.
+// Instead, each world keeps a palette, mapping saved block IDs to stable block names.
+// IDs in the palette are never reassigned, new blocks are simply appended to the end.
+
+package world
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/util"
+)
+
+const paletteFile = "palette.gob"
+
+// Block types, as they were numbered before the palette was introduced.
+// Worlds without a palette file are decoded with it
+var legacyPalette = []string{
+	"empty", "stone", "water", "sand", "grass", "snow",
+	"short_grass", "tall_grass", "flowers", "pine_tree",
+	"red_mushroom", "white_mushroom", "cave_entrance", "cave_wall", "cave_floor",
+}
+
+// represents the palette on the disk
+// index in the slice is the saved block ID
+type SavedPalette struct {
+	Names []string
+}
+
+type palette struct {
+	mutex sync.Mutex
+	path  string
+
+	names []string
+	ids   map[string]types.BlockType
+	// set, when new blocks were added, but the palette wasn't written yet
+	dirty bool
+}
+
+// Palettes are shared between the saver goroutine and the main thread, same as regions
+var (
+	palettesMutex sync.Mutex
+	palettes      = make(map[string]*palette)
+)
+
+func newPalette(path string, names []string) *palette {
+	p := &palette{
+		path:  path,
+		names: append([]string(nil), names...),
+		ids:   make(map[string]types.BlockType),
+	}
+	for id, name := range p.names {
+		p.ids[name] = types.BlockType(id)
+	}
+	return p
+}
+
+func paletteFor(metadata types.Save) (*palette, error) {
+	path := filepath.Join(saveDirectory(metadata), paletteFile)
+
+	palettesMutex.Lock()
+	defer palettesMutex.Unlock()
+
+	if p, exists := palettes[path]; exists {
+		return p, nil
+	}
+
+	p, err := readPalette(path)
+	if err != nil {
+		return nil, err
+	}
+	palettes[path] = p
+	return p, nil
+}
+
+func readPalette(path string) (*palette, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return newPalette(path, legacyPalette), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	saved := SavedPalette{}
+	if err := gob.NewDecoder(f).Decode(&saved); err != nil {
+		return nil, &LoadError{Kind: ErrCorrupt, Path: path, Err: err}
+	}
+	return newPalette(path, saved.Names), nil
+}
+
+// closes all cached palettes under the given directory
+func closePalettes(dir string) {
+	palettesMutex.Lock()
+	defer palettesMutex.Unlock()
+
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	for path := range palettes {
+		if strings.HasPrefix(path, prefix) {
+			delete(palettes, path)
+		}
+	}
+}
+
+// encode returns saved ID for the runtime block type, adding the block to the palette if needed
+func (p *palette) encode(blockType types.BlockType) types.BlockType {
+	name := blocks.Name(blockType)
+	if name == "" {
+		// unregistered blocks are saved as empty ones
+		name = "empty"
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if id, exists := p.ids[name]; exists {
+		return id
+	}
+
+	id := types.BlockType(len(p.names))
+	p.names = append(p.names, name)
+	p.ids[name] = id
+	p.dirty = true
+	return id
+}
+
+// decode returns runtime block type for the saved ID
+func (p *palette) decode(id types.BlockType) (types.BlockType, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if int(id) >= len(p.names) {
+		return 0, fmt.Errorf("block ID %v is not in the palette", id)
+	}
+	name := p.names[id]
+
+	blockType, exists := blocks.TypeByName(name)
+	if !exists {
+		return 0, fmt.Errorf("unknown block %q", name)
+	}
+	return blockType, nil
+}
+
+// save writes the palette to the disk, if new blocks were added to it.
+// Must be called before any chunk using the new IDs is written
+func (p *palette) save() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.dirty {
+		return nil
+	}
+
+	os.MkdirAll(filepath.Dir(p.path), os.ModePerm)
+	err := util.WriteFileAtomic(p.path, func(w io.Writer) error {
+		return gob.NewEncoder(w).Encode(SavedPalette{Names: p.names})
+	})
+	if err != nil {
+		return err
+	}
+
+	p.dirty = false
+	return nil
+}
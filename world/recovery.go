@@ -0,0 +1,63 @@
+// Recovery of the world after a crash
+
+package world
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/3elDU/bamboo/types"
+)
+
+// Corrupt chunks and regions are moved to this directory, inside the world directory.
+// They are not deleted, so they can be inspected ( or repaired ) later
+const quarantineDirectory = "corrupt"
+
+// recoverWorld replays chunk writes, interrupted by a crash.
+// Chunks, that were damaged anyway, are found by their checksums, and quarantined, when they are loaded
+func recoverWorld(metadata types.Save) error {
+	j, err := journalFor(metadata)
+	if err != nil {
+		return err
+	}
+	replayed, err := j.replay()
+	if err != nil {
+		return err
+	}
+	if replayed > 0 {
+		log.Printf("recoverWorld() - replayed %v chunks from the journal", replayed)
+	}
+	return nil
+}
+
+// quarantineChunk copies chunk data to the quarantine directory, and removes the chunk from its region
+func quarantineChunk(metadata types.Save, cx, cy uint64, data []byte, reason error) {
+	log.Printf("quarantineChunk() - chunk %v, %v is corrupt, it will be generated again - %v", cx, cy, reason)
+
+	dir := filepath.Join(saveDirectory(metadata), quarantineDirectory)
+	os.MkdirAll(dir, os.ModePerm)
+
+	path := filepath.Join(dir, fmt.Sprintf("chunk_%v_%v_%v.gob", cx, cy, time.Now().Unix()))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("quarantineChunk() - failed to copy the chunk - %v", err)
+	}
+
+	region, err := regionFor(metadata, cx, cy, false)
+	if err != nil || region == nil {
+		return
+	}
+	if err := region.remove(cx, cy); err != nil {
+		log.Printf("quarantineChunk() - failed to remove the chunk from the region - %v", err)
+	}
+}
+
+// quarantineFile moves the whole file to the quarantine directory
+func quarantineFile(metadata types.Save, path string) error {
+	dir := filepath.Join(saveDirectory(metadata), quarantineDirectory)
+	os.MkdirAll(dir, os.ModePerm)
+
+	return os.Rename(path, filepath.Join(dir, fmt.Sprintf("%v.%v", filepath.Base(path), time.Now().Unix())))
+}
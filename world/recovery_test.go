@@ -0,0 +1,106 @@
+//go:build headless
+
+package world
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/3elDU/bamboo/types"
+)
+
+func TestQuarantine(t *testing.T) {
+	// writes a chunk, that isn't a valid gob, but matches its checksum
+	writeChunk := func(t *testing.T, metadata types.Save) {
+		if err := writeRecords(metadata, []chunkRecord{{X: 0, Y: 0, Data: []byte("not a chunk")}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// overwrites the first byte of the chunk data, or of the header, if offset is 0
+	damageRegion := func(t *testing.T, metadata types.Save, offset int64) {
+		f, err := os.OpenFile(regionPath(metadata, 0, 0), os.O_RDWR, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteAt([]byte{0xff}, offset); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, metadata types.Save)
+		load    func(metadata types.Save, x, y uint64) (*Chunk, error)
+		// whether the chunk is moved to the quarantine directory, and removed from the world
+		quarantined bool
+	}{
+		{
+			name: "chunk, that can't be decoded",
+			prepare: func(t *testing.T, metadata types.Save) {
+				writeChunk(t, metadata)
+			},
+			load:        LoadChunk,
+			quarantined: true,
+		},
+		{
+			name: "chunk with a wrong checksum",
+			prepare: func(t *testing.T, metadata types.Save) {
+				writeChunk(t, metadata)
+				damageRegion(t, metadata, int64(headerSectors*sectorSize))
+			},
+			load:        LoadChunk,
+			quarantined: true,
+		},
+		{
+			name: "region with a damaged header",
+			prepare: func(t *testing.T, metadata types.Save) {
+				os.MkdirAll(saveDirectory(metadata), os.ModePerm)
+				if err := os.WriteFile(regionPath(metadata, 0, 0), []byte("too short for a header"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			load:        LoadChunk,
+			quarantined: true,
+		},
+		{
+			name: "tools only read the chunk",
+			prepare: func(t *testing.T, metadata types.Save) {
+				writeChunk(t, metadata)
+				damageRegion(t, metadata, int64(headerSectors*sectorSize))
+			},
+			load:        ReadChunk,
+			quarantined: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := newTestSave(t)
+			tt.prepare(t, metadata)
+
+			if _, err := tt.load(metadata, 0, 0); !errors.Is(err, ErrCorrupt) {
+				t.Fatalf("loaded a corrupt chunk with error %v, expected ErrCorrupt", err)
+			}
+
+			copies, err := filepath.Glob(filepath.Join(saveDirectory(metadata), quarantineDirectory, "*"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.quarantined != (len(copies) == 1) {
+				t.Errorf("%v copies in the quarantine, expected the chunk to be quarantined - %v", len(copies), tt.quarantined)
+			}
+
+			// quarantined chunks are generated again
+			exists, err := ChunkExistsOnDisk(metadata, 0, 0)
+			if tt.quarantined && (exists || err != nil) {
+				t.Errorf("quarantined chunk is still in the world (%v)", err)
+			}
+			if !tt.quarantined && !exists && err == nil {
+				t.Error("the chunk is removed from the world, when it is only read")
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+// Region files.
+//
+// Instead of storing each chunk in its own file, chunks are grouped into regions,
+// RegionSize x RegionSize chunks each. A region file starts with a header,
+// which holds an offset table with one entry per chunk, followed by chunk data, aligned to sectors.
+// When a chunk grows and doesn't fit into its old place anymore,
+// it is moved to the first free gap large enough, or to the end of the file.
+
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/types"
+)
+
+const (
+	sectorSize = 4096
+	// how many chunks are there in a single region
+	regionChunks = int(config.RegionSize * config.RegionSize)
+	// size of a single entry in the offset table, in bytes
+	regionEntrySize = 16
+	// how many sectors the offset table takes
+	headerSectors = (regionChunks*regionEntrySize + sectorSize - 1) / sectorSize
+)
+
+// Entry in the region offset table
+// Offset and Sectors are measured in sectors, Length is measured in bytes.
+// Zero Sectors means that the chunk is not present in the region
+type regionEntry struct {
+	Offset  uint32
+	Sectors uint32
+	Length  uint32
+	// CRC32 of the chunk data, to detect torn writes
+	Checksum uint32
+}
+
+// returned, when chunk data doesn't match the checksum from the offset table
+var errChecksumMismatch = errors.New("chunk checksum mismatch")
+
+type regionFile struct {
+	mutex sync.Mutex
+
+	file   *os.File
+	header [regionChunks]regionEntry
+	// used[i] is true, when i'th sector of the file is occupied
+	used []bool
+}
+
+func sectorsFor(length int) uint32 {
+	return uint32((length + sectorSize - 1) / sectorSize)
+}
+
+// index of the chunk in the offset table
+func regionIndex(cx, cy uint64) int {
+	return int(cy%config.RegionSize*config.RegionSize + cx%config.RegionSize)
+}
+
+// opens the region file, creating it, if it doesn't exist yet
+func openRegionFile(path string) (*regionFile, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r := &regionFile{file: f}
+
+	// freshly created file, write an empty header
+	if info.Size() == 0 {
+		if err := f.Truncate(int64(headerSectors * sectorSize)); err != nil {
+			f.Close()
+			return nil, err
+		}
+		r.used = make([]bool, headerSectors)
+		for i := range r.used {
+			r.used[i] = true
+		}
+		return r, nil
+	}
+
+	if err := binary.Read(io.NewSectionReader(f, 0, int64(headerSectors*sectorSize)), binary.LittleEndian, &r.header); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read region header - %v", err)
+	}
+
+	totalSectors := int((info.Size() + sectorSize - 1) / sectorSize)
+	if totalSectors < headerSectors {
+		totalSectors = headerSectors
+	}
+	r.used = make([]bool, totalSectors)
+	for i := 0; i < headerSectors; i++ {
+		r.used[i] = true
+	}
+
+	for i, entry := range r.header {
+		if entry.Sectors == 0 {
+			continue
+		}
+
+		// drop the entries pointing outside of the file, or into the header
+		end := int(entry.Offset + entry.Sectors)
+		if int(entry.Offset) < headerSectors || end > totalSectors || entry.Length > entry.Sectors*sectorSize {
+			log.Printf("openRegionFile() - %v: invalid entry %v (%+v), dropping it", path, i, entry)
+			r.header[i] = regionEntry{}
+			continue
+		}
+
+		for s := int(entry.Offset); s < end; s++ {
+			r.used[s] = true
+		}
+	}
+
+	return r, nil
+}
+
+func (r *regionFile) exists(cx, cy uint64) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.header[regionIndex(cx, cy)].Sectors != 0
+}
+
+// read returns raw chunk data
+// if the chunk is not present in the region, returns nil
+// On error, whatever data has been read is still returned, so it can be quarantined
+func (r *regionFile) read(cx, cy uint64) ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry := r.header[regionIndex(cx, cy)]
+	if entry.Sectors == 0 {
+		return nil, nil
+	}
+
+	data := make([]byte, entry.Length)
+	if _, err := r.file.ReadAt(data, int64(entry.Offset)*sectorSize); err != nil {
+		return data, err
+	}
+	if crc32.ChecksumIEEE(data) != entry.Checksum {
+		return data, errChecksumMismatch
+	}
+	return data, nil
+}
+
+// finds a gap of free sectors large enough to hold n sectors
+// if there is none, returns the end of the file
+func (r *regionFile) allocate(n uint32) uint32 {
+	run := uint32(0)
+	for i, used := range r.used {
+		if used {
+			run = 0
+			continue
+		}
+
+		run++
+		if run == n {
+			return uint32(i) + 1 - n
+		}
+	}
+
+	return uint32(len(r.used)) - run
+}
+
+func (r *regionFile) markSectors(offset, count uint32, used bool) {
+	for int(offset+count) > len(r.used) {
+		r.used = append(r.used, false)
+	}
+	for s := offset; s < offset+count; s++ {
+		r.used[s] = used
+	}
+}
+
+// write stores raw chunk data in the region,
+// reusing the old place of the chunk, when it fits there
+func (r *regionFile) write(cx, cy uint64, data []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	index := regionIndex(cx, cy)
+	entry := r.header[index]
+	sectors := sectorsFor(len(data))
+
+	if sectors > entry.Sectors {
+		// chunk doesn't fit into the old place, free it and find a new one
+		r.markSectors(entry.Offset, entry.Sectors, false)
+		entry.Offset = r.allocate(sectors)
+	} else if sectors < entry.Sectors {
+		// chunk shrunk, free the remaining sectors
+		r.markSectors(entry.Offset+sectors, entry.Sectors-sectors, false)
+	}
+	entry.Sectors = sectors
+	entry.Length = uint32(len(data))
+	entry.Checksum = crc32.ChecksumIEEE(data)
+	r.markSectors(entry.Offset, entry.Sectors, true)
+
+	// pad the data to the sector boundary, so the file always consists of whole sectors
+	padded := make([]byte, int(sectors)*sectorSize)
+	copy(padded, data)
+	if _, err := r.file.WriteAt(padded, int64(entry.Offset)*sectorSize); err != nil {
+		return err
+	}
+
+	// update the offset table entry, only after the data has been written
+	return r.writeEntry(index, entry)
+}
+
+func (r *regionFile) writeEntry(index int, entry regionEntry) error {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, entry)
+	if _, err := r.file.WriteAt(buf.Bytes(), int64(index*regionEntrySize)); err != nil {
+		return err
+	}
+
+	r.header[index] = entry
+	return nil
+}
+
+// remove deletes the chunk from the region, freeing its sectors
+func (r *regionFile) remove(cx, cy uint64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	index := regionIndex(cx, cy)
+	entry := r.header[index]
+	if entry.Sectors == 0 {
+		return nil
+	}
+
+	r.markSectors(entry.Offset, entry.Sectors, false)
+	return r.writeEntry(index, regionEntry{})
+}
+
+// returns coordinates of all chunks, present in the region
+// rx and ry are region coordinates
+func (r *regionFile) chunks(rx, ry uint64) (coords []types.Vec2u) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, entry := range r.header {
+		if entry.Sectors == 0 {
+			continue
+		}
+		coords = append(coords, types.Vec2u{
+			X: rx*config.RegionSize + uint64(i)%config.RegionSize,
+			Y: ry*config.RegionSize + uint64(i)/config.RegionSize,
+		})
+	}
+	return
+}
+
+// sync flushes region contents to the disk
+func (r *regionFile) sync() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.file.Sync()
+}
+
+func (r *regionFile) close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.file.Close()
+}
+
+// Region files are shared between saver and loader goroutines, and the main thread,
+// so they are kept open in one place
+var (
+	regionsMutex sync.Mutex
+	regions      = make(map[string]*regionFile)
+)
+
+func regionPath(metadata types.Save, cx, cy uint64) string {
+	return filepath.Join(saveDirectory(metadata),
+		fmt.Sprintf("region_%v_%v.bin", cx/config.RegionSize, cy/config.RegionSize))
+}
+
+// returns the region, containing chunk at given chunk coordinates
+// if create is false, and region file doesn't exist, returns nil
+func regionFor(metadata types.Save, cx, cy uint64, create bool) (*regionFile, error) {
+	path := regionPath(metadata, cx, cy)
+
+	regionsMutex.Lock()
+	defer regionsMutex.Unlock()
+
+	if r, exists := regions[path]; exists {
+		return r, nil
+	}
+
+	if _, err := os.Stat(path); err != nil && !create {
+		return nil, nil
+	}
+
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	r, err := openRegionFile(path)
+	if err != nil {
+		return nil, err
+	}
+	regions[path] = r
+	return r, nil
+}
+
+// SavedChunks returns coordinates of all chunks, saved in the world regions
+func SavedChunks(metadata types.Save) ([]types.Vec2u, error) {
+	paths, err := filepath.Glob(filepath.Join(saveDirectory(metadata), "region_*_*.bin"))
+	if err != nil {
+		return nil, err
+	}
+
+	var coords []types.Vec2u
+	for _, path := range paths {
+		var rx, ry uint64
+		if _, err := fmt.Sscanf(filepath.Base(path), "region_%d_%d.bin", &rx, &ry); err != nil {
+			continue
+		}
+
+		region, err := regionFor(metadata, rx*config.RegionSize, ry*config.RegionSize, false)
+		if err != nil {
+			return nil, err
+		}
+		if region == nil {
+			continue
+		}
+		coords = append(coords, region.chunks(rx, ry)...)
+	}
+	return coords, nil
+}
+
+// closes all opened region files under the given directory
+func closeRegions(dir string) {
+	regionsMutex.Lock()
+	defer regionsMutex.Unlock()
+
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	for path, r := range regions {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if err := r.close(); err != nil {
+			log.Printf("closeRegions() - failed to close %v - %v", path, err)
+		}
+		delete(regions, path)
+	}
+}
+
+// ConvertChunkFiles migrates a world, saved in the old format ( one chunk_X_Y.gob file per chunk ),
+// to region files. Chunk files are deleted after they're written to the region.
+// Returns number of converted chunks.
+func ConvertChunkFiles(metadata types.Save) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(saveDirectory(metadata), "chunk_*_*.gob"))
+	if err != nil {
+		return 0, err
+	}
+
+	touched := make(map[*regionFile]bool)
+	converted := make([]string, 0, len(paths))
+	for _, path := range paths {
+		var cx, cy uint64
+		if _, err := fmt.Sscanf(filepath.Base(path), "chunk_%d_%d.gob", &cx, &cy); err != nil {
+			log.Printf("ConvertChunkFiles() - skipping %v - %v", path, err)
+			continue
+		}
+
+		// chunk files contain the same gob-encoded SavedChunk, as regions do,
+		// so the data can be copied as is
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+
+		r, err := regionFor(metadata, cx, cy, true)
+		if err != nil {
+			return 0, err
+		}
+		if err := r.write(cx, cy, data); err != nil {
+			return 0, err
+		}
+
+		touched[r] = true
+		converted = append(converted, path)
+	}
+
+	// delete the old files only when the regions are safely on the disk
+	for r := range touched {
+		if err := r.sync(); err != nil {
+			return 0, err
+		}
+	}
+	for _, path := range converted {
+		if err := os.Remove(path); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(converted), nil
+}
@@ -0,0 +1,122 @@
+//go:build headless
+
+package world
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+// regionOp writes a chunk of the given size into the region, or removes it, if the size is 0
+type regionOp struct {
+	cx   uint64
+	size int
+}
+
+// chunk data, that differs between the chunks and their sizes
+func regionData(op regionOp) []byte {
+	return bytes.Repeat([]byte{byte(op.cx + 1), byte(op.size)}, op.size/2)
+}
+
+func TestRegionWrite(t *testing.T) {
+	const h = uint32(headerSectors)
+
+	tests := []struct {
+		name string
+		ops  []regionOp
+		// expected entries after all operations, by the chunk X coordinate. Zero sectors means no chunk
+		want map[uint64]regionEntry
+	}{
+		{
+			name: "first chunk goes right after the header",
+			ops:  []regionOp{{0, 100}},
+			want: map[uint64]regionEntry{0: {Offset: h, Sectors: 1}},
+		},
+		{
+			name: "chunks are appended to the end",
+			ops:  []regionOp{{0, 100}, {1, 5000}, {2, 100}},
+			want: map[uint64]regionEntry{0: {Offset: h, Sectors: 1}, 1: {Offset: h + 1, Sectors: 2}, 2: {Offset: h + 3, Sectors: 1}},
+		},
+		{
+			name: "chunk, that fits, is rewritten in place",
+			ops:  []regionOp{{0, 5000}, {1, 100}, {0, 6000}},
+			want: map[uint64]regionEntry{0: {Offset: h, Sectors: 2}, 1: {Offset: h + 2, Sectors: 1}},
+		},
+		{
+			name: "growing chunk moves to the end",
+			ops:  []regionOp{{0, 100}, {1, 100}, {0, 5000}},
+			want: map[uint64]regionEntry{0: {Offset: h + 2, Sectors: 2}, 1: {Offset: h + 1, Sectors: 1}},
+		},
+		{
+			name: "growing chunk moves into a large enough gap",
+			ops:  []regionOp{{0, 100}, {1, 100}, {2, 100}, {3, 100}, {1, 0}, {2, 0}, {3, 5000}},
+			want: map[uint64]regionEntry{0: {Offset: h, Sectors: 1}, 1: {}, 2: {}, 3: {Offset: h + 1, Sectors: 2}},
+		},
+		{
+			name: "shrinking chunk frees its tail",
+			ops:  []regionOp{{0, 3 * sectorSize}, {1, 100}, {0, 100}, {2, 5000}},
+			want: map[uint64]regionEntry{0: {Offset: h, Sectors: 1}, 1: {Offset: h + 3, Sectors: 1}, 2: {Offset: h + 1, Sectors: 2}},
+		},
+		{
+			name: "removed chunk frees its sectors",
+			ops:  []regionOp{{0, 100}, {1, 100}, {0, 0}, {2, 100}},
+			want: map[uint64]regionEntry{0: {}, 1: {Offset: h + 1, Sectors: 1}, 2: {Offset: h, Sectors: 1}},
+		},
+		{
+			name: "removing a missing chunk does nothing",
+			ops:  []regionOp{{0, 0}, {1, 100}},
+			want: map[uint64]regionEntry{0: {}, 1: {Offset: h, Sectors: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "region_0_0.bin")
+			r, err := openRegionFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			last := make(map[uint64][]byte)
+			for _, op := range tt.ops {
+				if op.size == 0 {
+					err = r.remove(op.cx, 0)
+					delete(last, op.cx)
+				} else {
+					err = r.write(op.cx, 0, regionData(op))
+					last[op.cx] = regionData(op)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := r.close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// the offset table must survive reopening the file
+			r, err = openRegionFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.close()
+
+			for cx, want := range tt.want {
+				got := r.header[regionIndex(cx, 0)]
+				if got.Offset != want.Offset || got.Sectors != want.Sectors {
+					t.Errorf("chunk %v - offset %v, %v sectors; expected offset %v, %v sectors",
+						cx, got.Offset, got.Sectors, want.Offset, want.Sectors)
+				}
+
+				data, err := r.read(cx, 0)
+				if err != nil {
+					t.Errorf("chunk %v - %v", cx, err)
+				}
+				if !bytes.Equal(data, last[cx]) {
+					t.Errorf("chunk %v - read %v bytes, expected the last written %v bytes", cx, len(data), len(last[cx]))
+				}
+			}
+		})
+	}
+}
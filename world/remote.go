@@ -0,0 +1,153 @@
+// Remote worlds - worlds, replicated from a multiplayer server
+//
+// A remote world doesn't load, generate or save chunks itself, it requests them from the server instead.
+// Blocks, placed and broken by the player, are sent to the server, which checks the changes,
+// applies them and sends them back to all players.
+// Entities, saved with the chunks ( mobs ), are simulated by the server, and only moved by its updates.
+// The generator is still created from the seed, since it is needed for dummy chunks and spawn points.
+
+package world
+
+import (
+	"log"
+
+	"github.com/3elDU/bamboo/event"
+	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/worldgen"
+)
+
+// Remote is the connection to the server, the world is replicated from
+type Remote interface {
+	RequestChunk(cx, cy uint64)
+	// PlaceBlock and BreakBlock ask the server to change the block on behalf of the player
+	PlaceBlock(bx, by uint64, block types.Block)
+	StartBreaking(bx, by uint64)
+	BreakBlock(bx, by uint64)
+
+	// Receive* methods return the next update from the server.
+	// The second value is false, if there are no more updates
+	ReceiveChunk() (*Chunk, bool)
+	// ReceiveDenied returns the coordinates of the requested chunk, that the server refused to send
+	ReceiveDenied() (types.Vec2u, bool)
+	ReceiveBlock() (BlockChange, bool)
+	ReceiveEntity() (EntityUpdate, bool)
+	ReceiveTime() (types.WorldTime, bool)
+}
+
+// BlockChange is a block, changed by the server
+type BlockChange struct {
+	X, Y  uint64
+	Block types.Block
+}
+
+// EntityUpdate is an entity, spawned, moved or removed by the server.
+// Only the entities, saved with the chunks, are sent, the players are handled by the game
+type EntityUpdate struct {
+	ID uint32
+	// set, when the entity appears near the player
+	Entity types.Entity
+	// set, when the entity has moved
+	Position types.Vec2f
+	// set, when the entity is gone, or too far from the player
+	Removed bool
+}
+
+// NewRemoteWorld creates a world, which chunks come from the server
+func NewRemoteWorld(metadata types.Save, remote Remote) *World {
+	log.Printf("NewRemoteWorld - name %v; seed %v", metadata.Name, metadata.Seed)
+
+	return &World{
+		generator: worldgen.NewWorldgenForType(metadata.Seed, metadata.WorldType),
+		remote:    remote,
+
+		remoteEntities: make(map[uint32]types.Entity),
+
+		metadata: metadata,
+		events:   event.NewBus(),
+
+		chunks: make(map[types.Vec2u]*Chunk),
+	}
+}
+
+// Remote returns nil, if the world is local
+func (world *World) Remote() Remote {
+	return world.remote
+}
+
+// applies everything, that was received from the server since the last update
+func (world *World) receiveRemote() {
+	for {
+		chunk, ok := world.remote.ReceiveChunk()
+		if !ok {
+			break
+		}
+		world.replaceChunk(chunk)
+		event.Fire(world.events, &types.ChunkLoadedEvent{Chunk: chunk})
+	}
+
+	for {
+		coords, ok := world.remote.ReceiveDenied()
+		if !ok {
+			break
+		}
+		// forgetting the dummy chunk, so it is requested again on the next access.
+		// A chunk with entities is kept, it is forgotten by the unload loop, once they walk out
+		if chunk, exists := world.chunks[coords]; exists && chunk.dummy && len(chunk.entities) == 0 {
+			delete(world.chunks, coords)
+		}
+	}
+
+	for {
+		change, ok := world.remote.ReceiveBlock()
+		if !ok {
+			break
+		}
+		// changes in the chunks, that we don't have, will come with the chunks themselves
+		if world.ChunkExists(change.X/16, change.Y/16) {
+			world.setBlock(change.X, change.Y, change.Block)
+		}
+	}
+
+	for {
+		update, ok := world.remote.ReceiveEntity()
+		if !ok {
+			break
+		}
+		world.applyEntityUpdate(update)
+	}
+
+	for {
+		time, ok := world.remote.ReceiveTime()
+		if !ok {
+			break
+		}
+		world.SetTime(time)
+	}
+}
+
+func (world *World) applyEntityUpdate(update EntityUpdate) {
+	entity, exists := world.remoteEntities[update.ID]
+	// removed before it is moved, since it is looked up in the chunk at its position
+	if exists {
+		world.RemoveEntity(entity)
+	}
+
+	switch {
+	case update.Removed:
+		delete(world.remoteEntities, update.ID)
+	case update.Entity != nil:
+		world.remoteEntities[update.ID] = update.Entity
+		world.AddEntity(update.Entity)
+	case exists:
+		entity.SetPosition(update.Position)
+		// re-added on each move, since the chunk with the entity could have been unloaded
+		world.AddEntity(entity)
+	}
+}
+
+// LoadedChunk returns the chunk, if it has been generated or loaded from the disk, and requests it otherwise.
+// Unlike ChunkAt, it never returns dummy chunks
+func (world *World) LoadedChunk(cx, cy uint64) (*Chunk, bool) {
+	chunk := world.ChunkAt(cx, cy).(*Chunk)
+	return chunk, !chunk.dummy
+}
@@ -3,76 +3,161 @@
 package world
 
 import (
+	"bytes"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/3elDU/bamboo/blocks"
 	"github.com/3elDU/bamboo/config"
 	"github.com/3elDU/bamboo/types"
+	"github.com/3elDU/bamboo/util"
 	"github.com/google/uuid"
 )
 
-func init() {
-	// create saves directory, if it doesn't exist yet
-	os.Mkdir(config.WorldSaveDirectory, os.ModePerm)
-}
-
 // SaverLoader maintains chunk loading/saving queue,
 // while doing actual work on separate goroutine,
 // so we don't have any freezes on the main thread
 type SaverLoader struct {
 	Metadata types.Save
 
-	saveRequests     chan Chunk
+	saveRequests     chan saveRequest
 	loadRequestsPool map[types.Vec2u]bool
 	// loadRequestsPool keeps track of currently requested chunks,
 	// so that one same chunk can't be requested twice
 	loadRequests chan types.Vec2u
 	loaded       chan *Chunk
+	// coordinates of requested chunks, that turned out to be missing on the disk
+	missing chan types.Vec2u
+	// errors, after which the world can't be used, see World.Err()
+	failed chan error
+
+	// closed by Stop()
+	stop    chan struct{}
+	running sync.WaitGroup
+}
+
+type saveRequest struct {
+	record chunkRecord
+	// if not nil, it is closed after all previous requests have been written to the disk
+	done chan struct{}
 }
 
 func NewWorldSaverLoader(metadata types.Save) *SaverLoader {
 	return &SaverLoader{
 		Metadata: metadata,
 
-		saveRequests:     make(chan Chunk, 1024),
+		saveRequests:     make(chan saveRequest, 1024),
 		loadRequestsPool: make(map[types.Vec2u]bool),
 		loadRequests:     make(chan types.Vec2u, 256),
 		loaded:           make(chan *Chunk),
+		missing:          make(chan types.Vec2u, 256),
+		failed:           make(chan error, 16),
+		stop:             make(chan struct{}),
 	}
 }
 
 func (sl *SaverLoader) runSaver() {
+	defer sl.running.Done()
+
 	for {
-		chunk := <-sl.saveRequests
+		var first saveRequest
+		select {
+		case first = <-sl.saveRequests:
+		case <-sl.stop:
+			return
+		}
+
+		// collect everything, that is already queued, so it can be committed at once
+		batch := []saveRequest{first}
+		for len(sl.saveRequests) > 0 {
+			batch = append(batch, <-sl.saveRequests)
+		}
 
-		// FIXME: This is probably not very save
-		chunk.Save(sl.Metadata)
+		records := make([]chunkRecord, 0, len(batch))
+		for _, request := range batch {
+			if request.done == nil {
+				records = append(records, request.record)
+			}
+		}
+
+		j, err := journalFor(sl.Metadata)
+		if err == nil {
+			err = j.commit(records)
+		}
+		if err != nil {
+			// the journal, if it has been written, will be replayed on the next load
+			log.Printf("SaverLoader.runSaver() - failed to save %v chunks - %v", len(records), err)
+		}
+
+		for _, request := range batch {
+			if request.done != nil {
+				close(request.done)
+			}
+		}
 	}
 }
 
 func (sl *SaverLoader) runLoader() {
-	for {
-		request := <-sl.loadRequests
+	defer sl.running.Done()
 
-		c := LoadChunk(sl.Metadata, request.X, request.Y)
-		if c == nil {
-			// if the requested chunk doesn't exist, simply ignore the error and skip it
-			continue
+	for {
+		var request types.Vec2u
+		select {
+		case request = <-sl.loadRequests:
+		case <-sl.stop:
+			return
 		}
 
-		sl.loaded <- c
+		c, err := LoadChunk(sl.Metadata, request.X, request.Y)
+		switch {
+		case c != nil:
+			select {
+			case sl.loaded <- c:
+			case <-sl.stop:
+				return
+			}
+		case err == nil || errors.Is(err, ErrCorrupt):
+			if err != nil {
+				log.Printf("SaverLoader.runLoader() - %v", err)
+			}
+			// the chunk is gone ( e.g. it has been quarantined ), so it has to be generated again
+			select {
+			case sl.missing <- request:
+			case <-sl.stop:
+				return
+			}
+		default:
+			// e.g. the chunk was saved by a newer version of the game. Generating it again would overwrite it,
+			// so it stays requested, and is never loaded
+			select {
+			case sl.failed <- err:
+			default:
+				log.Printf("SaverLoader.runLoader() - %v", err)
+			}
+		}
 	}
 }
 
 func (sl *SaverLoader) Run() {
+	sl.running.Add(2)
 	go sl.runSaver()
 	go sl.runLoader()
 }
 
+// Stop waits for the chunk, that is being saved or loaded, and stops the goroutines.
+// Queued requests are dropped, so Flush() must be called before
+func (sl *SaverLoader) Stop() {
+	close(sl.stop)
+	sl.running.Wait()
+}
+
 // Returns newly loaded chunk
 // If there is no pending chunks, returns nil
 func (sl *SaverLoader) Receive() *Chunk {
@@ -85,9 +170,46 @@ func (sl *SaverLoader) Receive() *Chunk {
 	}
 }
 
+// ReceiveError returns an error, that happened while loading a chunk, and that can't be fixed by generating it again.
+// If there are no such errors, returns nil
+func (sl *SaverLoader) ReceiveError() error {
+	select {
+	case err := <-sl.failed:
+		return err
+	default:
+		return nil
+	}
+}
+
+// ReceiveMissing returns coordinates of a requested chunk, that couldn't be loaded from the disk
+// The second value is false, if there are no such chunks
+func (sl *SaverLoader) ReceiveMissing() (types.Vec2u, bool) {
+	select {
+	case coords := <-sl.missing:
+		delete(sl.loadRequestsPool, coords)
+		return coords, true
+	default:
+		return types.Vec2u{}, false
+	}
+}
+
 // Pushes chunk save request to the queue
+// The chunk is encoded immediately, so it can be safely modified after that
 func (sl *SaverLoader) Save(chunk *Chunk) {
-	sl.saveRequests <- *chunk
+	// a dummy chunk stands in for the one, that isn't loaded yet, or failed to load. It must not overwrite it
+	if !chunk.modified || chunk.dummy {
+		return
+	}
+
+	sl.saveRequests <- saveRequest{record: chunk.encode(sl.Metadata)}
+	chunk.modified = false
+}
+
+// Flush blocks until all queued chunks are written to the disk
+func (sl *SaverLoader) Flush() {
+	done := make(chan struct{})
+	sl.saveRequests <- saveRequest{done: done}
+	<-done
 }
 
 // Pushes chunk load reuqest to the queue
@@ -107,6 +229,7 @@ func (sl *SaverLoader) Load(cx, cy uint64) {
 // it contains all required data
 // + optional metadata, that can be written individually by each block
 type SavedBlock struct {
+	// ID of the block in the world palette, not the runtime block type
 	Type  types.BlockType
 	State interface{}
 }
@@ -114,58 +237,138 @@ type SavedBlock struct {
 // represents chunk on the disk
 // all chunks are converted to this structure before saving
 type SavedChunk struct {
-	X, Y uint64
-	Data [16][16]SavedBlock
+	// Version of the save format, the chunk was saved with
+	Version uint
+	X, Y    uint64
+	Data    [16][16]SavedBlock
+	// Only entities of registered types are saved ( see RegisterEntity )
+	Entities []SavedEntity
 }
 
-func Load(baseID, id uuid.UUID) *World {
+// ReadMetadata reads world metadata from the world.gob file in the given directory
+func ReadMetadata(dir string) (types.Save, error) {
+	path := filepath.Join(dir, config.WorldInfoFile)
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return types.Save{}, &LoadError{Kind: ErrNotFound, Path: path, Err: err}
+		}
+		return types.Save{}, err
+	}
+	defer f.Close()
+
+	metadata := types.Save{}
+	if err := gob.NewDecoder(f).Decode(&metadata); err != nil {
+		return types.Save{}, &LoadError{Kind: ErrCorrupt, Path: path, Err: err}
+	}
+
+	if metadata.Version > FormatVersion {
+		return metadata, &LoadError{
+			Kind: ErrVersionMismatch,
+			Path: path,
+			Err:  fmt.Errorf("format version %v, supported up to %v", metadata.Version, FormatVersion),
+		}
+	}
+
+	return metadata, nil
+}
+
+func Load(baseID, id uuid.UUID) (*World, error) {
 	saveDir := filepath.Join(config.WorldSaveDirectory, baseID.String(), id.String())
 
-	f, err := os.Open(filepath.Join(saveDir, config.WorldInfoFile))
+	metadata, err := ReadMetadata(saveDir)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("world.Load() - loaded metadata; seed - %v; format version - %v", metadata.Seed, metadata.Version)
+
+	// migrate chunks saved in the old format, if there are any
+	converted, err := ConvertChunkFiles(metadata)
 	if err != nil {
-		log.Panicf("world.Load() - invalid file descriptor - %v", err)
+		return nil, fmt.Errorf("failed to convert chunk files to regions - %w", err)
+	}
+	if converted > 0 {
+		log.Printf("world.Load() - converted %v chunk files to regions", converted)
+	}
+
+	if err := recoverWorld(metadata); err != nil {
+		return nil, fmt.Errorf("recovery failed - %w", err)
 	}
 
-	decoder := gob.NewDecoder(f)
-	metadata := new(types.Save)
-	if err := decoder.Decode(metadata); err != nil {
-		log.Panicf("world.Load() - failed to decode metadata - %v", err)
+	// without the palette, none of the chunks can be decoded
+	if _, err := paletteFor(metadata); err != nil {
+		return nil, err
 	}
 
-	log.Printf("world.Load() - loaded metadata; seed - %v", metadata.Seed)
+	// chunks are saved in the current format from now on, so the older versions of the game
+	// must refuse to open the world right away, instead of overwriting the chunks, they can't read
+	metadata.Version = FormatVersion
+	if err := writeMetadata(metadata); err != nil {
+		return nil, err
+	}
 
-	return NewWorld(*metadata)
+	return NewWorld(metadata), nil
 }
 
 // NOTE: world folder is named after the UUID, not after the world name
 // that is, to avoid folder collision
 func (world *World) Save() {
-	saveDir := filepath.Join(config.WorldSaveDirectory, world.metadata.BaseUUID.String(), world.metadata.UUID.String())
-
-	// make a save directory, if it doesn't exist yet
-	os.MkdirAll(saveDir, os.ModePerm)
-
-	// open world metadata file
-	f, err := os.Create(filepath.Join(saveDir, config.WorldInfoFile))
-	if err != nil {
-		log.Panicf("failed to create world metadata file")
+	// remote worlds are saved by the server
+	if world.remote != nil || world.closed {
+		return
 	}
-	defer f.Close()
 
-	// encode the metadata to it
-	encoder := gob.NewEncoder(f)
-	if err := encoder.Encode(world.metadata); err != nil {
-		log.Panicf("failed to encode world metadata")
+	// chunks are migrated when they're loaded, so the world is always saved in the current format
+	world.metadata.Version = FormatVersion
+	if err := writeMetadata(world.metadata); err != nil {
+		log.Panicf("failed to write world metadata - %v", err)
 	}
 
-	// loop over all loaded chunks, saving modified ones to the disk
+	// queue all modified chunks, and wait until they're on the disk
 	for _, chunk := range world.chunks {
-		chunk.Save(world.metadata)
+		world.saverLoader.Save(chunk)
 	}
+	world.saverLoader.Flush()
+}
+
+// Close saves the world, and closes its files, so they aren't kept open after leaving the world.
+// The world can't be used after that
+func (world *World) Close() {
+	if world.remote != nil || world.closed {
+		return
+	}
+
+	world.Save()
+	world.saverLoader.Stop()
+	world.closed = true
+
+	dir := saveDirectory(world.metadata)
+	closeRegions(dir)
+	closeJournals(dir)
+	closePalettes(dir)
+}
+
+func writeMetadata(metadata types.Save) error {
+	saveDir := saveDirectory(metadata)
+
+	// make a save directory, if it doesn't exist yet
+	os.MkdirAll(saveDir, os.ModePerm)
+
+	// write world metadata through a temporary file, so a crash won't leave it truncated
+	return util.WriteFileAtomic(filepath.Join(saveDir, config.WorldInfoFile), func(w io.Writer) error {
+		return gob.NewEncoder(w).Encode(metadata)
+	})
+}
+
+// returns path to the directory, where the world is stored
+func saveDirectory(metadata types.Save) string {
+	return filepath.Join(config.WorldSaveDirectory, metadata.BaseUUID.String(), metadata.UUID.String())
 }
 
 func ExistsOnDisk(metadata types.Save) bool {
-	path := filepath.Join(config.WorldSaveDirectory, metadata.BaseUUID.String(), metadata.UUID.String())
+	path := saveDirectory(metadata)
 
 	file, err := os.Open(path)
 	defer file.Close()
@@ -176,88 +379,175 @@ func ExistsOnDisk(metadata types.Save) bool {
 	return true
 }
 
-func ChunkExistsOnDisk(metadata types.Save, x, y uint64) bool {
-	path := filepath.Join(config.WorldSaveDirectory, metadata.BaseUUID.String(), metadata.UUID.String(),
-		fmt.Sprintf("chunk_%v_%v.gob", x, y))
+// ChunkExistsOnDisk returns an error, if the region with the chunk can't be opened
+func ChunkExistsOnDisk(metadata types.Save, x, y uint64) (bool, error) {
+	region, err := regionFor(metadata, x, y, false)
+	if err != nil {
+		return false, &LoadError{Kind: ErrCorrupt, Path: regionPath(metadata, x, y), Err: err}
+	}
+	if region == nil {
+		return false, nil
+	}
 
-	_, err := os.Stat(path)
-	return err == nil
+	return region.exists(x, y), nil
 }
 
-// if saved chunk doesn't exist, returns nil
-func LoadChunk(metadata types.Save, x, y uint64) *Chunk {
-	path := filepath.Join(config.WorldSaveDirectory, metadata.BaseUUID.String(), metadata.UUID.String(),
-		fmt.Sprintf("chunk_%v_%v.gob", x, y))
+// if saved chunk doesn't exist, returns nil, nil
+// Corrupt chunks are quarantined, and returned as ErrCorrupt
+func LoadChunk(metadata types.Save, x, y uint64) (*Chunk, error) {
+	return loadChunk(metadata, x, y, true)
+}
 
-	if _, err := os.Stat(path); err == nil {
-		f, err := os.Open(path)
-		if err != nil {
-			log.Panicf("failed to open a chunk - %v", err)
+// ReadChunk is the same as LoadChunk, but it leaves corrupt chunks as is
+// Meant for tools, that only inspect the save
+func ReadChunk(metadata types.Save, x, y uint64) (*Chunk, error) {
+	return loadChunk(metadata, x, y, false)
+}
+
+func loadChunk(metadata types.Save, x, y uint64, quarantine bool) (*Chunk, error) {
+	region, err := regionFor(metadata, x, y, false)
+	if err != nil {
+		// the header itself is damaged, so there is no way to tell where the chunks are
+		if quarantine {
+			log.Printf("loadChunk() - region %v is corrupt - %v", regionPath(metadata, x, y), err)
+			if err := quarantineFile(metadata, regionPath(metadata, x, y)); err != nil {
+				log.Printf("loadChunk() - failed to quarantine the region - %v", err)
+			}
 		}
+		return nil, &LoadError{Kind: ErrCorrupt, Path: regionPath(metadata, x, y), Err: err}
+	}
+	if region == nil {
+		return nil, nil
+	}
 
-		savedChunk := new(SavedChunk)
-		if err := gob.NewDecoder(f).Decode(savedChunk); err != nil {
-			log.Panicf("failed to decode a chunk - %v", err)
+	// quarantine the chunk, so it will be generated again, instead of failing over and over
+	corrupt := func(data []byte, err error) (*Chunk, error) {
+		if quarantine {
+			quarantineChunk(metadata, x, y, data, err)
 		}
+		return nil, &LoadError{
+			Kind: ErrCorrupt,
+			Path: fmt.Sprintf("%v (chunk %v, %v)", regionPath(metadata, x, y), x, y),
+			Err:  err,
+		}
+	}
 
-		c := NewChunk(x, y)
+	data, err := region.read(x, y)
+	if err != nil {
+		return corrupt(data, err)
+	}
+	if data == nil {
+		return nil, nil
+	}
 
-		// decode blocks
-		for x := uint(0); x < 16; x++ {
-			for y := uint(0); y < 16; y++ {
-				b := blocks.GetBlockByID(savedChunk.Data[x][y].Type)
-				b.LoadState(savedChunk.Data[x][y].State)
-				c.SetBlock(x, y, b)
-			}
+	savedChunk := new(SavedChunk)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(savedChunk); err != nil {
+		return corrupt(data, err)
+	}
+
+	if savedChunk.Version > FormatVersion {
+		return nil, &LoadError{
+			Kind: ErrVersionMismatch,
+			Path: regionPath(metadata, x, y),
+			Err:  fmt.Errorf("chunk %v, %v has format version %v", x, y, savedChunk.Version),
 		}
+	}
+	migrated, err := migrateChunk(savedChunk)
+	if err != nil {
+		return corrupt(data, err)
+	}
 
-		// mark chunk as unmodified, to avoid recursive loading/saving
-		c.modified = false
-		return c
+	p, err := paletteFor(metadata)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
+	c := NewChunk(x, y)
 
-func (c *Chunk) Save(metadata types.Save) {
-	// if chunk wasn't modified, saving is unnecessary
-	if !c.modified {
-		return
+	// decode blocks
+	for x := uint(0); x < 16; x++ {
+		for y := uint(0); y < 16; y++ {
+			blockType, err := p.decode(savedChunk.Data[x][y].Type)
+			if err != nil {
+				return corrupt(data, err)
+			}
+			b := blocks.GetBlockByID(blockType)
+			if err := b.LoadState(savedChunk.Data[x][y].State); err != nil {
+				return corrupt(data, err)
+			}
+			c.SetBlock(x, y, b)
+		}
 	}
 
-	path := filepath.Join(config.WorldSaveDirectory, metadata.BaseUUID.String(), metadata.UUID.String(),
-		fmt.Sprintf("chunk_%v_%v.gob", c.x, c.y))
+	if err := c.loadEntities(savedChunk.Entities); err != nil {
+		return corrupt(data, err)
+	}
 
-	f, err := os.Create(path)
+	// mark chunk as unmodified, to avoid recursive loading/saving
+	// migrated chunks are left modified, so they will be written back in the current format
+	c.modified = migrated
+	return c, nil
+}
+
+// encode serializes the chunk to the format, that's stored in the regions
+// Block types are translated through the world palette, which is written to the disk first, if it has grown
+func (c *Chunk) encode(metadata types.Save) chunkRecord {
+	p, err := paletteFor(metadata)
 	if err != nil {
-		log.Panicf("failed to create chunk save file - %v", err)
+		log.Panicf("failed to load the block palette - %v", err)
 	}
-	defer f.Close()
 
-	// serialize the chunk
 	chunk := SavedChunk{
-		X: c.x, Y: c.y,
+		Version: FormatVersion,
+		X:       c.x, Y: c.y,
 	}
 	for x := 0; x < 16; x++ {
 		for y := 0; y < 16; y++ {
 			block := c.blocks[x][y]
 			chunk.Data[x][y] = SavedBlock{
-				Type:  block.Type(),
+				Type:  p.encode(block.Type()),
 				State: block.State(),
 			}
 		}
 	}
 
-	encoder := gob.NewEncoder(f)
-	if err := encoder.Encode(chunk); err != nil {
+	if err := p.save(); err != nil {
+		log.Panicf("failed to save the block palette - %v", err)
+	}
+	chunk.Entities = c.savedEntities()
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(chunk); err != nil {
 		log.Panicf("failed to encode chunk")
 	}
 
+	return chunkRecord{X: c.x, Y: c.y, Data: buf.Bytes()}
+}
+
+// Save writes the chunk to the disk immediately, bypassing the SaverLoader queue
+func (c *Chunk) Save(metadata types.Save) {
+	// if chunk wasn't modified, saving is unnecessary
+	if !c.modified {
+		return
+	}
+
+	j, err := journalFor(metadata)
+	if err != nil {
+		log.Panicf("failed to open the journal - %v", err)
+	}
+	if err := j.commit([]chunkRecord{c.encode(metadata)}); err != nil {
+		log.Panicf("failed to save the chunk - %v", err)
+	}
+
 	c.modified = false
 }
 
 func DeleteWorld(metadata types.Save) {
 	path := filepath.Join(config.WorldSaveDirectory, metadata.BaseUUID.String())
+	// region files and journals of all sub-worlds have to be closed before deleting them
+	closeRegions(path)
+	closeJournals(path)
+	closePalettes(path)
 	if err := os.RemoveAll(path); err != nil {
 		log.Panicf("Failed to delete world %v - %v", metadata.BaseUUID, err)
 	}
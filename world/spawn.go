@@ -0,0 +1,55 @@
+// Spawn points
+//
+// The spawn point only depends on the seed, so the game, the server and the clients all pick the same one
+
+package world
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+)
+
+func isValidSpawnpoint(blockType types.BlockType) bool {
+	validBlocks := []types.BlockType{
+		blocks.Sand, blocks.Grass, blocks.Snow, blocks.ShortGrass, blocks.TallGrass, blocks.Flowers, blocks.RedMushroom, blocks.WhiteMushroom,
+		blocks.CaveFloor,
+	}
+
+	for _, blockType2 := range validBlocks {
+		if blockType == blockType2 {
+			return true
+		}
+	}
+	return false
+}
+
+// SpawnPoint picks a block, where a new player can stand
+func SpawnPoint(w types.World) types.Vec2f {
+	// use the same seed for reproducible spawnpoint generation
+	rng := rand.New(rand.NewSource(1))
+
+	x, y := 0, 0
+	it := 1
+	for {
+		// Pick X and Y coordinates from 256 to 768
+		x = rng.Intn(768-256) + 256
+		y = rng.Intn(768-256) + 256
+
+		// create a new generator so that it doesn't overwrite chunks in the world
+		c := NewChunk(uint64(x)/16, uint64(y)/16)
+		w.Generator().GenerateImmediately(c)
+		blockType := c.At(uint(x%16), uint(y%16)).Type()
+
+		if isValidSpawnpoint(blockType) {
+			break
+		}
+
+		it++
+	}
+	log.Printf("picked spawn point (%v, %v), took %v iterations", x, y, it)
+
+	return types.Vec2f{X: float64(x), Y: float64(y)}
+}
@@ -1,22 +1,34 @@
 package world
 
 import (
+	"errors"
+
 	"github.com/3elDU/bamboo/blocks"
 	"github.com/3elDU/bamboo/worldgen"
 	"log"
 
+	"github.com/3elDU/bamboo/clock"
 	"github.com/3elDU/bamboo/config"
-	"github.com/3elDU/bamboo/scene_manager"
+	"github.com/3elDU/bamboo/event"
 	"github.com/3elDU/bamboo/types"
 )
 
 type World struct {
 	generator   types.WorldGenerator
 	saverLoader *SaverLoader
+	// nil for local worlds. Remote worlds don't have a SaverLoader
+	remote Remote
+	// entities, sent by the server, by their IDs. Only set for remote worlds
+	remoteEntities map[uint32]types.Entity
 
 	metadata types.Save
+	events   *event.Bus
 
 	chunks map[types.Vec2u]*Chunk
+	// set, when a chunk can't be loaded, see Err()
+	err error
+	// set by Close()
+	closed bool
 }
 
 // Creates a new world, using given name and seed
@@ -27,13 +39,14 @@ func NewWorld(metadata types.Save) *World {
 	go generator.Run()
 
 	saverLoader := NewWorldSaverLoader(metadata)
-	go saverLoader.Run()
+	saverLoader.Run()
 
 	return &World{
 		generator:   generator,
 		saverLoader: saverLoader,
 
 		metadata: metadata,
+		events:   event.NewBus(),
 
 		chunks: make(map[types.Vec2u]*Chunk),
 	}
@@ -41,45 +54,103 @@ func NewWorld(metadata types.Save) *World {
 
 // Update - x and y are player coordinates
 func (world *World) Update() {
+	if world.remote != nil {
+		world.receiveRemote()
+	} else {
+		world.receiveLocal()
+	}
+
+	// each 30 ticks ( half a second ) check for chunks,
+	// that weren't accessed ( neither read, nor write ) for specified amount of ticks
+	// ( check config.go )
+	if clock.Ticks()%30 == 0 {
+		for coords, chunk := range world.chunks {
+			if clock.Ticks()-chunk.lastAccessed > config.ChunkUnloadDelay {
+				// a cancelled chunk is kept for another ChunkUnloadDelay
+				if !chunk.dummy && !event.Fire(world.events, &types.ChunkUnloadedEvent{Chunk: chunk}) {
+					chunk.lastAccessed = clock.Ticks()
+					continue
+				}
+				// dummy chunks aren't saved, so the entities, that walked into one, would be lost.
+				// It is kept, until the real chunk takes them, or they walk out
+				if chunk.dummy && len(chunk.entities) > 0 {
+					chunk.lastAccessed = clock.Ticks()
+					continue
+				}
+				// the server keeps remote chunks, so they are simply forgotten
+				if world.remote == nil {
+					world.saverLoader.Save(chunk)
+				}
+				delete(world.chunks, coords)
+			}
+		}
+	}
+
+	// blocks may look differently at night, so all chunks are redrawn at sunrise and sunset
+	previous := world.metadata.Time
+	world.metadata.Time++
+	if world.metadata.Time.IsNight() != previous.IsNight() {
+		for _, chunk := range world.chunks {
+			chunk.TriggerRedraw()
+		}
+	}
+	world.invalidateSkyLight(previous)
+
+	// Update all currently loaded chunks
+	for _, chunk := range world.chunks {
+		chunk.Update(world)
+	}
+	world.updateEntities()
+}
+
+// receives chunks from the generator and the SaverLoader
+func (world *World) receiveLocal() {
 	// receive newly generated chunks from world generator
 	chunks := world.generator.Receive()
 	for _, chunk := range chunks {
-		world.chunks[chunk.Coords()] = chunk.(*Chunk)
-		// Request redraw of each neighbor
-		for _, neighbor := range world.GetNeighbors(chunk.Coords().X, chunk.Coords().Y) {
-			neighbor.TriggerRedraw()
-		}
+		world.replaceChunk(chunk.(*Chunk))
+		world.spawnEntities(chunk.(*Chunk))
+		event.Fire(world.events, &types.ChunkGeneratedEvent{Chunk: chunk})
 	}
 
 	// receive newly loaded chunks
 	for {
 		if chunk := world.saverLoader.Receive(); chunk != nil {
-			world.chunks[chunk.Coords()] = chunk
-			// Request redraw of each neighbor
-			for _, neighbor := range world.GetNeighbors(chunk.Coords().X, chunk.Coords().Y) {
-				neighbor.TriggerRedraw()
-			}
+			world.replaceChunk(chunk)
+			event.Fire(world.events, &types.ChunkLoadedEvent{Chunk: chunk})
 		} else {
 			break
 		}
 	}
 
-	// each 30 ticks ( half a second ) check for chunks,
-	// that weren't accessed ( neither read, nor write ) for specified amount of ticks
-	// ( check config.go )
-	if scene_manager.Ticks()%30 == 0 {
-		for coords, chunk := range world.chunks {
-			if scene_manager.Ticks()-chunk.lastAccessed > config.ChunkUnloadDelay {
-				world.saverLoader.Save(chunk)
-				delete(world.chunks, coords)
-			}
+	if err := world.saverLoader.ReceiveError(); err != nil {
+		world.fail(err)
+	}
+
+	// chunks, that couldn't be loaded from the disk, are generated again
+	for {
+		coords, ok := world.saverLoader.ReceiveMissing()
+		if !ok {
+			break
 		}
+		world.generator.Generate(NewChunk(coords.X, coords.Y))
 	}
+}
 
-	// Update all currently loaded chunks
-	for _, chunk := range world.chunks {
-		chunk.Update(world)
+// replaceChunk puts a generated or loaded chunk in place of the dummy one
+func (world *World) replaceChunk(chunk *Chunk) {
+	// entities could have walked into the dummy chunk, while the real one was loading
+	if dummy, exists := world.chunks[chunk.Coords()]; exists && dummy != chunk {
+		chunk.entities = append(chunk.entities, dummy.entities...)
 	}
+	world.chunks[chunk.Coords()] = chunk
+
+	// Request redraw of each neighbor
+	for _, neighbor := range world.GetNeighbors(chunk.Coords().X, chunk.Coords().Y) {
+		neighbor.TriggerRedraw()
+	}
+	// emitters and walls of the new chunk may change the light of the neighbors
+	world.invalidateLight(chunk.Coords().X, chunk.Coords().Y)
 }
 
 func (world *World) ChunkAt(cx, cy uint64) types.Chunk {
@@ -94,24 +165,36 @@ func (world *World) ChunkAtB(bx, by uint64) types.Chunk {
 	_, exists := world.chunks[chunkCoordinates]
 
 	if !exists {
-		// try to load the chunk from disk first
-		if ChunkExistsOnDisk(world.metadata, cx, cy) {
-			// request chunk loading
-			world.saverLoader.Load(cx, cy)
+		if world.remote != nil {
+			world.remote.RequestChunk(cx, cy)
 		} else {
-			// request chunk generation
-			chunk := NewChunk(cx, cy)
-			world.generator.Generate(chunk)
+			world.requestLocal(cx, cy)
 		}
 		dummyChunk := NewChunk(cx, cy)
+		dummyChunk.dummy = true
 		world.generator.GenerateDummy(dummyChunk)
 		world.chunks[chunkCoordinates] = dummyChunk
 	}
 
-	world.chunks[chunkCoordinates].lastAccessed = scene_manager.Ticks()
+	world.chunks[chunkCoordinates].lastAccessed = clock.Ticks()
 	return world.chunks[chunkCoordinates]
 }
 
+// requestLocal requests loading of the chunk from the disk, or its generation, if it hasn't been saved yet
+func (world *World) requestLocal(cx, cy uint64) {
+	onDisk, err := ChunkExistsOnDisk(world.metadata, cx, cy)
+	switch {
+	case onDisk || errors.Is(err, ErrCorrupt):
+		// the loader quarantines the damaged region, and the chunk is generated again
+		world.saverLoader.Load(cx, cy)
+	case err != nil:
+		// the chunk may be on the disk, so it isn't generated, and stays dummy
+		world.fail(err)
+	default:
+		world.generator.Generate(NewChunk(cx, cy))
+	}
+}
+
 // There is no B suffix, because it's trivial that this function accepts block coordinates
 func (world *World) BlockAt(bx, by uint64) types.Block {
 	cx, cy := bx/16, by/16
@@ -124,17 +207,84 @@ func (world *World) BlockAt(bx, by uint64) types.Block {
 	return chunk.At(uint(bx%16), uint(by%16))
 }
 
+// SetBlock changes the block. On a remote world the change is only local, and is lost,
+// once the server sends the chunk again. Changes, made by the player, go through PlaceBlock and BreakBlock
 func (world *World) SetBlock(bx, by uint64, block types.Block) {
+	if world.remote != nil {
+		// chunks, that aren't received yet, can't be generated on the client
+		if world.ChunkExists(bx/16, by/16) {
+			world.setBlock(bx, by, block)
+		}
+		return
+	}
+
+	world.setBlock(bx, by, block)
+}
+
+// PlaceBlock places the block on behalf of the player.
+// On a remote world, the server is asked to place it. The change is applied right away,
+// so the player doesn't wait for the server, which sends the block back, if it rejects the change
+func (world *World) PlaceBlock(bx, by uint64, block types.Block) {
+	if world.remote != nil {
+		world.remote.PlaceBlock(bx, by, block)
+	}
+	world.SetBlock(bx, by, block)
+}
+
+// StartBreaking tells the server of a remote world, that the player has started to break the block,
+// so it can check, that the block isn't broken faster than it should be
+func (world *World) StartBreaking(bx, by uint64) {
+	if world.remote != nil {
+		world.remote.StartBreaking(bx, by)
+	}
+}
+
+// BreakBlock breaks the block on behalf of the player, if it can be broken, see BreakableAt().
+// On a remote world, the server is asked to break it, and it sends the drops to the player.
+// Same as with PlaceBlock(), the change is applied right away
+func (world *World) BreakBlock(bx, by uint64) {
+	_, replacement, ok := world.BreakableAt(bx, by)
+	if !ok {
+		return
+	}
+	if world.remote != nil {
+		world.remote.BreakBlock(bx, by)
+	}
+	world.SetBlock(bx, by, replacement)
+}
+
+// BreakableAt returns the block at the given coordinates, and the block, that is left after breaking it.
+// The third value is false, if the block can't be broken
+func (world *World) BreakableAt(bx, by uint64) (types.BreakableBlock, types.Block, bool) {
+	block, breakable := world.BlockAt(bx, by).(types.BreakableBlock)
+	if !breakable {
+		return nil, nil, false
+	}
+	replacement := block.BreaksInto()
+	if replacement == nil {
+		replacement = world.generator.BaseAt(bx, by)
+	}
+	// the natural ground is replaced with the same block, and would drop the items endlessly
+	if replacement.Type() == block.Type() {
+		return nil, nil, false
+	}
+	return block, replacement, true
+}
+
+func (world *World) setBlock(bx, by uint64, block types.Block) {
 	cx, cy := bx/16, by/16
 
 	if !world.ChunkExists(cx, cy) {
 		// generate a chunk immediately, if it doesn't exist
 		c := NewChunk(cx, cy)
 		world.generator.GenerateImmediately(c)
-		world.chunks[types.Vec2u{X: cx, Y: cy}] = c
+		world.replaceChunk(c)
+		world.spawnEntities(c)
+		event.Fire(world.events, &types.ChunkGeneratedEvent{Chunk: c})
 	}
 
 	world.chunks[types.Vec2u{X: cx, Y: cy}].SetBlock(uint(bx%16), uint(by%16), block)
+	world.invalidateLight(cx, cy)
 }
 
 func (world *World) ChunkExists(cx, cy uint64) bool {
@@ -182,6 +332,40 @@ func (world *World) Metadata() types.Save {
 	return world.metadata
 }
 
+func (world *World) Time() types.WorldTime {
+	return world.metadata.Time
+}
+
+// SetTime is used to keep the clock running, when the player switches between worlds of the same save
+func (world *World) SetTime(time types.WorldTime) {
+	previous := world.metadata.Time
+	world.metadata.Time = time
+	if time.IsNight() != previous.IsNight() {
+		for _, chunk := range world.chunks {
+			chunk.TriggerRedraw()
+		}
+	}
+	world.invalidateSkyLight(previous)
+}
+
+// Err returns the error, after which the world can't be played, e.g. a chunk, saved by a newer version of the game.
+// The chunk is never loaded, and isn't overwritten, so the world should be saved and closed
+func (world *World) Err() error {
+	return world.err
+}
+
+// fail remembers the first error, returned by Err()
+func (world *World) fail(err error) {
+	if world.err == nil {
+		log.Printf("World - %v", err)
+		world.err = err
+	}
+}
+
+func (world *World) Events() *event.Bus {
+	return world.events
+}
+
 func (world *World) Generator() types.WorldGenerator {
 	return world.generator
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/3elDU/bamboo/config"
 	"github.com/3elDU/bamboo/types"
 	"github.com/aquilax/go-perlin"
+	"log"
 	"math"
 	"math/rand"
 )
@@ -65,9 +66,15 @@ func height(gen *perlin.Perlin, x, y uint64, scale float64) float64 {
 type generatorImplementation interface {
 	generate(chunk types.Chunk)
 	generateDummy(chunk types.Chunk)
+	BaseAt(x, y uint64) types.Block
 	seed() int64
 }
 
+// implemented by generators, which support structures
+type structureGenerator interface {
+	registerStructure(structure Structure)
+}
+
 // Generator handles chunk generation queue, while the generation itself is handled by embedded class.
 // Generation runs in separate goroutine to reduce freezes
 type Generator struct {
@@ -142,6 +149,20 @@ func (generator *Generator) Receive() (chunks []types.Chunk) {
 	return
 }
 
+// RegisterStructure adds a structure to the generator
+// Must be called before any chunk is generated. Panics, if the generator doesn't support structures
+func (generator *Generator) RegisterStructure(structure Structure) {
+	implementation, ok := generator.implementation.(structureGenerator)
+	if !ok {
+		log.Panicf("%T doesn't support structures", generator.implementation)
+	}
+	implementation.registerStructure(structure)
+}
+
+func (generator *Generator) BaseAt(x, y uint64) types.Block {
+	return generator.implementation.BaseAt(x, y)
+}
+
 func (generator *Generator) Seed() int64 {
 	return generator.implementation.seed()
 }
@@ -0,0 +1,169 @@
+package worldgen
+
+import (
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+)
+
+// Feature is a block, that is generated on top of the biome ground with the given chance
+type Feature struct {
+	Chance float64
+	Block  func() types.Block
+}
+
+// Biome decides, which blocks are generated in a certain area of the overworld
+type Biome struct {
+	Name string
+
+	// Block, covering the land
+	Ground func() types.Block
+
+	// Uses secondary height.
+	// Below GroundHeight, the ground is left as is.
+	// Between GroundHeight and TreeHeight, foliage is generated.
+	// Above TreeHeight, trees are generated
+	GroundHeight float64
+	TreeHeight   float64
+
+	// Features are checked in order, each with its own chance.
+	// If none of them was chosen, DefaultFoliage is generated ( if it is set )
+	Foliage        []Feature
+	DefaultFoliage func() types.Block
+	// Can be nil, if the biome has no trees
+	Tree func() types.Block
+}
+
+func blockOfType(blockType types.BlockType) func() types.Block {
+	return func() types.Block {
+		return blocks.GetBlockByID(blockType)
+	}
+}
+
+var (
+	// Beach is chosen by height, not by the climate, so it is found on the shore of every island
+	Beach = &Biome{
+		Name:   "beach",
+		Ground: func() types.Block { return blocks.NewSandBlock(false) },
+		// sand is decorated with stones regardless of the height
+		GroundHeight: 0,
+		TreeHeight:   2,
+		Foliage: []Feature{
+			{Chance: 0.03, Block: func() types.Block { return blocks.NewSandBlock(true) }},
+		},
+	}
+
+	SnowyTundra = &Biome{
+		Name:         "snowy_tundra",
+		Ground:       blockOfType(blocks.Snow),
+		GroundHeight: 1.1,
+		TreeHeight:   1.35,
+		Tree:         blockOfType(blocks.PineTree),
+	}
+
+	RockyHighlands = &Biome{
+		Name:         "rocky_highlands",
+		Ground:       blockOfType(blocks.Grass),
+		GroundHeight: 0.95,
+		TreeHeight:   1.2,
+		Foliage: []Feature{
+			{Chance: 0.1, Block: blockOfType(blocks.TallGrass)},
+		},
+		DefaultFoliage: blockOfType(blocks.ShortGrass),
+		// boulders instead of trees
+		Tree: blockOfType(blocks.Stone),
+	}
+
+	Forest = &Biome{
+		Name:         "forest",
+		Ground:       blockOfType(blocks.Grass),
+		GroundHeight: 0.8,
+		TreeHeight:   1.1,
+		Foliage: []Feature{
+			{Chance: 0.015, Block: blockOfType(blocks.RedMushroom)},
+			{Chance: 0.015, Block: blockOfType(blocks.WhiteMushroom)},
+			{Chance: 0.03, Block: blockOfType(blocks.Flowers)},
+			{Chance: 0.1, Block: blockOfType(blocks.TallGrass)},
+		},
+		DefaultFoliage: blockOfType(blocks.ShortGrass),
+		Tree:           blockOfType(blocks.PineTree),
+	}
+
+	Meadow = &Biome{
+		Name:         "meadow",
+		Ground:       blockOfType(blocks.Grass),
+		GroundHeight: 0.9,
+		TreeHeight:   1.4,
+		Foliage: []Feature{
+			{Chance: 0.0075, Block: blockOfType(blocks.RedMushroom)},
+			{Chance: 0.0075, Block: blockOfType(blocks.WhiteMushroom)},
+			{Chance: 0.12, Block: blockOfType(blocks.Flowers)},
+			{Chance: 0.15, Block: blockOfType(blocks.TallGrass)},
+		},
+		DefaultFoliage: blockOfType(blocks.ShortGrass),
+		Tree:           blockOfType(blocks.PineTree),
+	}
+)
+
+// Climate levels, used as indices of biomeTable
+const (
+	cold = iota
+	temperate
+	warm
+)
+
+const (
+	dry = iota
+	normal
+	wet
+)
+
+// Land biomes, indexed by [temperature][moisture]
+var biomeTable = [3][3]*Biome{
+	cold:      {dry: RockyHighlands, normal: SnowyTundra, wet: SnowyTundra},
+	temperate: {dry: RockyHighlands, normal: Meadow, wet: Forest},
+	warm:      {dry: Meadow, normal: Meadow, wet: Forest},
+}
+
+// Perlin noise is concentrated around 1, so the thresholds are close to it
+const (
+	// Uses temperature height.
+	ColdHeight = 0.85
+	WarmHeight = 1.15
+
+	// Uses moisture height.
+	DryHeight = 0.85
+	WetHeight = 1.15
+)
+
+// splits the climate value ( 0 to 2 ) into three levels
+func climateLevel(value, low, high float64) int {
+	switch {
+	case value < low:
+		return 0
+	case value < high:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// LandBiome picks the biome from the biome table
+func LandBiome(temperature, moisture float64) *Biome {
+	return biomeTable[climateLevel(temperature, ColdHeight, WarmHeight)][climateLevel(moisture, DryHeight, WetHeight)]
+}
+
+// pick chooses the foliage block, using random value from 0 to 1
+// Returns nil, if the ground should be left empty
+func (biome *Biome) pick(random float64) types.Block {
+	for _, feature := range biome.Foliage {
+		if random < feature.Chance {
+			return feature.Block()
+		}
+		random -= feature.Chance
+	}
+
+	if biome.DefaultFoliage == nil {
+		return nil
+	}
+	return biome.DefaultFoliage()
+}
@@ -1,12 +1,18 @@
 package worldgen
 
 import (
+	"encoding/binary"
+	"hash/fnv"
+
 	"github.com/3elDU/bamboo/blocks"
 	"github.com/3elDU/bamboo/config"
 	"github.com/3elDU/bamboo/types"
 	"github.com/aquilax/go-perlin"
 )
 
+// Chance of a cave floor block to be a glowing mushroom
+const GlowingMushroomChance = 0.005
+
 type CaveGenerator struct {
 	noiseSeed int64
 	noise     *perlin.Perlin
@@ -24,17 +30,35 @@ func (generator *CaveGenerator) generate(chunk types.Chunk) {
 	for x := uint(0); x < 16; x++ {
 		for y := uint(0); y < 16; y++ {
 			coords := chunk.BlockCoords()
-			h := height(generator.noise, coords.X+uint64(x), coords.Y+uint64(y), config.PerlinNoiseScaleFactor/5)
+			bx, by := coords.X+uint64(x), coords.Y+uint64(y)
 
-			if h < 1 {
-				chunk.SetBlock(x, y, blocks.NewCaveFloorBlock())
+			if generator.isFloor(bx, by) && generator.glowingMushroomAt(bx, by) {
+				chunk.SetBlock(x, y, blocks.GetBlockByID(blocks.GlowingMushroom))
 			} else {
-				chunk.SetBlock(x, y, blocks.NewCaveWallBlock())
+				chunk.SetBlock(x, y, generator.BaseAt(bx, by))
 			}
 		}
 	}
 }
 
+func (generator *CaveGenerator) isFloor(x, y uint64) bool {
+	return height(generator.noise, x, y, config.PerlinNoiseScaleFactor/5) < 1
+}
+
+func (generator *CaveGenerator) BaseAt(x, y uint64) types.Block {
+	if generator.isFloor(x, y) {
+		return blocks.NewCaveFloorBlock()
+	}
+	return blocks.GetBlockByID(blocks.CaveWall)
+}
+
+// glowing mushrooms are the only source of light in the caves, besides the exit
+func (generator *CaveGenerator) glowingMushroomAt(x, y uint64) bool {
+	hash := fnv.New64a()
+	binary.Write(hash, binary.LittleEndian, [3]uint64{uint64(generator.noiseSeed), x, y})
+	return float64(hash.Sum64()%10000)/10000 < GlowingMushroomChance
+}
+
 func (generator *CaveGenerator) generateDummy(chunk types.Chunk) {
 	for x := uint(0); x < 16; x++ {
 		for y := uint(0); y < 16; y++ {
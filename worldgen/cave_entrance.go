@@ -0,0 +1,59 @@
+package worldgen
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/3elDU/bamboo/blocks"
+	"github.com/3elDU/bamboo/types"
+	"github.com/google/uuid"
+)
+
+// CaveEntrance is a single block, leading to the cave world
+type CaveEntrance struct{}
+
+func (s CaveEntrance) Name() string {
+	return "cave_entrance"
+}
+
+func (s CaveEntrance) Placement() StructurePlacement {
+	return StructurePlacement{
+		Spacing:    4,
+		Separation: 1,
+		Chance:     CaveEntranceChance,
+		Attempts:   3,
+	}
+}
+
+func (s CaveEntrance) Size() types.Vec2u {
+	return types.Vec2u{X: 1, Y: 1}
+}
+
+// valid positions for cave entrance are those that are surrounded by grass blocks on all sides
+func (s CaveEntrance) CanPlace(terrain Terrain, origin types.Vec2u) bool {
+	// neighbors would be out of the world
+	if origin.X == 0 || origin.Y == 0 {
+		return false
+	}
+	for x := origin.X - 1; x <= origin.X+1; x++ {
+		for y := origin.Y - 1; y <= origin.Y+1; y++ {
+			if terrain.BaseAt(x, y).Type() != blocks.Grass {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s CaveEntrance) Blocks(_ types.Vec2u, rng *rand.Rand) map[types.Vec2u]types.Block {
+	// kinda slow but reproducible with the same seed, which is the most important
+	id, err := uuid.NewRandomFromReader(rng)
+	if err != nil {
+		// this should really never happen
+		log.Panicf("failed to generate UUID for cave: %v", err)
+	}
+
+	return map[types.Vec2u]types.Block{
+		{X: 0, Y: 0}: blocks.NewCaveEntranceBlock(id),
+	}
+}
@@ -4,8 +4,6 @@ Functions related to world generation
 package worldgen
 
 import (
-	"github.com/google/uuid"
-	"log"
 	"math/rand"
 
 	"github.com/3elDU/bamboo/blocks"
@@ -15,27 +13,16 @@ import (
 )
 
 // Chances of generating certain structures, blocks, and other worldgen-related constants
+// Chances of the blocks, generated inside of the biomes, are defined in the biome table ( biome.go )
 const (
 	// Uses base height.
 	// Height, below which water will generate
 	WaterHeight = 1.0
-	// Height, below which sand will generate
+	// Height, below which beach will generate
 	SandHeight = 1.1
 
-	// Uses secondary height.
-	// Height, below which grass will generate
-	GrassHeight = 0.9
-	// Height, below which foliage will generate
-	FoliageHeight = 1.3
-
-	// %Chance of stones on sand being generated
-	SandStoneChance = 0.03
-	// %Chance of generating a mushroom
-	MushroomChance = 0.015
-	// %Chance of generating a flower
-	FlowerChance = 0.06
-	// %Chance of generating cave entrance in a chunk
-	CaveEntranceChance = 0.05
+	// %Chance of generating cave entrance in a structure cell ( 4x4 chunks )
+	CaveEntranceChance = 0.8
 )
 
 type OverworldGenerator struct {
@@ -43,6 +30,11 @@ type OverworldGenerator struct {
 	// Separate perlin noise generators for base blocks and vegetation/features
 	basePerlin      *perlin.Perlin
 	secondaryPerlin *perlin.Perlin
+	// Climate maps, used to choose the biome
+	temperaturePerlin *perlin.Perlin
+	moisturePerlin    *perlin.Perlin
+
+	structures []Structure
 }
 
 func NewOverworldGenerator(seed int64) types.WorldGenerator {
@@ -50,34 +42,54 @@ func NewOverworldGenerator(seed int64) types.WorldGenerator {
 	globalSeed := rand.New(rand.NewSource(seed))
 
 	// generate perlin noise seeds, using it
+	// new seeds are appended to the end, so that the seeds above don't change.
+	// Still, new noise changes the terrain itself: the climate maps, added for biomes, changed the chunks
+	// of existing worlds, that weren't generated yet, so they may not line up with the already saved ones
 	var (
-		baseSeed      = globalSeed.Int63()
-		secondarySeed = globalSeed.Int63()
+		baseSeed        = globalSeed.Int63()
+		secondarySeed   = globalSeed.Int63()
+		temperatureSeed = globalSeed.Int63()
+		moistureSeed    = globalSeed.Int63()
 	)
 
 	implementation := &OverworldGenerator{
-		noiseSeed:       seed,
-		basePerlin:      perlin.NewPerlin(2, 2, 16, baseSeed),
-		secondaryPerlin: perlin.NewPerlin(2, 2, 16, secondarySeed),
+		noiseSeed:         seed,
+		basePerlin:        perlin.NewPerlin(2, 2, 16, baseSeed),
+		secondaryPerlin:   perlin.NewPerlin(2, 2, 16, secondarySeed),
+		temperaturePerlin: perlin.NewPerlin(2, 2, 4, temperatureSeed),
+		moisturePerlin:    perlin.NewPerlin(2, 2, 4, moistureSeed),
 	}
+	implementation.registerStructure(CaveEntrance{})
 
 	return newGenerator(implementation)
 }
 
-// generates basic blocks ( sand, water, etc. )
-func (generator *OverworldGenerator) genBase(x, y uint64) types.Block {
+// BiomeAt returns the biome at the given block coordinates, or nil, if there is water
+func (generator *OverworldGenerator) BiomeAt(x, y uint64) *Biome {
 	baseHeight := applyCircularMask(float64(x), float64(y),
 		height(generator.basePerlin, x, y, config.PerlinNoiseScaleFactor),
 	)
 
 	switch {
-	case baseHeight <= WaterHeight: // Water
+	case baseHeight <= WaterHeight:
+		return nil
+	case baseHeight <= SandHeight:
+		return Beach
+	}
+
+	// climate changes slower than the terrain, so biomes span several islands
+	temperature := height(generator.temperaturePerlin, x, y, config.PerlinNoiseScaleFactor*4)
+	moisture := height(generator.moisturePerlin, x, y, config.PerlinNoiseScaleFactor*4)
+	return LandBiome(temperature, moisture)
+}
+
+// generates basic blocks ( sand, water, etc. )
+func (generator *OverworldGenerator) genBase(x, y uint64) types.Block {
+	biome := generator.BiomeAt(x, y)
+	if biome == nil {
 		return blocks.NewWaterBlock()
-	case baseHeight <= SandHeight: // Sand
-		return blocks.NewSandBlock(false)
-	default: // Grass
-		return blocks.NewGrassBlock()
 	}
+	return biome.Ground()
 }
 
 // Checks if 8 neighbors of the block are of the same type
@@ -102,88 +114,45 @@ func (generator *OverworldGenerator) checkNeighbors(desiredType types.BlockType,
 	return true
 }
 
-// generates block features, depending on previous block
+// generates block features, depending on the biome
 func (generator *OverworldGenerator) genFeatures(previous types.Block, x, y uint64) types.Block {
+	biome := generator.BiomeAt(x, y)
+	if biome == nil {
+		return previous
+	}
+
 	features := makeFeatures(generator.secondaryPerlin, x*16, y*16)
 
 	// do not apply circular mask, while generating block features
 	secondaryHeight := height(generator.secondaryPerlin, x, y, config.PerlinNoiseScaleFactor)
 
-	switch previous.Type() {
-	case blocks.Sand:
-		// With 3% change, generate sand with stones
-		if features.f1 <= SandStoneChance {
-			return blocks.NewSandBlock(true)
-		}
-	case blocks.Grass:
-		// generate features on grass, only if it is surrounded by grass on all sides
-		if !generator.checkNeighbors(blocks.Grass, x, y) {
-			return previous
-		}
+	// generate features only if the block is surrounded by the ground on all sides,
+	// beaches are the exception, since they're too narrow for that
+	if biome != Beach && !generator.checkNeighbors(previous.Type(), x, y) {
+		return previous
+	}
 
-		switch {
-		case secondaryHeight <= GrassHeight: // Empty grass
-			return previous
-		case secondaryHeight <= FoliageHeight: // Foliage
-			switch {
-			case features.f1 <= MushroomChance:
-				if features.f2 <= 0.5 {
-					return blocks.NewRedMushroomBlock()
-				} else {
-					return blocks.NewWhiteMushroomBlock()
-				}
-			case features.f1 <= FlowerChance:
-				return blocks.NewFlowersBlock()
-			}
-
-			return blocks.NewShortGrassBlock()
-		default: // Tree
-			return blocks.NewPineTreeBlock()
+	switch {
+	case secondaryHeight <= biome.GroundHeight: // Empty ground
+		return previous
+	case secondaryHeight <= biome.TreeHeight || biome.Tree == nil: // Foliage
+		if foliage := biome.pick(features.f1); foliage != nil {
+			return foliage
 		}
+		return previous
+	default: // Tree
+		return biome.Tree()
 	}
-
-	// pass the base block forward, without any modifications
-	return previous
 }
 
-func (generator *OverworldGenerator) generateStructures(chunk types.Chunk) {
-	chunkCoords := chunk.BlockCoords()
-	features := makeFeatures(generator.secondaryPerlin, chunkCoords.X, chunkCoords.Y)
-
-	if features.f1 < CaveEntranceChance {
-		// use a bunch of hardcoded possible coordinates for cave entrance
-		// it is just easier than trying to make reproducible RNG
-		possibleCoordinates := []types.Vec2u{
-			{X: 8, Y: 4},
-			{X: 4, Y: 12},
-			{X: 12, Y: 12},
-		}
-
-		// iterate over all possible coordinates, and pick the first valid pair
-		var chosenCoordinates types.Vec2u
-		valid := false
-		for _, coords := range possibleCoordinates {
-			// valid positions for cave entrance are those that are surrounded by grass blocks on all sides
-			if generator.checkNeighbors(blocks.Grass, chunkCoords.X+coords.X, chunkCoords.Y+coords.Y) {
-				chosenCoordinates = coords
-				valid = true
-				break
-			}
-		}
-		if !valid {
-			return
-		}
+// BaseAt implements Terrain
+func (generator *OverworldGenerator) BaseAt(x, y uint64) types.Block {
+	return generator.genBase(x, y)
+}
 
-		// kinda slow but reproducible with the same seed, which is the most important
-		rng := rand.New(rand.NewSource(features.i1))
-		id, err := uuid.NewRandomFromReader(rng)
-		if err != nil {
-			// this should really never happen
-			log.Panicf("failed to generate UUID for cave: %v", err)
-		}
-		log.Printf("cave at %v, %v: %v", chunkCoords.X+chosenCoordinates.X, chunkCoords.Y+chosenCoordinates.Y, id)
-		chunk.SetBlock(uint(chosenCoordinates.X), uint(chosenCoordinates.Y), blocks.NewCaveEntranceBlock(id))
-	}
+func (generator *OverworldGenerator) registerStructure(structure Structure) {
+	validatePlacement(structure)
+	generator.structures = append(generator.structures, structure)
 }
 
 func (generator *OverworldGenerator) generate(chunk types.Chunk) {
@@ -202,7 +171,7 @@ func (generator *OverworldGenerator) generate(chunk types.Chunk) {
 		}
 	}
 
-	generator.generateStructures(chunk)
+	placeStructures(generator.noiseSeed, generator, generator.structures, chunk)
 }
 
 // simply fills a chunk with water
@@ -0,0 +1,137 @@
+package worldgen
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"log"
+	"math/rand"
+
+	"github.com/3elDU/bamboo/config"
+	"github.com/3elDU/bamboo/types"
+)
+
+// Terrain gives structures a look at the world, before any structure is placed
+// It is computed directly from the noise, so it works for chunks, that aren't generated yet
+type Terrain interface {
+	// Returns the ground block, without foliage and trees
+	BaseAt(x, y uint64) types.Block
+}
+
+// StructurePlacement controls, how often a structure appears in the world
+//
+// The world is divided into cells of Spacing x Spacing chunks, with at most one structure per cell.
+// The structure origin is always in the first Spacing-Separation chunks of the cell,
+// so two structures are at least Separation chunks apart.
+type StructurePlacement struct {
+	Spacing    uint64
+	Separation uint64
+	// Chance of the structure being generated in a cell
+	Chance float64
+	// How many random positions inside the cell to try, before giving up
+	Attempts int
+}
+
+// Structure is a group of blocks, placed on top of the generated terrain
+// Structures can span chunk borders - each chunk places only the part of the structure, that falls into it.
+// Because of that, all methods must be deterministic: given the same arguments, return the same result
+type Structure interface {
+	// Unique name of the structure, also used to seed its placement
+	Name() string
+	Placement() StructurePlacement
+	// Size of the structure in blocks
+	Size() types.Vec2u
+	// CanPlace checks whether the structure can be placed with the top-left corner at origin
+	CanPlace(terrain Terrain, origin types.Vec2u) bool
+	// Blocks returns blocks of the structure, with coordinates relative to the origin.
+	// All randomness must come from rng
+	Blocks(origin types.Vec2u, rng *rand.Rand) map[types.Vec2u]types.Block
+}
+
+// returns random generator, unique for the structure and the cell
+func cellRandom(seed int64, structure Structure, cellX, cellY uint64) *rand.Rand {
+	hash := fnv.New64a()
+	hash.Write([]byte(structure.Name()))
+	binary.Write(hash, binary.LittleEndian, [3]uint64{uint64(seed), cellX, cellY})
+	return rand.New(rand.NewSource(int64(hash.Sum64())))
+}
+
+// locateStructure returns the origin of the structure in the cell, if there is one
+// The second value is the random generator, which should be used to generate the structure itself
+func locateStructure(seed int64, terrain Terrain, structure Structure, cellX, cellY uint64) (types.Vec2u, *rand.Rand, bool) {
+	placement := structure.Placement()
+	rng := cellRandom(seed, structure, cellX, cellY)
+
+	if rng.Float64() >= placement.Chance {
+		return types.Vec2u{}, nil, false
+	}
+
+	// range of blocks, where the origin can be
+	span := (placement.Spacing - placement.Separation) * 16
+	size := structure.Size()
+	for attempt := 0; attempt < placement.Attempts; attempt++ {
+		origin := types.Vec2u{
+			X: cellX*placement.Spacing*16 + uint64(rng.Int63n(int64(span))),
+			Y: cellY*placement.Spacing*16 + uint64(rng.Int63n(int64(span))),
+		}
+		if origin.X+size.X > config.WorldWidth || origin.Y+size.Y > config.WorldHeight {
+			continue
+		}
+		if structure.CanPlace(terrain, origin) {
+			return origin, rng, true
+		}
+	}
+
+	return types.Vec2u{}, nil, false
+}
+
+// placeStructures places parts of all the structures, that overlap the chunk
+func placeStructures(seed int64, terrain Terrain, structures []Structure, chunk types.Chunk) {
+	chunkCoords := chunk.BlockCoords()
+
+	for _, structure := range structures {
+		placement := structure.Placement()
+		size := structure.Size()
+		cellSize := placement.Spacing * 16
+
+		// cells, in which a structure overlapping this chunk can start
+		var minX, minY uint64
+		if chunkCoords.X > size.X {
+			minX = (chunkCoords.X - size.X) / cellSize
+		}
+		if chunkCoords.Y > size.Y {
+			minY = (chunkCoords.Y - size.Y) / cellSize
+		}
+		maxX := (chunkCoords.X + 15) / cellSize
+		maxY := (chunkCoords.Y + 15) / cellSize
+
+		for cellX := minX; cellX <= maxX; cellX++ {
+			for cellY := minY; cellY <= maxY; cellY++ {
+				origin, rng, ok := locateStructure(seed, terrain, structure, cellX, cellY)
+				if !ok {
+					continue
+				}
+
+				for offset, block := range structure.Blocks(origin, rng) {
+					x, y := origin.X+offset.X, origin.Y+offset.Y
+					if x < chunkCoords.X || x >= chunkCoords.X+16 || y < chunkCoords.Y || y >= chunkCoords.Y+16 {
+						continue
+					}
+					chunk.SetBlock(uint(x-chunkCoords.X), uint(y-chunkCoords.Y), block)
+				}
+
+				// log the structure only once, from the chunk where it starts
+				if origin.X/16 == chunkCoords.X/16 && origin.Y/16 == chunkCoords.Y/16 {
+					log.Printf("%v at %v, %v", structure.Name(), origin.X, origin.Y)
+				}
+			}
+		}
+	}
+}
+
+func validatePlacement(structure Structure) {
+	placement := structure.Placement()
+	if placement.Spacing == 0 || placement.Separation >= placement.Spacing {
+		log.Panicf("structure %v - separation must be less than spacing ( %v, %v )",
+			structure.Name(), placement.Separation, placement.Spacing)
+	}
+}